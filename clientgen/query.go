@@ -3,7 +3,7 @@ package clientgen
 import (
 	"fmt"
 
-	"github.com/Yamashou/gqlgenc/config"
+	"github.com/pleclech/gqlgenc/config"
 	"github.com/vektah/gqlparser/v2/ast"
 	"github.com/vektah/gqlparser/v2/parser"
 	"github.com/vektah/gqlparser/v2/validator"
@@ -0,0 +1,284 @@
+package clientgenv2
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/99designs/gqlgen/codegen/templates"
+	gqlgencConfig "github.com/pleclech/gqlgenc/config"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/validator"
+)
+
+// Batch is a batch of mutations sent in one document by its generated method, returning their results.
+type Batch struct {
+	// Name is the name of the batch, the one of the operation of its document.
+	Name string
+	// TypeName is the type of the results of the batch, holding the response of each mutation.
+	TypeName string
+	// Document is the document of the batch, QueryHash its hex SHA-256 hash and DocumentID its id with the id document mode.
+	Document   string
+	QueryHash  string
+	DocumentID string
+	// Mutations are the mutations of the batch, in the order of the configuration.
+	Mutations []*BatchMutation
+	// Headers are the headers of the transaction of the batch, sorted by name.
+	Headers []*BatchHeader
+}
+
+// BatchMutation is a mutation of a batch.
+type BatchMutation struct {
+	Name               string
+	ResponseStructName string
+	// Prefix is the prefix of the variables and of the aliases of the root fields of the mutation in the document of the batch.
+	Prefix string
+	// Args are the variables of the mutation, prefixed.
+	Args []*Argument
+}
+
+// BatchHeader is a header sent with the batches.
+type BatchHeader struct {
+	Name  string
+	Value string
+}
+
+// NewBatches returns the batches of mutationBatches, sorted by name, built from the documents of their operations.
+func NewBatches(schema *ast.Schema, queryDocuments []*ast.QueryDocument, operations []*Operation, generateConfig *gqlgencConfig.GenerateConfig) ([]*Batch, error) {
+	if generateConfig == nil || len(generateConfig.MutationBatches) == 0 {
+		return nil, nil
+	}
+	if generateConfig.Executor || !generateConfig.ShouldGenerateClient() {
+		return nil, fmt.Errorf("mutationBatches: the batches are sent by the generated client of clientv2, unlike the executor or without client")
+	}
+
+	queryDocumentsMap := queryDocumentMapByOperationName(queryDocuments)
+	operationsMap := make(map[string]*Operation, len(operations))
+	for _, operation := range operations {
+		operationsMap[operation.Name] = operation
+	}
+	var headers []*BatchHeader
+	if transaction := generateConfig.BatchTransaction; transaction != nil {
+		for name, value := range transaction.Headers {
+			headers = append(headers, &BatchHeader{Name: name, Value: value})
+		}
+		sort.Slice(headers, func(i, j int) bool {
+			return headers[i].Name < headers[j].Name
+		})
+	}
+
+	names := make([]string, 0, len(generateConfig.MutationBatches))
+	for name := range generateConfig.MutationBatches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	batches := make([]*Batch, 0, len(names))
+	for _, name := range names {
+		if _, ok := operationsMap[name]; ok {
+			return nil, fmt.Errorf("mutationBatches.%s: named as an operation", name)
+		}
+		batch, err := newBatch(schema, name, generateConfig.MutationBatches[name], queryDocumentsMap, operationsMap, generateConfig)
+		if err != nil {
+			return nil, fmt.Errorf("mutationBatches.%s: %w", name, err)
+		}
+		batch.Headers = headers
+		batches = append(batches, batch)
+	}
+
+	return batches, nil
+}
+
+// newBatch returns the batch name of the mutations, whose document has their variables and the aliases of their root fields
+// prefixed with the name of their operation.
+func newBatch(schema *ast.Schema, name string, mutations []string, queryDocuments map[string]*ast.QueryDocument, operations map[string]*Operation, generateConfig *gqlgencConfig.GenerateConfig) (*Batch, error) {
+	definition := &ast.OperationDefinition{Operation: ast.Mutation, Name: name}
+	if transaction := generateConfig.BatchTransaction; transaction != nil && transaction.Directive != "" {
+		definition.Directives = ast.DirectiveList{{Name: transaction.Directive}}
+	}
+	document := &ast.QueryDocument{Operations: ast.OperationList{definition}}
+	fragments := make(map[string]bool)
+	batch := &Batch{Name: name, TypeName: generateConfig.TypeName(templates.ToGo(name))}
+	for _, mutation := range mutations {
+		operation, queryDocument := operations[mutation], queryDocuments[mutation]
+		if operation == nil || queryDocument == nil {
+			return nil, fmt.Errorf("unknown operation %s", mutation)
+		}
+		if operation.Type != string(ast.Mutation) {
+			return nil, fmt.Errorf("%s is not a mutation", mutation)
+		}
+		for _, batchMutation := range batch.Mutations {
+			if batchMutation.Name == mutation {
+				return nil, fmt.Errorf("%s is batched twice", mutation)
+			}
+		}
+
+		prefix := mutation + "_"
+		batchMutation := &BatchMutation{Name: mutation, ResponseStructName: operation.ResponseStructName, Prefix: prefix}
+		for _, arg := range operation.Args {
+			batchMutation.Args = append(batchMutation.Args, &Argument{Variable: prefix + arg.Variable, Type: arg.Type, Required: arg.Required})
+		}
+		batch.Mutations = append(batch.Mutations, batchMutation)
+
+		source := queryDocument.Operations[0]
+		for _, variable := range source.VariableDefinitions {
+			prefixed := *variable
+			prefixed.Variable = prefix + variable.Variable
+			prefixed.DefaultValue = prefixVariables(variable.DefaultValue, prefix)
+			definition.VariableDefinitions = append(definition.VariableDefinitions, &prefixed)
+		}
+		for _, selection := range source.SelectionSet {
+			field, ok := selection.(*ast.Field)
+			if !ok {
+				return nil, fmt.Errorf("%s selects a fragment at its root, whose fields cannot be aliased", mutation)
+			}
+			aliased := prefixSelectionVariables(ast.SelectionSet{field}, prefix)[0].(*ast.Field)
+			aliased.Alias = prefix + field.Alias
+			definition.SelectionSet = append(definition.SelectionSet, aliased)
+		}
+		for _, fragment := range queryDocument.Fragments {
+			if usesVariables(fragment.SelectionSet) {
+				return nil, fmt.Errorf("the fragment %s of %s uses variables, which are prefixed in the batch", fragment.Name, mutation)
+			}
+			if !fragments[fragment.Name] {
+				fragments[fragment.Name] = true
+				document.Fragments = append(document.Fragments, fragment)
+			}
+		}
+	}
+
+	batch.Document = queryString(document)
+	if errs := validator.Validate(schema, document); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid document: %w", errs)
+	}
+	hash := sha256.Sum256([]byte(batch.Document))
+	batch.QueryHash = hex.EncodeToString(hash[:])
+	if generateConfig.DocumentMode == gqlgencConfig.DocumentModeID {
+		batch.DocumentID = generateConfig.DocumentID(name, batch.QueryHash)
+	}
+
+	return batch, nil
+}
+
+// prefixSelectionVariables returns a copy of the selections whose variables are prefixed.
+func prefixSelectionVariables(selectionSet ast.SelectionSet, prefix string) ast.SelectionSet {
+	if selectionSet == nil {
+		return nil
+	}
+
+	prefixed := make(ast.SelectionSet, 0, len(selectionSet))
+	for _, selection := range selectionSet {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			field := *selection
+			field.Arguments = make(ast.ArgumentList, 0, len(selection.Arguments))
+			for _, argument := range selection.Arguments {
+				copied := *argument
+				copied.Value = prefixVariables(argument.Value, prefix)
+				field.Arguments = append(field.Arguments, &copied)
+			}
+			field.Directives = prefixDirectiveVariables(selection.Directives, prefix)
+			field.SelectionSet = prefixSelectionVariables(selection.SelectionSet, prefix)
+			prefixed = append(prefixed, &field)
+		case *ast.InlineFragment:
+			fragment := *selection
+			fragment.Directives = prefixDirectiveVariables(selection.Directives, prefix)
+			fragment.SelectionSet = prefixSelectionVariables(selection.SelectionSet, prefix)
+			prefixed = append(prefixed, &fragment)
+		case *ast.FragmentSpread:
+			spread := *selection
+			spread.Directives = prefixDirectiveVariables(selection.Directives, prefix)
+			prefixed = append(prefixed, &spread)
+		}
+	}
+
+	return prefixed
+}
+
+// prefixDirectiveVariables returns a copy of the directives whose variables are prefixed.
+func prefixDirectiveVariables(directives ast.DirectiveList, prefix string) ast.DirectiveList {
+	if directives == nil {
+		return nil
+	}
+
+	prefixed := make(ast.DirectiveList, 0, len(directives))
+	for _, directive := range directives {
+		copied := *directive
+		copied.Arguments = make(ast.ArgumentList, 0, len(directive.Arguments))
+		for _, argument := range directive.Arguments {
+			copiedArgument := *argument
+			copiedArgument.Value = prefixVariables(argument.Value, prefix)
+			copied.Arguments = append(copied.Arguments, &copiedArgument)
+		}
+		prefixed = append(prefixed, &copied)
+	}
+
+	return prefixed
+}
+
+// prefixVariables returns a copy of value whose variables are prefixed.
+func prefixVariables(value *ast.Value, prefix string) *ast.Value {
+	if value == nil {
+		return nil
+	}
+
+	copied := *value
+	if value.Kind == ast.Variable {
+		copied.Raw = prefix + value.Raw
+	}
+	copied.Children = make(ast.ChildValueList, 0, len(value.Children))
+	for _, child := range value.Children {
+		copied.Children = append(copied.Children, &ast.ChildValue{Name: child.Name, Value: prefixVariables(child.Value, prefix), Position: child.Position})
+	}
+
+	return &copied
+}
+
+// usesVariables reports whether the selections use variables, in the arguments of their fields or in their directives.
+func usesVariables(selectionSet ast.SelectionSet) bool {
+	uses := false
+	var walk func(selectionSet ast.SelectionSet)
+	walk = func(selectionSet ast.SelectionSet) {
+		for _, selection := range selectionSet {
+			var directives ast.DirectiveList
+			switch selection := selection.(type) {
+			case *ast.Field:
+				for _, argument := range selection.Arguments {
+					uses = uses || hasVariable(argument.Value)
+				}
+				directives = selection.Directives
+				walk(selection.SelectionSet)
+			case *ast.InlineFragment:
+				directives = selection.Directives
+				walk(selection.SelectionSet)
+			case *ast.FragmentSpread:
+				directives = selection.Directives
+			}
+			for _, directive := range directives {
+				for _, argument := range directive.Arguments {
+					uses = uses || hasVariable(argument.Value)
+				}
+			}
+		}
+	}
+	walk(selectionSet)
+
+	return uses
+}
+
+// hasVariable reports whether value is or holds a variable.
+func hasVariable(value *ast.Value) bool {
+	if value == nil {
+		return false
+	}
+	if value.Kind == ast.Variable {
+		return true
+	}
+	for _, child := range value.Children {
+		if hasVariable(child.Value) {
+			return true
+		}
+	}
+
+	return false
+}
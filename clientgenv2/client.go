@@ -2,10 +2,14 @@ package clientgenv2
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/99designs/gqlgen/codegen/config"
 	"github.com/99designs/gqlgen/plugin"
-	gqlgencConfig "github.com/Yamashou/gqlgenc/config"
+	gqlgencConfig "github.com/pleclech/gqlgenc/config"
+	"github.com/pleclech/gqlgenc/introspection"
+	gqlgencPlugin "github.com/pleclech/gqlgenc/plugin"
+	"github.com/vektah/gqlparser/v2/ast"
 )
 
 var _ plugin.ConfigMutator = &Plugin{}
@@ -14,13 +18,16 @@ type Plugin struct {
 	queryFilePaths []string
 	Client         config.PackageConfig
 	GenerateConfig *gqlgencConfig.GenerateConfig
+	plugins        []gqlgencPlugin.Plugin
 }
 
-func New(queryFilePaths []string, client config.PackageConfig, generateConfig *gqlgencConfig.GenerateConfig) *Plugin {
+// New returns the client generator, running plugins in order once the client is generated.
+func New(queryFilePaths []string, client config.PackageConfig, generateConfig *gqlgencConfig.GenerateConfig, plugins ...gqlgencPlugin.Plugin) *Plugin {
 	return &Plugin{
 		queryFilePaths: queryFilePaths,
 		Client:         client,
 		GenerateConfig: generateConfig,
+		plugins:        plugins,
 	}
 }
 
@@ -36,7 +43,7 @@ func (p *Plugin) MutateConfig(cfg *config.Config) error {
 
 	// 1. 全体のqueryDocumentを1度にparse
 	// 1. Parse document from source of query
-	queryDocument, err := ParseQueryDocuments(cfg.Schema, querySources)
+	queryDocument, timeouts, err := ParseQueryDocuments(cfg.Schema, querySources)
 	if err != nil {
 		return fmt.Errorf(": %w", err)
 	}
@@ -48,9 +55,13 @@ func (p *Plugin) MutateConfig(cfg *config.Config) error {
 		return fmt.Errorf("parse query document failed: %w", err)
 	}
 
+	if err := checkFixedLists(cfg.Schema, p.GenerateConfig); err != nil {
+		return fmt.Errorf("invalid fixed lists: %w", err)
+	}
+
 	// 3. テンプレートと情報ソースを元にコード生成
 	// 3. Generate code from template and document source
-	sourceGenerator := NewSourceGenerator(cfg, p.Client)
+	sourceGenerator := NewSourceGenerator(cfg, p.Client, p.GenerateConfig)
 	source := NewSource(cfg.Schema, queryDocument, sourceGenerator, p.GenerateConfig)
 	query, err := source.Query()
 	if err != nil {
@@ -72,15 +83,123 @@ func (p *Plugin) MutateConfig(cfg *config.Config) error {
 		return fmt.Errorf("generating operation response failed: %w", err)
 	}
 
-	operations, err := source.Operations(queryDocuments)
+	operations, err := source.Operations(queryDocuments, operationResponses, timeouts)
 	if err != nil {
 		return fmt.Errorf("generating operation failed: %w", err)
 	}
 
+	services, err := NewServices(operations, p.GenerateConfig)
+	if err != nil {
+		return fmt.Errorf("generating services failed: %w", err)
+	}
+
+	if err := reportFieldNameCollisions(os.Stderr, sourceGenerator.FieldNameCollisions, p.GenerateConfig.FieldNameCollisionStrategy()); err != nil {
+		return fmt.Errorf("resolving field names failed: %w", err)
+	}
+
+	if err := checkUnions(sourceGenerator.Unions, p.GenerateConfig); err != nil {
+		return fmt.Errorf("generating unions failed: %w", err)
+	}
+
+	batches, err := NewBatches(cfg.Schema, queryDocuments, operations, p.GenerateConfig)
+	if err != nil {
+		return fmt.Errorf("generating mutation batches failed: %w", err)
+	}
+
+	if err := checkDocumentMode(operations, batches, p.GenerateConfig); err != nil {
+		return fmt.Errorf("invalid document mode: %w", err)
+	}
+
+	contextValues, err := NewContextValues(p.GenerateConfig)
+	if err != nil {
+		return fmt.Errorf("generating context values failed: %w", err)
+	}
+
 	generateClient := p.GenerateConfig.ShouldGenerateClient()
-	if err := RenderTemplate(cfg, query, mutation, fragments, operations, operationResponses, source.ResponseSubTypes(), generateClient, p.Client); err != nil {
+	timeScalars, err := NewTimeScalars(p.GenerateConfig)
+	if err != nil {
+		return fmt.Errorf("generating time scalars failed: %w", err)
+	}
+
+	pingQuery, err := NewPingQuery(cfg.Schema, p.GenerateConfig)
+	if err != nil {
+		return fmt.Errorf("invalid ping query: %w", err)
+	}
+
+	var getters []*Getters
+	if p.GenerateConfig != nil && p.GenerateConfig.Getters {
+		getters = NewAllGetters(query, mutation, fragments, source.ResponseSubTypes(), operationResponses)
+	}
+
+	var clones []string
+	if p.GenerateConfig != nil && p.GenerateConfig.Clone {
+		clones = NewClones(query, mutation, fragments, source.ResponseSubTypes(), operationResponses)
+	}
+
+	envPrefix, err := NewEnvPrefix(p.GenerateConfig)
+	if err != nil {
+		return fmt.Errorf("invalid env prefix: %w", err)
+	}
+
+	header, err := gqlgencConfig.NewHeader(p.GenerateConfig, p.Client)
+	if err != nil {
+		return fmt.Errorf("invalid header: %w", err)
+	}
+
+	schemaHash := introspection.SchemaHash(cfg.Schema)
+	if err := RenderTemplate(cfg, &TemplateData{
+		Query:             query,
+		Mutation:          mutation,
+		Fragment:          fragments,
+		Operation:         operations,
+		OperationResponse: operationResponses,
+		GenerateClient:    generateClient,
+		StructSources:     source.ResponseSubTypes(),
+		Unions:            sourceGenerator.Unions,
+		Getters:           getters,
+		Clones:            clones,
+		TimeScalars:       timeScalars,
+		Int64Scalars:      NewInt64Scalars(p.GenerateConfig),
+		TypenameChecks:    p.GenerateConfig != nil && p.GenerateConfig.TypenameChecks,
+		Executor:          p.GenerateConfig != nil && p.GenerateConfig.Executor,
+		Services:          services,
+		Batches:           batches,
+		ContextValues:     contextValues,
+		SchemaHash:        schemaHash,
+		PingQuery:         pingQuery,
+		EnvPrefix:         envPrefix,
+		DocumentMode:      NewDocumentMode(p.GenerateConfig),
+		Header:            header,
+	}, p.Client); err != nil {
 		return fmt.Errorf("template failed: %w", err)
 	}
 
+	data := newGenerateData(cfg, queryDocument, queryDocuments, p.Client)
+	for _, plugin := range p.plugins {
+		if err := plugin.GenerateCode(data); err != nil {
+			return fmt.Errorf("%s failed: %w", plugin.Name(), err)
+		}
+	}
+
 	return nil
 }
+
+// newGenerateData returns the data given to the plugins, queryDocuments being the documents of the operations of queryDocument.
+func newGenerateData(cfg *config.Config, queryDocument *ast.QueryDocument, queryDocuments []*ast.QueryDocument, client config.PackageConfig) *gqlgencPlugin.GenerateData {
+	operations := make([]*gqlgencPlugin.Operation, 0, len(queryDocuments))
+	for i, document := range queryDocuments {
+		operations = append(operations, &gqlgencPlugin.Operation{
+			Name:       queryDocument.Operations[i].Name,
+			Definition: queryDocument.Operations[i],
+			Document:   document,
+			Query:      queryString(document),
+		})
+	}
+
+	return &gqlgencPlugin.GenerateData{
+		Schema:        cfg.Schema,
+		QueryDocument: queryDocument,
+		Operations:    operations,
+		Client:        client,
+	}
+}
@@ -0,0 +1,529 @@
+package clientgenv2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/api"
+	codegenconfig "github.com/99designs/gqlgen/codegen/config"
+	"github.com/pleclech/gqlgenc/config"
+	"github.com/pleclech/gqlgenc/generator"
+	gqlgencPlugin "github.com/pleclech/gqlgenc/plugin"
+	"github.com/pleclech/gqlgenc/plugin/querylist"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"golang.org/x/tools/go/packages"
+)
+
+var update = flag.Bool("update", false, "update the golden files of the generated clients")
+
+// generate runs the plugin with the config of testdata/name and plugins, and returns the generated client.
+// The generation uses the global state of gqlgen templates, so the tests using it do not run in parallel.
+func generate(t *testing.T, name string, plugins ...gqlgencPlugin.Plugin) (string, error) {
+	t.Helper()
+
+	dir := filepath.Join("testdata", name)
+	t.Cleanup(func() {
+		os.RemoveAll(filepath.Join(dir, "gen"))
+	})
+
+	cfg, err := config.LoadConfig(filepath.Join(dir, ".gqlgenc.yml"))
+	require.NoError(t, err)
+
+	if err := generator.Generate(context.Background(), cfg, api.AddPlugin(New(cfg.Query, cfg.Client, cfg.Generate, plugins...))); err != nil {
+		return "", err
+	}
+
+	client, err := ioutil.ReadFile(cfg.Client.Filename)
+	require.NoError(t, err)
+
+	return string(client), nil
+}
+
+// requireGolden compares the generated client with testdata/name/client.go.golden, go test -update rewrites it.
+func requireGolden(t *testing.T, name, got string) {
+	t.Helper()
+
+	requireGoldenFile(t, filepath.Join("testdata", name, "client.go.golden"), got)
+}
+
+// requireCompiles loads the generated client of testdata/name with buildFlags, failing on its errors, and returns its package.
+func requireCompiles(t *testing.T, name string, buildFlags ...string) *types.Package {
+	t.Helper()
+
+	pkgs, err := packages.Load(&packages.Config{Mode: packages.NeedName | packages.NeedTypes, BuildFlags: buildFlags}, "github.com/pleclech/gqlgenc/clientgenv2/testdata/"+name+"/gen")
+	require.NoError(t, err)
+	require.Len(t, pkgs, 1)
+	require.Empty(t, pkgs[0].Errors)
+
+	return pkgs[0].Types
+}
+
+// requireGeneratedTests generates the client of testdata/name, compares it with its golden file
+// and runs the tests of testdata/name against the generated client.
+func requireGeneratedTests(t *testing.T, name string) {
+	t.Helper()
+
+	got, err := generate(t, name)
+	require.NoError(t, err)
+	requireGolden(t, name, got)
+
+	out, err := exec.Command("go", "test", "-count=1", "./"+filepath.Join("testdata", name)).CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+// requireGoldenFile compares got with the golden file, go test -update rewrites it.
+func requireGoldenFile(t *testing.T, golden, got string) {
+	t.Helper()
+
+	if *update {
+		require.NoError(t, ioutil.WriteFile(golden, []byte(got), 0o600))
+	}
+
+	want, err := ioutil.ReadFile(golden)
+	require.NoError(t, err)
+	require.Equal(t, string(want), got)
+}
+
+func TestFieldNameCollision(t *testing.T) {
+	got, err := generate(t, "collision")
+	require.NoError(t, err)
+	requireGolden(t, "collision", got)
+}
+
+func TestOperationTimeout(t *testing.T) {
+	got, err := generate(t, "timeout")
+	require.NoError(t, err)
+	requireGolden(t, "timeout", got)
+	requireCompiles(t, "timeout")
+}
+
+func TestNewPingQuery(t *testing.T) {
+	t.Parallel()
+
+	schema := gqlparser.MustLoadSchema(&ast.Source{Input: `type Query { user(id: ID!): User } type User { id: ID! }`})
+
+	query, err := NewPingQuery(schema, nil)
+	require.NoError(t, err)
+	require.Equal(t, "{ __typename }", query)
+
+	query, err = NewPingQuery(schema, &config.GenerateConfig{PingQuery: `{ user(id: "1") { id } }`})
+	require.NoError(t, err)
+	require.Equal(t, `{ user(id: "1") { id } }`, query)
+
+	_, err = NewPingQuery(schema, &config.GenerateConfig{PingQuery: "{ status }"})
+	require.EqualError(t, err, "\"{ status }\": input:1: Cannot query field \"status\" on type \"Query\".\n")
+}
+
+func TestIterator(t *testing.T) {
+	got, err := generate(t, "pagination")
+	require.NoError(t, err)
+	requireGolden(t, "pagination", got)
+	requireCompiles(t, "pagination")
+}
+
+func TestInt64Scalars(t *testing.T) {
+	got, err := generate(t, "scalars")
+	require.NoError(t, err)
+	requireGolden(t, "scalars", got)
+	requireCompiles(t, "scalars")
+}
+
+func TestOperationResults(t *testing.T) {
+	got, err := generate(t, "result")
+	require.NoError(t, err)
+	requireGolden(t, "result", got)
+
+	requireCompiles(t, "result")
+}
+
+func TestOperationVariables(t *testing.T) {
+	// the tests of the generated client compare the variables returned by Variables with the ones sent,
+	// and the operation metadata with the operations sent
+	requireGeneratedTests(t, "variables")
+}
+
+func TestRequiredVariables(t *testing.T) {
+	// the test of the generated client calls the operations without their required variables
+	requireGeneratedTests(t, "required")
+}
+
+func TestVariableDefaults(t *testing.T) {
+	got, err := generate(t, "defaults")
+	require.NoError(t, err)
+	requireGolden(t, "defaults", got)
+
+	requireCompiles(t, "defaults")
+}
+
+func TestGetters(t *testing.T) {
+	// the test of the generated client walks nil structs with the getters
+	requireGeneratedTests(t, "getters")
+}
+
+func TestClone(t *testing.T) {
+	// the test of the generated client modifies the clones
+	requireGeneratedTests(t, "clone")
+}
+
+func TestRawMethods(t *testing.T) {
+	// the test of the generated client compares the raw data with the bytes sent by the server
+	requireGeneratedTests(t, "raw")
+}
+
+func TestUnionInterfaces(t *testing.T) {
+	// the test of the generated client switches on the types of the decoded members
+	requireGeneratedTests(t, "unions")
+}
+
+func TestValidatedScalars(t *testing.T) {
+	// the test of the generated client fails to send invalid variables
+	requireGeneratedTests(t, "validated")
+
+	scalars, err := ioutil.ReadFile(filepath.Join("testdata", "validated", "gen", "scalars_gen.go"))
+	require.NoError(t, err)
+	requireGoldenFile(t, filepath.Join("testdata", "validated", "scalars_gen.go.golden"), string(scalars))
+}
+
+func TestMutationBatches(t *testing.T) {
+	// the test of the generated client decodes the result of each mutation of the batch
+	requireGeneratedTests(t, "batches")
+}
+
+func TestContextValues(t *testing.T) {
+	// the test of the generated client round-trips the values through the helpers and checks they are sent
+	requireGeneratedTests(t, "contextvalues")
+}
+
+func TestInputRawExtras(t *testing.T) {
+	// the test of the generated client sends the raw extras along the typed fields
+	requireGeneratedTests(t, "extras")
+
+	// the input types get the raw extras field, merged into their encoding
+	for _, name := range []string{"models_gen.go", "inputs_gen.go"} {
+		generated, err := ioutil.ReadFile(filepath.Join("testdata", "extras", "gen", name))
+		require.NoError(t, err)
+		requireGoldenFile(t, filepath.Join("testdata", "extras", name+".golden"), string(generated))
+	}
+}
+
+func TestExhaustiveEnums(t *testing.T) {
+	// the test of the generated client switches on the decoded enums, failing for a value added by the server
+	requireGeneratedTests(t, "enums")
+
+	// the enums get the marker of their Switch method, generated along the models
+	for _, name := range []string{"models_gen.go", "enums_gen.go"} {
+		generated, err := ioutil.ReadFile(filepath.Join("testdata", "enums", "gen", name))
+		require.NoError(t, err)
+		requireGoldenFile(t, filepath.Join("testdata", "enums", name+".golden"), string(generated))
+	}
+}
+
+func TestFixedLists(t *testing.T) {
+	// the test of the generated client decodes the lists into arrays, failing for another length
+	requireGeneratedTests(t, "arrays")
+}
+
+func TestDocumentMode(t *testing.T) {
+	// the test of the generated client sends the ids of the documents instead of their text
+	requireGeneratedTests(t, "documents")
+}
+
+func TestConnectionMerge(t *testing.T) {
+	// the test of the generated client merges the pages of the connections under their keys
+	requireGeneratedTests(t, "connections")
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"collision_error", `clientgen failed: resolving field names failed: go field names collide:
+GetUserNames_User: field "user_name" collides with "userName" on go name UserName, renamed UserName2
+GetUserAliasedIDs_User: field "ID" collides with "id" on go name ID, renamed ID3
+GetUserIDs_User: field "ID" collides with "id" on go name ID, renamed ID2
+GetUserFragment_User: field "displayName" collides with "display_name" on go name DisplayName, renamed DisplayName2`},
+		{"unions_error", "clientgen failed: generating unions failed: unionInterfaces: Search_Search of the union SearchResult does not select __typename on every member"},
+		{"validated_error", "validatedScalars.User: not a scalar of the schema"},
+		{"batches_error", "clientgen failed: generating mutation batches failed: mutationBatches.SaveProfile: GetUser is not a mutation"},
+		{"contextvalues_error", "clientgen failed: generating context values failed: contextValues.Region: the endpoint is already the value Endpoint"},
+		{"extras_error", "inputRawExtras: the input UserInput has a field rawExtras, named as the raw extras"},
+		{"arrays_error", "clientgen failed: invalid fixed lists: Point.label: String is not a list"},
+		{"documents_error", "clientgen failed: invalid document mode: documentIds: unknown operation GetUsers"},
+		{"connections_error", "clientgen failed: generating operation failed: invalid @connection directive: ListUsers: unknown argument name of users in the filter of users"},
+		// the schema declares its own @connection, its key being optional
+		{"connections_key_error", "clientgen failed: generating operation failed: invalid @connection directive: ListUsers: the @connection of users has no key"},
+		{"inputs_error", "inputInterface: the schema has a type GraphQLInput, named as the input interface"},
+		{"env_error", "clientgen failed: invalid env prefix: envPrefix: the client is read from the environment by clientv2, unlike the executor or without client"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := generate(t, tt.name)
+			require.EqualError(t, err, tt.want)
+		})
+	}
+}
+
+func TestTypeNamePrefix(t *testing.T) {
+	got, err := generate(t, "prefix")
+	require.NoError(t, err)
+	requireGolden(t, "prefix", got)
+
+	pkg := requireCompiles(t, "prefix")
+
+	// the generated types are prefixed, the models of gqlgen and the client are not
+	scope := pkg.Scope()
+	var generated []string
+	for _, name := range scope.Names() {
+		if _, ok := scope.Lookup(name).(*types.TypeName); ok {
+			generated = append(generated, name)
+		}
+	}
+	require.Equal(t, []string{
+		"Admin", "Client",
+		"GenFriendFields", "GenGetNode", "GenGetNodeResult", "GenGetNodeVariables", "GenGetNode_Node", "GenGetNode_Node_Admin",
+		"GenGetUser", "GenGetUserResult", "GenGetUserVariables", "GenGetUser_User_UserFields_Friends", "GenListUsers", "GenListUsersIterator", "GenListUsersResult",
+		"GenListUsersVariables", "GenListUsers_Users", "GenListUsers_Users_PageInfo", "GenMutation", "GenQuery", "GenRename",
+		"GenRenameResult", "GenRenameVariables", "GenRename_Rename", "GenUserFields", "GenUserFields_Friends",
+		"Node", "PageInfo", "User", "UserConnection",
+	}, generated)
+}
+
+func TestJSONTags(t *testing.T) {
+	got, err := generate(t, "jsontags")
+	require.NoError(t, err)
+	requireGolden(t, "jsontags", got)
+
+	// the models get graphql tags, and omitempty on their nullable fields only
+	models, err := ioutil.ReadFile(filepath.Join("testdata", "jsontags", "gen", "models_gen.go"))
+	require.NoError(t, err)
+	requireGoldenFile(t, filepath.Join("testdata", "jsontags", "models_gen.go.golden"), string(models))
+
+	requireCompiles(t, "jsontags")
+}
+
+func TestInputInterface(t *testing.T) {
+	got, err := generate(t, "inputs")
+	require.NoError(t, err)
+	requireGolden(t, "inputs", got)
+
+	// the input types implement the interface, the other models do not
+	models, err := ioutil.ReadFile(filepath.Join("testdata", "inputs", "gen", "models_gen.go"))
+	require.NoError(t, err)
+	requireGoldenFile(t, filepath.Join("testdata", "inputs", "models_gen.go.golden"), string(models))
+
+	pkg := requireCompiles(t, "inputs")
+
+	scope := pkg.Scope()
+	input, ok := scope.Lookup("GraphQLInput").Type().Underlying().(*types.Interface)
+	require.True(t, ok)
+	for _, name := range []string{"UserInput", "AddressInput", "SearchFilter"} {
+		require.True(t, types.Implements(scope.Lookup(name).Type(), input), name)
+	}
+	require.False(t, types.Implements(scope.Lookup("User").Type(), input))
+}
+
+func TestHeader(t *testing.T) {
+	t.Run("header", func(t *testing.T) {
+		got, err := generate(t, "header")
+		require.NoError(t, err)
+		requireGolden(t, "header", got)
+		models, err := ioutil.ReadFile("testdata/header/gen/models_gen.go")
+		require.NoError(t, err)
+		requireGoldenFile(t, "testdata/header/models_gen.go.golden", string(models))
+		enums, err := ioutil.ReadFile("testdata/header/gen/enums_gen.go")
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(string(enums), "//go:build integration && !windows\n"))
+
+		pkg := requireCompiles(t, "header", "-tags=integration")
+		require.NotNil(t, pkg.Scope().Lookup("NewClient"))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		client := codegenconfig.PackageConfig{Filename: "gen/client.go", Package: "gen"}
+		tests := []struct {
+			generateConfig *config.GenerateConfig
+			want           string
+		}{
+			{&config.GenerateConfig{Header: "Copyright 2026"}, `header line "Copyright 2026" is not a // comment`},
+			{&config.GenerateConfig{Header: "// {{.Package"}, "header: template: header:1: unclosed action"},
+			{&config.GenerateConfig{BuildTags: "integration &&"}, `build tags "integration &&": unexpected end of expression`},
+		}
+		for _, tt := range tests {
+			_, err := config.NewHeader(tt.generateConfig, client)
+			require.EqualError(t, err, tt.want)
+		}
+
+		header, err := config.NewHeader(nil, client)
+		require.NoError(t, err)
+		require.Equal(t, config.DefaultHeader+"\n", header)
+	})
+}
+
+func TestClientFromEnv(t *testing.T) {
+	got, err := generate(t, "env")
+	require.NoError(t, err)
+	requireGolden(t, "env", got)
+
+	requireCompiles(t, "env")
+}
+
+func TestServices(t *testing.T) {
+	t.Run("groups", func(t *testing.T) {
+		got, err := generate(t, "services")
+		require.NoError(t, err)
+		requireGolden(t, "services", got)
+
+		requireCompiles(t, "services")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		operations := []*Operation{{Name: "GetUser"}, {Name: "GetOrder", Args: []*Argument{{Variable: "s"}}}}
+		tests := []struct {
+			services map[string][]string
+			want     string
+		}{
+			{map[string][]string{"users": {"Get*"}, "orders": {"*Order"}}, "operation GetOrder matches the services Orders, Users"},
+			{map[string][]string{"users": {"[User"}}, `service Users: pattern "[User": syntax error in pattern`},
+			{map[string][]string{"client": {"*"}}, "service client collides with the field Client of the client"},
+			{map[string][]string{"users": {"*User"}, "Users": {"*Users"}}, "services Users and users have the same go name Users"},
+			{map[string][]string{"orders": {"*Order"}}, "operation GetOrder of the service Orders has the variable $s, named as a variable of the service methods"},
+		}
+		for _, tt := range tests {
+			_, err := NewServices(operations, &config.GenerateConfig{Services: tt.services})
+			require.EqualError(t, err, tt.want)
+		}
+	})
+}
+
+func TestSubscriptionChannels(t *testing.T) {
+	got, err := generate(t, "subscription")
+	require.NoError(t, err)
+	requireGolden(t, "subscription", got)
+
+	requireCompiles(t, "subscription")
+}
+
+func TestExecutor(t *testing.T) {
+	got, err := generate(t, "executor")
+	require.NoError(t, err)
+	requireGolden(t, "executor", got)
+
+	requireCompiles(t, "executor")
+}
+
+func TestClientFields(t *testing.T) {
+	got, err := generate(t, "client")
+	require.NoError(t, err)
+	requireGolden(t, "client", got)
+
+	requireCompiles(t, "client")
+}
+
+func TestConditionalFields(t *testing.T) {
+	got, err := generate(t, "conditional")
+	require.NoError(t, err)
+	requireGolden(t, "conditional", got)
+	requireCompiles(t, "conditional")
+}
+
+func TestTypenameChecks(t *testing.T) {
+	got, err := generate(t, "typename")
+	require.NoError(t, err)
+	requireGolden(t, "typename", got)
+	requireCompiles(t, "typename")
+}
+
+// recordingPlugin records the operations it is given and the plugins which ran before it.
+type recordingPlugin struct {
+	name       string
+	ran        *[]string
+	operations []string
+	err        error
+}
+
+func (p *recordingPlugin) Name() string {
+	return p.name
+}
+
+func (p *recordingPlugin) GenerateCode(data *gqlgencPlugin.GenerateData) error {
+	*p.ran = append(*p.ran, p.name)
+	for _, operation := range data.Operations {
+		p.operations = append(p.operations, operation.Name)
+	}
+
+	return p.err
+}
+
+func TestPlugins(t *testing.T) {
+	t.Run("run in order", func(t *testing.T) {
+		var ran []string
+		first := &recordingPlugin{name: "first", ran: &ran}
+		second := &recordingPlugin{name: "second", ran: &ran}
+		queryList := filepath.Join(t.TempDir(), "queries.json")
+
+		_, err := generate(t, "timeout", first, querylist.New(queryList), second)
+		require.NoError(t, err)
+		require.Equal(t, []string{"first", "second"}, ran)
+		require.Equal(t, []string{"GetUser", "Rename"}, first.operations)
+
+		content, err := ioutil.ReadFile(queryList)
+		require.NoError(t, err)
+		var queries map[string]string
+		require.NoError(t, json.Unmarshal(content, &queries))
+		require.Len(t, queries, 2)
+		require.Contains(t, queries["GetUser"], "query GetUser ($id: ID!) {")
+		require.NotContains(t, queries["GetUser"], "@timeout")
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var ran []string
+		failing := &recordingPlugin{name: "failing", ran: &ran, err: errors.New("boom")}
+		next := &recordingPlugin{name: "next", ran: &ran}
+
+		_, err := generate(t, "timeout", failing, next)
+		require.EqualError(t, err, "clientgen failed: failing failed: boom")
+		require.Equal(t, []string{"failing"}, ran)
+	})
+}
+
+func TestModelPackage(t *testing.T) {
+	got, err := generate(t, "layout")
+	require.NoError(t, err)
+	requireGolden(t, "layout", got)
+
+	// the client imports the models generated in their own package, which does not import the client
+	const (
+		modelPath  = "github.com/pleclech/gqlgenc/clientgenv2/testdata/layout/gen/model"
+		clientPath = "github.com/pleclech/gqlgenc/clientgenv2/testdata/layout/gen/client"
+	)
+	pkgs, err := packages.Load(&packages.Config{
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedImports | packages.NeedDeps,
+	}, modelPath, clientPath)
+	require.NoError(t, err)
+	require.Len(t, pkgs, 2)
+	for _, pkg := range pkgs {
+		require.Empty(t, pkg.Errors, pkg.PkgPath)
+
+		switch pkg.PkgPath {
+		case modelPath:
+			require.NotContains(t, pkg.Imports, "github.com/pleclech/gqlgenc/clientv2")
+			require.NotContains(t, pkg.Imports, "net/http")
+		case clientPath:
+			require.Contains(t, pkg.Imports, modelPath)
+		}
+	}
+}
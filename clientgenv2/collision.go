@@ -0,0 +1,124 @@
+package clientgenv2
+
+import (
+	"fmt"
+	"go/types"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pleclech/gqlgenc/config"
+)
+
+// FieldNameCollision is a go field name shared by several fields of a generated struct,
+// like the fields id and ID of a type or a field also selected by a fragment spread with another alias.
+//
+// The first field keeps the go name. With the suffix strategy each next field is renamed
+// by appending the smallest number from 2 not used by another field of the struct,
+// in the order of the fields in the struct, so the output is the same from one generation to the next.
+// Fields having the same go name, type and tags are the same response field and are merged instead.
+type FieldNameCollision struct {
+	// Struct is the name of the generated struct.
+	Struct string
+	// Name is the go name of the fields.
+	Name string
+	// Key is the response key of the renamed field and CollidesWith the one of the field keeping the name.
+	Key          string
+	CollidesWith string
+	// Renamed is the new go name of the field.
+	Renamed string
+}
+
+func (c *FieldNameCollision) String() string {
+	return fmt.Sprintf("%s: field %q collides with %q on go name %s, renamed %s", c.Struct, c.Key, c.CollidesWith, c.Name, c.Renamed)
+}
+
+// resolveFieldNames renames the fields of a struct sharing a go name and merges the duplicated ones.
+func resolveFieldNames(structName string, fields []*types.Var, fieldTags []string) ([]*types.Var, []string, []*FieldNameCollision) {
+	vars := make([]*types.Var, 0, len(fields))
+	tags := make([]string, 0, len(fields))
+	first := make(map[string]int, len(fields))
+	var colliding []int
+	for i, field := range fields {
+		j, ok := first[field.Name()]
+		if !ok {
+			first[field.Name()] = len(vars)
+		} else {
+			if types.Identical(vars[j].Type(), field.Type()) && tags[j] == fieldTags[i] {
+				continue
+			}
+			colliding = append(colliding, len(vars))
+		}
+		vars = append(vars, field)
+		tags = append(tags, fieldTags[i])
+	}
+
+	if len(colliding) == 0 {
+		return vars, tags, nil
+	}
+
+	used := make(map[string]bool, len(vars))
+	for name := range first {
+		used[name] = true
+	}
+	collisions := make([]*FieldNameCollision, 0, len(colliding))
+	for _, i := range colliding {
+		field := vars[i]
+		renamed := field.Name()
+		for n := 2; used[renamed]; n++ {
+			renamed = field.Name() + strconv.Itoa(n)
+		}
+		used[renamed] = true
+
+		vars[i] = types.NewField(field.Pos(), field.Pkg(), renamed, field.Type(), field.Embedded())
+		collisions = append(collisions, &FieldNameCollision{
+			Struct:       structName,
+			Name:         field.Name(),
+			Key:          responseKey(tags[i]),
+			CollidesWith: responseKey(tags[first[field.Name()]]),
+			Renamed:      renamed,
+		})
+	}
+
+	return vars, tags, collisions
+}
+
+// responseKey returns the json name of a struct tag.
+func responseKey(tag string) string {
+	name := reflect.StructTag(tag).Get("json")
+	if i := strings.Index(name, ","); i != -1 {
+		name = name[:i]
+	}
+
+	return name
+}
+
+// reportFieldNameCollisions writes a warning for each collision to w,
+// or fails listing them all when the strategy is error.
+func reportFieldNameCollisions(w io.Writer, collisions []*FieldNameCollision, strategy string) error {
+	seen := make(map[string]bool, len(collisions))
+	messages := make([]string, 0, len(collisions))
+	for _, collision := range collisions {
+		message := collision.String()
+		if seen[message] {
+			continue
+		}
+		seen[message] = true
+		messages = append(messages, message)
+	}
+
+	if len(messages) == 0 {
+		return nil
+	}
+
+	if strategy == config.FieldNameCollisionError {
+		return fmt.Errorf("go field names collide:\n%s", strings.Join(messages, "\n"))
+	}
+
+	for _, message := range messages {
+		fmt.Fprintf(w, "warning: %s\n", message)
+	}
+
+	return nil
+}
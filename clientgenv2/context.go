@@ -0,0 +1,65 @@
+package clientgenv2
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/99designs/gqlgen/codegen/templates"
+	gqlgencConfig "github.com/pleclech/gqlgenc/config"
+)
+
+// ContextValue is a value of the requests carried by their context, generated with typed helpers, like WithAuthToken
+// and AuthTokenFrom, and sent by the generated client with the interceptor of its use.
+type ContextValue struct {
+	// Name is the go name of the value, like AuthToken, and Var the name of its variables, like authToken.
+	Name string
+	Var  string
+	// Header is the header carrying the value, after Prefix, when the value is not the Endpoint or the IdempotencyKey.
+	Header         string
+	Prefix         string
+	Endpoint       bool
+	IdempotencyKey bool
+}
+
+// NewContextValues returns the context values of contextValues, sorted by name.
+func NewContextValues(generateConfig *gqlgencConfig.GenerateConfig) ([]*ContextValue, error) {
+	if generateConfig == nil || len(generateConfig.ContextValues) == 0 {
+		return nil, nil
+	}
+	if generateConfig.Executor || !generateConfig.ShouldGenerateClient() {
+		return nil, fmt.Errorf("contextValues: the values are sent by the generated client of clientv2, unlike the executor or without client")
+	}
+
+	names := make([]string, 0, len(generateConfig.ContextValues))
+	for name := range generateConfig.ContextValues {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	values := make([]*ContextValue, 0, len(names))
+	var endpoint, idempotencyKey string
+	for _, name := range names {
+		contextValue := generateConfig.ContextValues[name]
+		if contextValue.Endpoint {
+			if endpoint != "" {
+				return nil, fmt.Errorf("contextValues.%s: the endpoint is already the value %s", name, endpoint)
+			}
+			endpoint = name
+		}
+		if contextValue.IdempotencyKey {
+			if idempotencyKey != "" {
+				return nil, fmt.Errorf("contextValues.%s: the idempotency key is already the value %s", name, idempotencyKey)
+			}
+			idempotencyKey = name
+		}
+		values = append(values, &ContextValue{
+			Name:           templates.ToGo(name),
+			Var:            templates.ToGoPrivate(name),
+			Header:         contextValue.Header,
+			Prefix:         contextValue.Prefix,
+			Endpoint:       contextValue.Endpoint,
+			IdempotencyKey: contextValue.IdempotencyKey,
+		})
+	}
+
+	return values, nil
+}
@@ -0,0 +1,81 @@
+package clientgenv2
+
+import (
+	"go/types"
+	"strconv"
+
+	"github.com/99designs/gqlgen/codegen/templates"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// VariableDefault is the generated constant of the default value of a variable of an operation,
+// like ListUsersDefaultFirst for $first: Int = 10 in ListUsers.
+type VariableDefault struct {
+	Name     string
+	Variable string
+	// Type is the type of the constant, the one of the variable without pointer.
+	Type types.Type
+	// Value is the go literal of the default value.
+	Value string
+}
+
+// NewVariableDefaults returns the constants of the default values of the variables of operation.
+// args are the arguments of the variables of the operation, in the order of their definitions.
+// The defaults of the variables whose types have no constants, like lists and input objects, are left out.
+func NewVariableDefaults(operation *ast.OperationDefinition, args []*Argument) []*VariableDefault {
+	var defaults []*VariableDefault
+	for i, definition := range operation.VariableDefinitions {
+		if definition.DefaultValue == nil {
+			continue
+		}
+
+		typ, _ := derefType(args[i].Type)
+		value, ok := constantLiteral(definition.DefaultValue, typ)
+		if !ok {
+			continue
+		}
+
+		defaults = append(defaults, &VariableDefault{
+			Name:     templates.ToGo(operation.Name) + "Default" + templates.ToGo(definition.Variable),
+			Variable: definition.Variable,
+			Type:     typ,
+			Value:    value,
+		})
+	}
+
+	return defaults
+}
+
+// constantLiteral returns the go literal of value as a constant of typ, false if typ has no constants of value.
+func constantLiteral(value *ast.Value, typ types.Type) (string, bool) {
+	basic, ok := typ.Underlying().(*types.Basic)
+	if !ok {
+		return "", false
+	}
+
+	info := basic.Info()
+	switch value.Kind {
+	case ast.IntValue:
+		switch {
+		case info&types.IsNumeric != 0:
+			return value.Raw, true
+		case info&types.IsString != 0:
+			// an ID given as an Int
+			return strconv.Quote(value.Raw), true
+		}
+	case ast.FloatValue:
+		if info&types.IsFloat != 0 {
+			return value.Raw, true
+		}
+	case ast.StringValue, ast.BlockValue, ast.EnumValue:
+		if info&types.IsString != 0 {
+			return strconv.Quote(value.Raw), true
+		}
+	case ast.BooleanValue:
+		if info&types.IsBoolean != 0 {
+			return value.Raw, true
+		}
+	}
+
+	return "", false
+}
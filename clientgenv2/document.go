@@ -0,0 +1,55 @@
+package clientgenv2
+
+import (
+	"fmt"
+	"sort"
+
+	gqlgencConfig "github.com/pleclech/gqlgenc/config"
+)
+
+// documentModes are the clientv2.DocumentMode constants of the document modes, none for the text of the documents.
+var documentModes = map[string]string{
+	gqlgencConfig.DocumentModeHash: "PersistedHash",
+	gqlgencConfig.DocumentModeID:   "RegisteredID",
+}
+
+// NewDocumentMode returns the clientv2.DocumentMode constant the generated client sends the documents with,
+// empty when it sends their text.
+func NewDocumentMode(generateConfig *gqlgencConfig.GenerateConfig) string {
+	if generateConfig == nil {
+		return ""
+	}
+
+	return documentModes[generateConfig.DocumentMode]
+}
+
+// checkDocumentMode fails when the documents cannot be sent as configured: the document mode is applied by the transport
+// of clientv2, and the ids of the documents must be the ones of operations or batches.
+func checkDocumentMode(operations []*Operation, batches []*Batch, generateConfig *gqlgencConfig.GenerateConfig) error {
+	if generateConfig == nil {
+		return nil
+	}
+	if NewDocumentMode(generateConfig) != "" && (!generateConfig.ShouldGenerateClient() || generateConfig.Executor) {
+		return fmt.Errorf("documentMode: %s is sent by the generated client of clientv2, unlike the executor or without client", generateConfig.DocumentMode)
+	}
+
+	names := make(map[string]bool, len(operations))
+	for _, operation := range operations {
+		names[operation.Name] = true
+	}
+	for _, batch := range batches {
+		names[batch.Name] = true
+	}
+	ids := make([]string, 0, len(generateConfig.DocumentIDs))
+	for name := range generateConfig.DocumentIDs {
+		ids = append(ids, name)
+	}
+	sort.Strings(ids)
+	for _, name := range ids {
+		if !names[name] {
+			return fmt.Errorf("documentIds: unknown operation %s", name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package clientgenv2
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+	"strings"
+
+	gqlgencConfig "github.com/pleclech/gqlgenc/config"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// checkFixedLists checks the lists of fixed length of the config are list fields of the schema, of a positive length.
+func checkFixedLists(schema *ast.Schema, generateConfig *gqlgencConfig.GenerateConfig) error {
+	if generateConfig == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(generateConfig.FixedLists))
+	for name := range generateConfig.FixedLists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		typeName, fieldName, ok := strings.Cut(name, ".")
+		if !ok {
+			return fmt.Errorf("%s: want Type.field", name)
+		}
+		definition := schema.Types[typeName]
+		if definition == nil {
+			return fmt.Errorf("%s: unknown type %s", name, typeName)
+		}
+		field := definition.Fields.ForName(fieldName)
+		if field == nil {
+			return fmt.Errorf("%s: unknown field %s of %s", name, fieldName, typeName)
+		}
+		if field.Type.Elem == nil {
+			return fmt.Errorf("%s: %s is not a list", name, field.Type)
+		}
+		if length := generateConfig.FixedLists[name]; length <= 0 {
+			return fmt.Errorf("%s: invalid length %d", name, length)
+		}
+	}
+
+	return nil
+}
+
+// fixedLength returns the length of the list of the field when it is configured as a list of fixed length.
+func (r *SourceGenerator) fixedLength(field *ast.Field) (int, bool) {
+	if r.generate == nil || field.ObjectDefinition == nil {
+		return 0, false
+	}
+	length, ok := r.generate.FixedLists[field.ObjectDefinition.Name+"."+field.Name]
+
+	return length, ok
+}
+
+// fixedListType returns the go array of length of the slice typ of a list, a pointer to it for a nullable list.
+func fixedListType(typ types.Type, length int, nullable bool) types.Type {
+	slice, ok := typ.(*types.Slice)
+	if !ok {
+		return typ
+	}
+	array := types.NewArray(slice.Elem(), int64(length))
+	if nullable {
+		return types.NewPointer(array)
+	}
+
+	return array
+}
@@ -0,0 +1,107 @@
+package clientgenv2
+
+import (
+	"go/types"
+)
+
+// Getters are the generated methods of a struct type returning its fields, nil-safe: called on a nil struct,
+// they return the zero values of the fields, so that optional nested objects are walked without nil checks,
+// like res.GetUser().GetAddress().GetCity().
+type Getters struct {
+	// TypeName is the name of the struct type.
+	TypeName string
+	Fields   []*Getter
+}
+
+// Getter is the generated method returning a field.
+type Getter struct {
+	Name  string
+	Field string
+	Type  types.Type
+	// Pointer reports whether the getter returns a pointer to the field, a struct value,
+	// for the getters of the struct to be called on the result.
+	Pointer bool
+}
+
+// NewGetters returns the getters of the struct type typ named name, nil if it is not a struct.
+// The fields whose getter would have the name of another field get none.
+func NewGetters(name string, typ types.Type) *Getters {
+	structType, ok := typ.Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+
+	fields := make(map[string]bool, structType.NumFields())
+	for i := 0; i < structType.NumFields(); i++ {
+		fields[structType.Field(i).Name()] = true
+	}
+
+	getters := &Getters{TypeName: name}
+	for i := 0; i < structType.NumFields(); i++ {
+		field := structType.Field(i)
+		getter := "Get" + field.Name()
+		if fields[getter] {
+			continue
+		}
+
+		_, isStruct := field.Type().Underlying().(*types.Struct)
+		getters.Fields = append(getters.Fields, &Getter{
+			Name:    getter,
+			Field:   field.Name(),
+			Type:    field.Type(),
+			Pointer: isStruct,
+		})
+	}
+
+	return getters
+}
+
+// NewAllGetters returns the getters of the generated struct types, in the order of their declarations,
+// named as by the template.
+func NewAllGetters(query *Query, mutation *Mutation, fragments []*Fragment, structSources []*StructSource, operationResponses []*OperationResponse) []*Getters {
+	var all []*Getters
+	for _, generated := range generatedTypes(query, mutation, fragments, structSources, operationResponses) {
+		if getters := NewGetters(generated.name, generated.typ); getters != nil {
+			all = append(all, getters)
+		}
+	}
+
+	return all
+}
+
+// NewClones returns the names of the generated struct types, in the order of their declarations, which get a Clone method.
+func NewClones(query *Query, mutation *Mutation, fragments []*Fragment, structSources []*StructSource, operationResponses []*OperationResponse) []string {
+	var names []string
+	for _, generated := range generatedTypes(query, mutation, fragments, structSources, operationResponses) {
+		if _, ok := generated.typ.Underlying().(*types.Struct); ok {
+			names = append(names, generated.name)
+		}
+	}
+
+	return names
+}
+
+// generatedType is a type declared by the template, and its name.
+type generatedType struct {
+	name string
+	typ  types.Type
+}
+
+// generatedTypes returns the types declared by the template, in the order of their declarations.
+func generatedTypes(query *Query, mutation *Mutation, fragments []*Fragment, structSources []*StructSource, operationResponses []*OperationResponse) []generatedType {
+	all := []generatedType{{query.Name, query.Type}}
+	if mutation != nil {
+		all = append(all, generatedType{mutation.Name, mutation.Type})
+	}
+	for _, fragment := range fragments {
+		all = append(all, generatedType{fragment.Name, fragment.Type})
+	}
+	for _, structSource := range structSources {
+		all = append(all, generatedType{structSource.Name, structSource.Type})
+	}
+	for _, operationResponse := range operationResponses {
+		all = append(all, generatedType{operationResponse.Name, operationResponse.Type})
+	}
+
+	return all
+}
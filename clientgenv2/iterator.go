@@ -0,0 +1,316 @@
+package clientgenv2
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+
+	"github.com/99designs/gqlgen/codegen/templates"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Iterator is the generated iterator over the nodes of the Relay connection selected by a query,
+// the field having its first and after arguments set by variables and selecting
+// pageInfo { endCursor hasNextPage } and edges { node } or nodes.
+//
+// The go expressions are those of the generated page function, where res is the response of the operation.
+type Iterator struct {
+	// Name is the name of the method returning the iterator.
+	Name string
+	// TypeName is the name of the iterator type.
+	TypeName string
+	// Args are the arguments of the iterator method, the ones of the operation without first and after.
+	Args []*Argument
+	// CallArgs are the arguments passing the variables of the operation from a page size and a cursor.
+	CallArgs []string
+	// FirstType is the type of the first variable holding the page size, converted from the int page size,
+	// nil if the variable is an int passed as is.
+	FirstType types.Type
+	// Guard is the condition of a connection missing from the response, empty if never missing.
+	Guard string
+	// Connection is the connection in the response.
+	Connection string
+	// PageInfoGuard is the condition of a missing page info, empty if never missing.
+	PageInfoGuard string
+	EndCursor     string
+	HasNextPage   string
+	// Edges are the edges of the connection, empty if the nodes are selected directly.
+	Edges string
+	// EdgeGuard is the condition of a missing edge, empty if never missing.
+	EdgeGuard string
+	// Nodes are the nodes of the connection, or the node of an edge.
+	Nodes    string
+	NodeType types.Type
+	// Merge is the merge of the pages of the connection of its @connection directive, nil if it has none.
+	Merge *ConnectionMerge
+
+	// the connection field, the paths from the response to the connection and to its pointers, and the page info of the connection
+	field          *ast.Field
+	connectionPath string
+	pointerPaths   []string
+	pageInfo       string
+}
+
+// iteratorNames are the names declared by the generated iterator method, not usable by the arguments passed to the operation.
+var iteratorNames = map[string]bool{
+	"c": true, "s": true, "ctx": true, "pageSize": true, "interceptors": true, "first": true, "after": true, "size": true,
+	"res": true, "err": true, "connection": true, "nodes": true, "edge": true, "node": true,
+}
+
+// NewIterator returns the iterator of the connection selected by a query operation, nil if it selects none,
+// typeName returning the name of the generated type of a go name.
+func NewIterator(operation *ast.OperationDefinition, args []*Argument, response types.Type, typeName func(name string) string) *Iterator {
+	if operation.Operation != ast.Query {
+		return nil
+	}
+
+	var paths [][]*ast.Field
+	findConnections(operation.SelectionSet, nil, &paths)
+	if len(paths) != 1 {
+		return nil
+	}
+	path := paths[0]
+	connection := path[len(path)-1]
+
+	name := templates.ToGo(operation.Name) + "Iterator"
+	it := &Iterator{
+		Name:     name,
+		TypeName: typeName(name),
+		field:    connection,
+	}
+
+	first := connection.Arguments.ForName("first").Value.Raw
+	after := connection.Arguments.ForName("after").Value.Raw
+	for _, arg := range args {
+		switch arg.Variable {
+		case first:
+			typ, pointer := derefType(arg.Type)
+			if basic, ok := typ.(*types.Basic); !ok || basic.Info()&types.IsInteger == 0 {
+				return nil
+			}
+			size := "first"
+			if !types.Identical(typ, types.Typ[types.Int]) {
+				it.FirstType = typ
+				size = "size"
+			}
+			if pointer {
+				size = "&" + size
+			}
+			it.CallArgs = append(it.CallArgs, size)
+		case after:
+			if !types.Identical(arg.Type, types.NewPointer(types.Typ[types.String])) {
+				return nil
+			}
+			it.CallArgs = append(it.CallArgs, "after")
+		default:
+			name := templates.ToGoPrivate(arg.Variable)
+			if iteratorNames[name] {
+				return nil
+			}
+			it.Args = append(it.Args, arg)
+			it.CallArgs = append(it.CallArgs, name)
+		}
+	}
+
+	// walk the response to the connection
+	expr := "res"
+	var guards []string
+	typ := response
+	for _, field := range path {
+		var pointer bool
+		expr, typ, pointer = selectField(expr, typ, field.Alias)
+		if typ == nil {
+			return nil
+		}
+		if pointer {
+			guards = append(guards, expr+" == nil")
+			it.pointerPaths = append(it.pointerPaths, strings.TrimPrefix(expr, "res"))
+		}
+	}
+	it.Guard = strings.Join(guards, " || ")
+	it.Connection = expr
+	it.connectionPath = strings.TrimPrefix(expr, "res")
+
+	pageInfoField := selectedField(connection.SelectionSet, "pageInfo")
+	pageInfo, pageInfoType, pointer := selectField("connection", typ, pageInfoField.Alias)
+	if pageInfoType == nil {
+		return nil
+	}
+	if pointer {
+		it.PageInfoGuard = pageInfo + " == nil"
+	}
+	it.pageInfo = pageInfo
+
+	endCursor, endCursorType, pointer := selectField(pageInfo, pageInfoType, selectedField(pageInfoField.SelectionSet, "endCursor").Alias)
+	if basic, ok := endCursorType.(*types.Basic); !ok || basic.Kind() != types.String {
+		return nil
+	}
+	it.EndCursor = endCursor
+	if !pointer {
+		it.EndCursor = "&" + endCursor
+	}
+
+	hasNextPage, hasNextPageType, pointer := selectField(pageInfo, pageInfoType, selectedField(pageInfoField.SelectionSet, "hasNextPage").Alias)
+	if basic, ok := hasNextPageType.(*types.Basic); !ok || basic.Kind() != types.Bool {
+		return nil
+	}
+	it.HasNextPage = hasNextPage
+	if pointer {
+		it.HasNextPage = fmt.Sprintf("%s != nil && *%s", hasNextPage, hasNextPage)
+	}
+
+	if edgesField := selectedField(connection.SelectionSet, "edges"); edgesField != nil && selectedField(edgesField.SelectionSet, "node") != nil {
+		edges, edgeType := selectList("connection", typ, edgesField.Alias)
+		if edgeType == nil {
+			return nil
+		}
+		it.Edges = edges
+		edgeStruct, pointer := derefType(edgeType)
+		if pointer {
+			it.EdgeGuard = "edge == nil"
+		}
+
+		var nodeType types.Type
+		it.Nodes, nodeType, pointer = selectField("edge", edgeStruct, selectedField(edgesField.SelectionSet, "node").Alias)
+		if nodeType == nil {
+			return nil
+		}
+		it.NodeType = nodeType
+		if pointer {
+			it.NodeType = types.NewPointer(nodeType)
+		}
+
+		return it
+	}
+
+	if nodesField := selectedField(connection.SelectionSet, "nodes"); nodesField != nil {
+		it.Nodes, it.NodeType = selectList("connection", typ, nodesField.Alias)
+		if it.NodeType == nil {
+			return nil
+		}
+
+		return it
+	}
+
+	return nil
+}
+
+// findConnections appends the paths of fields to the connections paginated by variables found in selectionSet,
+// not walking through lists and inline fragments.
+func findConnections(selectionSet ast.SelectionSet, path []*ast.Field, paths *[][]*ast.Field) {
+	for _, selection := range selectionSet {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if selection.Definition == nil || selection.Definition.Type.Elem != nil {
+				continue
+			}
+
+			fieldPath := append(append([]*ast.Field(nil), path...), selection)
+			if isConnection(selection) {
+				*paths = append(*paths, fieldPath)
+
+				continue
+			}
+			findConnections(selection.SelectionSet, fieldPath, paths)
+		case *ast.FragmentSpread:
+			// the fields of a fragment spread are fields of the struct of their parent
+			findConnections(selection.Definition.SelectionSet, path, paths)
+		}
+	}
+}
+
+// isConnection reports whether field is a connection with its first and after arguments set by variables.
+func isConnection(field *ast.Field) bool {
+	for _, name := range []string{"first", "after"} {
+		argument := field.Arguments.ForName(name)
+		if argument == nil || argument.Value.Kind != ast.Variable {
+			return false
+		}
+	}
+
+	pageInfo := selectedField(field.SelectionSet, "pageInfo")
+	if pageInfo == nil || selectedField(pageInfo.SelectionSet, "endCursor") == nil || selectedField(pageInfo.SelectionSet, "hasNextPage") == nil {
+		return false
+	}
+
+	return selectedField(field.SelectionSet, "edges") != nil || selectedField(field.SelectionSet, "nodes") != nil
+}
+
+// selectedField returns the field of the schema named name selected by selectionSet, directly or by a fragment spread.
+func selectedField(selectionSet ast.SelectionSet, name string) *ast.Field {
+	for _, selection := range selectionSet {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if selection.Name == name {
+				return selection
+			}
+		case *ast.FragmentSpread:
+			if field := selectedField(selection.Definition.SelectionSet, name); field != nil {
+				return field
+			}
+		}
+	}
+
+	return nil
+}
+
+// selectField returns the expression and type of the struct field of key in typ, and whether the field is a pointer.
+// The type is nil if typ has no such field or if the field is a list.
+func selectField(expr string, typ types.Type, key string) (string, types.Type, bool) {
+	field := structField(typ, key)
+	if field == nil {
+		return "", nil, false
+	}
+	if _, ok := field.Type().(*types.Slice); ok {
+		return "", nil, false
+	}
+
+	fieldType, pointer := derefType(field.Type())
+
+	return expr + "." + field.Name(), fieldType, pointer
+}
+
+// selectList returns the expression of the list struct field of key in typ and the type of its elements,
+// nil if typ has no such field.
+func selectList(expr string, typ types.Type, key string) (string, types.Type) {
+	field := structField(typ, key)
+	if field == nil {
+		return "", nil
+	}
+
+	slice, ok := field.Type().(*types.Slice)
+	if !ok {
+		return "", nil
+	}
+
+	return expr + "." + field.Name(), slice.Elem()
+}
+
+// structField returns the field of the struct typ whose json name is key.
+func structField(typ types.Type, key string) *types.Var {
+	if typ == nil {
+		return nil
+	}
+
+	structType, ok := typ.Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+
+	for i := 0; i < structType.NumFields(); i++ {
+		if responseKey(structType.Tag(i)) == key {
+			return structType.Field(i)
+		}
+	}
+
+	return nil
+}
+
+// derefType returns the type pointed by typ and true, or typ and false if it is not a pointer.
+func derefType(typ types.Type) (types.Type, bool) {
+	if pointer, ok := typ.(*types.Pointer); ok {
+		return pointer.Elem(), true
+	}
+
+	return typ, false
+}
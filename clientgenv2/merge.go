@@ -0,0 +1,159 @@
+package clientgenv2
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/99designs/gqlgen/codegen/templates"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// ConnectionMerge is the merge of the pages of the connection of an iterator under the cache key of its @connection directive,
+// generated as a function returning the key for the arguments of the operation and a function merging a page into another.
+type ConnectionMerge struct {
+	// KeyName is the name of the function returning the cache key, Key the key of the directive.
+	KeyName string
+	Key     string
+	// Filter are the arguments of the connection in the cache key, KeyArgs the arguments of the operation they are set by.
+	Filter  []*ConnectionFilter
+	KeyArgs []*Argument
+	// Name is the name of the function merging the pages, ResponseType the type of the response of the operation.
+	Name         string
+	ResponseType string
+	// MergedGuard and PageGuard are the conditions of a connection missing from the merged response and the page,
+	// empty if never missing.
+	MergedGuard string
+	PageGuard   string
+	// Connection, List and PageInfo are the paths from the response to the connection, and from the connection to its edges,
+	// or nodes, and page info.
+	Connection string
+	List       string
+	PageInfo   string
+}
+
+// ConnectionFilter is an argument of a connection in its cache key.
+type ConnectionFilter struct {
+	Argument string
+	// Value is the go expression of the value, an argument of the key function or a literal.
+	Value string
+}
+
+// NewConnectionMerge returns the merge of the connection of the @connection directive of the operation, nil if it has none.
+// The directive must be the one of the connection of the iterator it, nil if the operation has none.
+func NewConnectionMerge(operation *ast.OperationDefinition, it *Iterator, args []*Argument, responseType string) (*ConnectionMerge, error) {
+	var fields []*ast.Field
+	connectionFields(operation.SelectionSet, &fields)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	if it == nil || len(fields) != 1 || fields[0] != it.field {
+		return nil, fmt.Errorf("%s: %s is not the connection of an iterator, a connection paginated by first and after variables", operation.Name, fields[0].Alias)
+	}
+	field := fields[0]
+	directive := field.Directives.ForName(connectionDirective)
+
+	// the key is optional in the @connection of a schema declaring its own
+	key := directive.Arguments.ForName("key")
+	if key == nil {
+		return nil, fmt.Errorf("%s: the @connection of %s has no key", operation.Name, field.Alias)
+	}
+	if key.Value.Kind != ast.StringValue {
+		return nil, fmt.Errorf("%s: the key of %s must be a string", operation.Name, field.Alias)
+	}
+	name := templates.ToGo(operation.Name)
+	merge := &ConnectionMerge{
+		KeyName:      name + "ConnectionKey",
+		Key:          key.Value.Raw,
+		Name:         "Merge" + name,
+		ResponseType: responseType,
+		MergedGuard:  pointerGuard("merged", it.pointerPaths),
+		PageGuard:    pointerGuard("page", it.pointerPaths),
+		Connection:   it.connectionPath,
+		List:         strings.TrimPrefix(it.Edges, "connection"),
+		PageInfo:     strings.TrimPrefix(it.pageInfo, "connection"),
+	}
+	if merge.List == "" {
+		merge.List = strings.TrimPrefix(it.Nodes, "connection")
+	}
+
+	if filter := directive.Arguments.ForName("filter"); filter != nil && filter.Value.Kind == ast.ListValue {
+		for _, child := range filter.Value.Children {
+			argumentName := child.Value.Raw
+			if field.Definition.Arguments.ForName(argumentName) == nil {
+				return nil, fmt.Errorf("%s: unknown argument %s of %s in the filter of %s", operation.Name, argumentName, field.Definition.Name, field.Alias)
+			}
+			argument := field.Arguments.ForName(argumentName)
+			if argument == nil {
+				continue
+			}
+			if argument.Value.Kind != ast.Variable {
+				value, err := literal(argument.Value)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %s of %s: %w", operation.Name, argumentName, field.Alias, err)
+				}
+				merge.Filter = append(merge.Filter, &ConnectionFilter{Argument: argumentName, Value: value})
+
+				continue
+			}
+			for _, arg := range args {
+				if arg.Variable == argument.Value.Raw {
+					merge.Filter = append(merge.Filter, &ConnectionFilter{Argument: argumentName, Value: templates.ToGoPrivate(arg.Variable)})
+					merge.KeyArgs = appendArgument(merge.KeyArgs, arg)
+				}
+			}
+		}
+	}
+
+	return merge, nil
+}
+
+// connectionFields appends the fields having a @connection directive selected by selectionSet.
+func connectionFields(selectionSet ast.SelectionSet, fields *[]*ast.Field) {
+	for _, selection := range selectionSet {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if selection.Directives.ForName(connectionDirective) != nil {
+				*fields = append(*fields, selection)
+			}
+			connectionFields(selection.SelectionSet, fields)
+		case *ast.InlineFragment:
+			connectionFields(selection.SelectionSet, fields)
+		case *ast.FragmentSpread:
+			connectionFields(selection.Definition.SelectionSet, fields)
+		}
+	}
+}
+
+// literal returns the go expression of the value of a constant argument, encoded in the key as a variable of this value.
+func literal(value *ast.Value) (string, error) {
+	v, err := value.Value(nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid value %s: %w", value, err)
+	}
+	if v == nil {
+		return "nil", nil
+	}
+
+	return fmt.Sprintf("%#v", v), nil
+}
+
+// pointerGuard returns the condition of one of the pointers of paths from the response named response being nil.
+func pointerGuard(response string, paths []string) string {
+	guards := make([]string, 0, len(paths))
+	for _, path := range paths {
+		guards = append(guards, response+path+" == nil")
+	}
+
+	return strings.Join(guards, " || ")
+}
+
+// appendArgument appends arg to args unless it is already there.
+func appendArgument(args []*Argument, arg *Argument) []*Argument {
+	for _, a := range args {
+		if a == arg {
+			return args
+		}
+	}
+
+	return append(args, arg)
+}
@@ -2,28 +2,116 @@ package clientgenv2
 
 import (
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/vektah/gqlparser/v2/ast"
 	"github.com/vektah/gqlparser/v2/parser"
 	"github.com/vektah/gqlparser/v2/validator"
 )
 
-func ParseQueryDocuments(schema *ast.Schema, querySources []*ast.Source) (*ast.QueryDocument, error) {
+// OperationTimeouts are the default timeouts of the generated methods, by operation name.
+type OperationTimeouts map[string]time.Duration
+
+// timeoutDirective annotates an operation with the default timeout of its generated method,
+// like query GetUser @timeout(ms: 5000). It is removed from the document sent to the server.
+const timeoutDirective = "timeout"
+
+// clientDirective marks the fields populated by the client, like user { name isSelected @client }:
+// they are generated in the models but removed from the document sent to the server,
+// their definitions coming from a local extension of the schema, like extend type User { isSelected: Boolean! }.
+const clientDirective = "client"
+
+// connectionDirective merges the pages of a connection under a cache key, like the @connection directive of Apollo,
+// like users(role: $role, first: $first, after: $after) @connection(key: "users", filter: ["role"]).
+// It is removed from the document sent to the server.
+const connectionDirective = "connection"
+
+func ParseQueryDocuments(schema *ast.Schema, querySources []*ast.Source) (*ast.QueryDocument, OperationTimeouts, error) {
 	var queryDocument ast.QueryDocument
 	for _, querySource := range querySources {
 		query, gqlerr := parser.ParseQuery(querySource)
 		if gqlerr != nil {
-			return nil, fmt.Errorf(": %w", gqlerr)
+			return nil, nil, fmt.Errorf(": %w", gqlerr)
 		}
 
 		mergeQueryDocument(&queryDocument, query)
 	}
 
-	if errs := validator.Validate(schema, &queryDocument); errs != nil {
-		return nil, fmt.Errorf(": %w", errs)
+	timeouts, err := removeTimeoutDirectives(queryDocument.Operations)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid @%s directive: %w", timeoutDirective, err)
+	}
+
+	if errs := validator.Validate(withClientDirectives(schema), &queryDocument); errs != nil {
+		return nil, nil, fmt.Errorf(": %w", errs)
 	}
 
-	return &queryDocument, nil
+	return &queryDocument, timeouts, nil
+}
+
+// withClientDirectives returns schema declaring the client and connection directives if it does not,
+// a copy leaving the schema and its hash unchanged.
+func withClientDirectives(schema *ast.Schema) *ast.Schema {
+	if schema.Directives[clientDirective] != nil && schema.Directives[connectionDirective] != nil {
+		return schema
+	}
+
+	withDirectives := *schema
+	withDirectives.Directives = make(map[string]*ast.DirectiveDefinition, len(schema.Directives)+2)
+	for name, directive := range schema.Directives {
+		withDirectives.Directives[name] = directive
+	}
+	if withDirectives.Directives[clientDirective] == nil {
+		withDirectives.Directives[clientDirective] = &ast.DirectiveDefinition{
+			Name:      clientDirective,
+			Locations: []ast.DirectiveLocation{ast.LocationField},
+		}
+	}
+	if withDirectives.Directives[connectionDirective] == nil {
+		withDirectives.Directives[connectionDirective] = &ast.DirectiveDefinition{
+			Name: connectionDirective,
+			Arguments: ast.ArgumentDefinitionList{
+				{Name: "key", Type: ast.NonNullNamedType("String", nil)},
+				{Name: "filter", Type: ast.ListType(ast.NonNullNamedType("String", nil), nil)},
+			},
+			Locations: []ast.DirectiveLocation{ast.LocationField},
+		}
+	}
+
+	return &withDirectives
+}
+
+// removeTimeoutDirectives removes the timeout directives of the operations and returns their timeouts.
+func removeTimeoutDirectives(operations ast.OperationList) (OperationTimeouts, error) {
+	timeouts := make(OperationTimeouts)
+	for _, operation := range operations {
+		directives := make(ast.DirectiveList, 0, len(operation.Directives))
+		for _, directive := range operation.Directives {
+			if directive.Name != timeoutDirective {
+				directives = append(directives, directive)
+
+				continue
+			}
+
+			if _, exist := timeouts[operation.Name]; exist {
+				return nil, fmt.Errorf("%s: repeated", operation.Name)
+			}
+
+			ms := directive.Arguments.ForName("ms")
+			if ms == nil || len(directive.Arguments) != 1 || ms.Value.Kind != ast.IntValue {
+				return nil, fmt.Errorf("%s: want a single ms argument with an Int value", operation.Name)
+			}
+			n, err := strconv.ParseInt(ms.Value.Raw, 10, 64)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("%s: ms must be a positive Int, got %s", operation.Name, ms.Value.Raw)
+			}
+			timeouts[operation.Name] = time.Duration(n) * time.Millisecond
+		}
+		operation.Directives = directives
+	}
+
+	return timeouts, nil
 }
 
 func mergeQueryDocument(q, other *ast.QueryDocument) {
@@ -34,6 +122,15 @@ func mergeQueryDocument(q, other *ast.QueryDocument) {
 func QueryDocumentsByOperations(schema *ast.Schema, operations ast.OperationList) ([]*ast.QueryDocument, error) {
 	queryDocuments := make([]*ast.QueryDocument, 0, len(operations))
 	for _, operation := range operations {
+		// the document sent to the server, the models being generated from operation
+		selectionSet := removeClientFields(operation.SelectionSet)
+		if len(selectionSet) == 0 {
+			return nil, fmt.Errorf("%s selects only @%s fields", operation.Name, clientDirective)
+		}
+		sent := *operation
+		sent.SelectionSet = selectionSet
+		operation := &sent
+
 		fragments := fragmentsInOperationDefinition(operation)
 
 		queryDocument := &ast.QueryDocument{
@@ -52,6 +149,61 @@ func QueryDocumentsByOperations(schema *ast.Schema, operations ast.OperationList
 	return queryDocuments, nil
 }
 
+// removeClientFields returns a copy of selectionSet without the client fields, nor the selections left empty without them.
+// The selections are copied, validating the copy setting their definitions.
+func removeClientFields(selectionSet ast.SelectionSet) ast.SelectionSet {
+	selections := make(ast.SelectionSet, 0, len(selectionSet))
+	for _, selection := range selectionSet {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if selection.Directives.ForName(clientDirective) != nil {
+				continue
+			}
+
+			field := *selection
+			field.Directives = removeDirective(selection.Directives, connectionDirective)
+			if len(selection.SelectionSet) > 0 {
+				if field.SelectionSet = removeClientFields(selection.SelectionSet); len(field.SelectionSet) == 0 {
+					continue
+				}
+			}
+			selections = append(selections, &field)
+		case *ast.InlineFragment:
+			inlineFragment := *selection
+			if inlineFragment.SelectionSet = removeClientFields(selection.SelectionSet); len(inlineFragment.SelectionSet) == 0 {
+				continue
+			}
+			selections = append(selections, &inlineFragment)
+		case *ast.FragmentSpread:
+			fragment := *selection.Definition
+			if fragment.SelectionSet = removeClientFields(selection.Definition.SelectionSet); len(fragment.SelectionSet) == 0 {
+				continue
+			}
+			spread := *selection
+			spread.Definition = &fragment
+			selections = append(selections, &spread)
+		}
+	}
+
+	return selections
+}
+
+// removeDirective returns the directives without the ones named name, directives if there are none.
+func removeDirective(directives ast.DirectiveList, name string) ast.DirectiveList {
+	if directives.ForName(name) == nil {
+		return directives
+	}
+
+	removed := make(ast.DirectiveList, 0, len(directives))
+	for _, directive := range directives {
+		if directive.Name != name {
+			removed = append(removed, directive)
+		}
+	}
+
+	return removed
+}
+
 func fragmentsInOperationDefinition(operation *ast.OperationDefinition) ast.FragmentDefinitionList {
 	fragments := fragmentsInOperationWalker(operation.SelectionSet)
 	uniqueFragments := fragmentsUnique(fragments)
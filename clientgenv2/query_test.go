@@ -0,0 +1,81 @@
+package clientgenv2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestParseQueryDocumentsTimeout(t *testing.T) {
+	t.Parallel()
+
+	schema := gqlparser.MustLoadSchema(&ast.Source{Input: `type Query { user: User } type User { name: String }`})
+	parse := func(query string) (*ast.QueryDocument, OperationTimeouts, error) {
+		return ParseQueryDocuments(schema, []*ast.Source{{Input: query}})
+	}
+
+	t.Run("removed from the document", func(t *testing.T) {
+		t.Parallel()
+		document, timeouts, err := parse(`query GetUser @timeout(ms: 1500) { user { name } } query GetName { user { name } }`)
+		require.NoError(t, err)
+		require.Equal(t, OperationTimeouts{"GetUser": 1500 * time.Millisecond}, timeouts)
+		require.Empty(t, document.Operations.ForName("GetUser").Directives)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		for query, want := range map[string]string{
+			`query GetUser @timeout { user { name } }`:                        "invalid @timeout directive: GetUser: want a single ms argument with an Int value",
+			`query GetUser @timeout(ms: "5s") { user { name } }`:              "invalid @timeout directive: GetUser: want a single ms argument with an Int value",
+			`query GetUser @timeout(ms: 0) { user { name } }`:                 "invalid @timeout directive: GetUser: ms must be a positive Int, got 0",
+			`query GetUser @timeout(ms: 1) @timeout(ms: 2) { user { name } }`: "invalid @timeout directive: GetUser: repeated",
+		} {
+			_, _, err := parse(query)
+			require.EqualError(t, err, want, query)
+		}
+	})
+}
+
+func TestQueryDocumentsByOperationsClientFields(t *testing.T) {
+	t.Parallel()
+
+	schema := gqlparser.MustLoadSchema(&ast.Source{Input: `type Query { user: User } type User { name: String selected: Boolean! }`})
+	parse := func(query string) ([]*ast.QueryDocument, error) {
+		document, _, err := ParseQueryDocuments(schema, []*ast.Source{{Input: query}})
+		require.NoError(t, err)
+
+		return QueryDocumentsByOperations(schema, document.Operations)
+	}
+
+	t.Run("removed from the document", func(t *testing.T) {
+		t.Parallel()
+		documents, err := parse(`query GetUser { user { name selected @client ...Local } } fragment Local on User { selected @client }`)
+		require.NoError(t, err)
+		require.Len(t, documents, 1)
+		require.Equal(t, "query GetUser {\n\tuser {\n\t\tname\n\t}\n}\n", queryString(documents[0]))
+		require.Nil(t, schema.Directives[clientDirective])
+		require.Nil(t, schema.Directives[connectionDirective])
+	})
+
+	t.Run("only client fields", func(t *testing.T) {
+		t.Parallel()
+		_, err := parse(`query GetSelected { user { selected @client } }`)
+		require.EqualError(t, err, "GetSelected selects only @client fields")
+	})
+}
+
+func TestQueryDocumentsByOperationsConnection(t *testing.T) {
+	t.Parallel()
+
+	schema := gqlparser.MustLoadSchema(&ast.Source{Input: `type Query { users(role: String, first: Int): [String!]! }`})
+	document, _, err := ParseQueryDocuments(schema, []*ast.Source{{Input: `query ListUsers($first: Int) { users(role: "admin", first: $first) @connection(key: "users", filter: ["role"]) }`}})
+	require.NoError(t, err)
+	require.NotNil(t, document.Operations[0].SelectionSet[0].(*ast.Field).Directives.ForName(connectionDirective), "the models see the directive")
+
+	documents, err := QueryDocumentsByOperations(schema, document.Operations)
+	require.NoError(t, err)
+	require.Equal(t, "query ListUsers ($first: Int) {\n\tusers(role: \"admin\", first: $first)\n}\n", queryString(documents[0]))
+}
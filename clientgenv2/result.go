@@ -0,0 +1,65 @@
+package clientgenv2
+
+import (
+	"github.com/99designs/gqlgen/codegen/templates"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Result is the generated result type of an operation, holding its data and the graphql errors of a partial response.
+type Result struct {
+	// Name is the name of the method returning the result.
+	Name string
+	// TypeName is the name of the result type.
+	TypeName string
+	// Fields are the root fields of the operation, having a method returning their errors.
+	Fields []*ResultField
+}
+
+// ResultField is a root field of an operation in its result type.
+type ResultField struct {
+	// Method is the name of the method returning the errors of the field.
+	Method string
+	// Key is the key of the field in the response.
+	Key string
+}
+
+// NewResult returns the result type of operation, typeName returning the name of the generated type of a go name.
+func NewResult(operation *ast.OperationDefinition, typeName func(name string) string) *Result {
+	name := templates.ToGo(operation.Name) + "Result"
+	result := &Result{
+		Name:     name,
+		TypeName: typeName(name),
+	}
+
+	// HasErrors is a method of every result type
+	methods := map[string]bool{"HasErrors": true}
+	for _, key := range rootKeys(operation.SelectionSet) {
+		method := templates.ToGo(key) + "Errors"
+		if methods[method] {
+			continue
+		}
+		methods[method] = true
+		result.Fields = append(result.Fields, &ResultField{Method: method, Key: key})
+	}
+
+	return result
+}
+
+// rootKeys returns the response keys of the fields of selectionSet, including those of its fragment spreads.
+func rootKeys(selectionSet ast.SelectionSet) []string {
+	var keys []string
+	for _, selection := range selectionSet {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if selection.Name != "__typename" {
+				keys = append(keys, selection.Alias)
+			}
+		case *ast.FragmentSpread:
+			keys = append(keys, rootKeys(selection.Definition.SelectionSet)...)
+		case *ast.InlineFragment:
+			keys = append(keys, rootKeys(selection.SelectionSet)...)
+		}
+	}
+
+	return keys
+}
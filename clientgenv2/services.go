@@ -0,0 +1,104 @@
+package clientgenv2
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/99designs/gqlgen/codegen/templates"
+	gqlgencConfig "github.com/pleclech/gqlgenc/config"
+)
+
+// Service is a generated group of operation methods, like UsersService for the service Users,
+// holding the client whose transport it shares and set in its field of the same name.
+type Service struct {
+	// Name is the name of the field of the service in the client.
+	Name string
+	// TypeName is the name of the service type.
+	TypeName string
+	// Operations are the go names of the operations of the service.
+	Operations []string
+	// patterns are the patterns of the names of the operations of the service.
+	patterns []string
+}
+
+// NewServices returns the services of the operations matching their name patterns, sorted by name,
+// and sets the service of these operations. It fails on an invalid pattern, on an operation matching
+// several services, on a service whose name collides with a field of the client, and on an operation
+// having a variable named as the s receiver of the service methods or their c client.
+func NewServices(operations []*Operation, generateConfig *gqlgencConfig.GenerateConfig) ([]*Service, error) {
+	if generateConfig == nil || len(generateConfig.Services) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(generateConfig.Services))
+	for name := range generateConfig.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	services := make([]*Service, 0, len(names))
+	seen := make(map[string]string, len(names))
+	for _, name := range names {
+		field := templates.ToGo(name)
+		if field == "Client" || field == "Executor" {
+			return nil, fmt.Errorf("service %s collides with the field %s of the client", name, field)
+		}
+		if other, ok := seen[field]; ok {
+			return nil, fmt.Errorf("services %s and %s have the same go name %s", other, name, field)
+		}
+		seen[field] = name
+
+		services = append(services, &Service{
+			Name:     field,
+			TypeName: generateConfig.TypeName(field + "Service"),
+			patterns: generateConfig.Services[name],
+		})
+	}
+
+	for _, operation := range operations {
+		var matched []string
+		for _, service := range services {
+			ok, err := matchAny(service.patterns, operation.Name)
+			if err != nil {
+				return nil, fmt.Errorf("service %s: %w", service.Name, err)
+			}
+			if !ok {
+				continue
+			}
+
+			matched = append(matched, service.Name)
+			operation.Service = service
+			service.Operations = append(service.Operations, templates.ToGo(operation.Name))
+		}
+		if len(matched) > 1 {
+			return nil, fmt.Errorf("operation %s matches the services %s", operation.Name, strings.Join(matched, ", "))
+		}
+		if operation.Service == nil {
+			continue
+		}
+		for _, arg := range operation.Args {
+			if name := templates.ToGoPrivate(arg.Variable); name == "s" || name == "c" {
+				return nil, fmt.Errorf("operation %s of the service %s has the variable $%s, named as a variable of the service methods", operation.Name, operation.Service.Name, arg.Variable)
+			}
+		}
+	}
+
+	return services, nil
+}
+
+// matchAny reports whether name matches one of the patterns of path.Match.
+func matchAny(patterns []string, name string) (bool, error) {
+	for _, pattern := range patterns {
+		ok, err := path.Match(pattern, name)
+		if err != nil {
+			return false, fmt.Errorf("pattern %q: %w", pattern, err)
+		}
+		if ok {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -2,11 +2,14 @@ package clientgenv2
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"go/types"
+	"time"
 
 	"github.com/99designs/gqlgen/codegen/templates"
-	"github.com/Yamashou/gqlgenc/config"
+	"github.com/pleclech/gqlgenc/config"
 	"github.com/vektah/gqlparser/v2/ast"
 	"github.com/vektah/gqlparser/v2/formatter"
 )
@@ -40,19 +43,16 @@ func (s *Source) Fragments() ([]*Fragment, error) {
 			return nil, fmt.Errorf("%s is duplicated", fragment.Name)
 		}
 
-		fragment := &Fragment{
-			Name: fragment.Name,
-			Type: responseFields.StructType(),
-		}
-
-		fragments = append(fragments, fragment)
+		fragments = append(fragments, &Fragment{
+			Name: s.generateConfig.TypeName(templates.ToGo(fragment.Name)),
+			Type: s.sourceGenerator.StructType(fragment.Name, responseFields),
+		})
 	}
 
-	for _, fragment := range fragments {
-		name := fragment.Name
+	for i, fragment := range s.queryDocument.Fragments {
 		s.sourceGenerator.cfg.Models.Add(
-			name,
-			fmt.Sprintf("%s.%s", s.sourceGenerator.client.Pkg(), templates.ToGo(name)),
+			fragment.Name,
+			fmt.Sprintf("%s.%s", s.sourceGenerator.client.Pkg(), fragments[i].Name),
 		)
 	}
 
@@ -65,15 +65,49 @@ type Operation struct {
 	Operation           string
 	Args                []*Argument
 	VariableDefinitions ast.VariableDefinitionList
+	// Type is the type of the operation, query, mutation or subscription.
+	Type string
+	// QueryHash is the hex SHA-256 hash of Operation, the document sent.
+	QueryHash string
+	// DocumentID is the id of the document in the operation registry sent with the id document mode, empty with the others.
+	DocumentID string
+	// Timeout is the default timeout of the generated method, 0 if none.
+	Timeout time.Duration
+	// Iterator is the iterator over the connection selected by the operation, nil if none.
+	Iterator *Iterator
+	// Result is the result type of the operation, nil if not generated.
+	Result *Result
+	// RawMethod is the name of the method returning the undecoded data of the operation, empty if not generated.
+	RawMethod string
+	// Variables is the struct of the variables of the operation, nil if not generated.
+	Variables *Variables
+	// VariableDefaults are the constants of the default values of the variables, nil if not generated.
+	VariableDefaults []*VariableDefault
+	// SubscriptionEvent is the event type of the channel returned by the method of a subscription, empty if not generated.
+	SubscriptionEvent string
+	// Service is the service whose methods are the ones of the operation, nil for the methods of the client.
+	Service *Service
 }
 
-func NewOperation(operation *ast.OperationDefinition, queryDocument *ast.QueryDocument, args []*Argument, generateConfig *config.GenerateConfig) *Operation {
+func NewOperation(operation *ast.OperationDefinition, queryDocument *ast.QueryDocument, args []*Argument, timeout time.Duration, generateConfig *config.GenerateConfig) *Operation {
+	query := queryString(queryDocument)
+	hash := sha256.Sum256([]byte(query))
+	queryHash := hex.EncodeToString(hash[:])
+	var documentID string
+	if generateConfig != nil && generateConfig.DocumentMode == config.DocumentModeID {
+		documentID = generateConfig.DocumentID(operation.Name, queryHash)
+	}
+
 	return &Operation{
 		Name:                operation.Name,
 		ResponseStructName:  getResponseStructName(operation, generateConfig),
-		Operation:           queryString(queryDocument),
+		Operation:           query,
+		Type:                string(operation.Operation),
+		QueryHash:           queryHash,
+		DocumentID:          documentID,
 		Args:                args,
 		VariableDefinitions: operation.VariableDefinitions,
+		Timeout:             timeout,
 	}
 }
 
@@ -97,7 +131,7 @@ func IsUniqueName(os ast.OperationList) error {
 	return nil
 }
 
-func (s *Source) Operations(queryDocuments []*ast.QueryDocument) ([]*Operation, error) {
+func (s *Source) Operations(queryDocuments []*ast.QueryDocument, operationResponses []*OperationResponse, timeouts OperationTimeouts) ([]*Operation, error) {
 	operations := make([]*Operation, 0, len(s.queryDocument.Operations))
 
 	queryDocumentsMap := queryDocumentMapByOperationName(queryDocuments)
@@ -107,16 +141,48 @@ func (s *Source) Operations(queryDocuments []*ast.QueryDocument) ([]*Operation,
 		return nil, fmt.Errorf("validation error: %w", err)
 	}
 
+	responseTypes := make(map[string]types.Type, len(operationResponses))
+	for _, operationResponse := range operationResponses {
+		responseTypes[operationResponse.Name] = operationResponse.Type
+	}
+
 	for _, operation := range s.queryDocument.Operations {
 		queryDocument := queryDocumentsMap[operation.Name]
 
 		args := operationArgsMap[operation.Name]
-		operations = append(operations, NewOperation(
+		op := NewOperation(
 			operation,
 			queryDocument,
 			args,
+			timeouts[operation.Name],
 			s.generateConfig,
-		))
+		)
+		op.Iterator = NewIterator(operation, args, responseTypes[op.ResponseStructName], s.generateConfig.TypeName)
+		merge, err := NewConnectionMerge(operation, op.Iterator, args, op.ResponseStructName)
+		if err != nil {
+			return nil, fmt.Errorf("invalid @%s directive: %w", connectionDirective, err)
+		}
+		if merge != nil {
+			op.Iterator.Merge = merge
+		}
+		// the result types and the subscription channels need the transport of clientv2
+		switch {
+		case s.generateConfig == nil || s.generateConfig.Executor:
+		case s.generateConfig.SubscriptionChannels && operation.Operation == ast.Subscription:
+			op.SubscriptionEvent = s.generateConfig.TypeName(templates.ToGo(operation.Name) + "Event")
+		case s.generateConfig.OperationResults:
+			op.Result = NewResult(operation, s.generateConfig.TypeName)
+		}
+		if s.generateConfig != nil && s.generateConfig.RawMethods && !s.generateConfig.Executor && op.SubscriptionEvent == "" {
+			op.RawMethod = templates.ToGo(operation.Name) + "Raw"
+		}
+		if s.generateConfig != nil && s.generateConfig.OperationVariables {
+			op.Variables = NewVariables(operation, args, s.generateConfig.TypeName)
+		}
+		if s.generateConfig != nil && s.generateConfig.VariableDefaults {
+			op.VariableDefaults = NewVariableDefaults(operation, args)
+		}
+		operations = append(operations, op)
 	}
 
 	return operations, nil
@@ -164,7 +230,7 @@ func (s *Source) OperationResponses() ([]*OperationResponse, error) {
 		}
 		operationResponse = append(operationResponse, &OperationResponse{
 			Name: name,
-			Type: responseFields.StructType(),
+			Type: s.sourceGenerator.StructType(name, responseFields),
 		})
 	}
 
@@ -172,7 +238,7 @@ func (s *Source) OperationResponses() ([]*OperationResponse, error) {
 		name := operationResponse.Name
 		s.sourceGenerator.cfg.Models.Add(
 			name,
-			fmt.Sprintf("%s.%s", s.sourceGenerator.client.Pkg(), templates.ToGo(name)),
+			fmt.Sprintf("%s.%s", s.sourceGenerator.client.Pkg(), name),
 		)
 	}
 
@@ -194,14 +260,15 @@ func (s *Source) Query() (*Query, error) {
 		return nil, fmt.Errorf("generate failed for query struct type : %w", err)
 	}
 
+	name := s.generateConfig.TypeName(templates.ToGo(s.schema.Query.Name))
 	s.sourceGenerator.cfg.Models.Add(
 		s.schema.Query.Name,
-		fmt.Sprintf("%s.%s", s.sourceGenerator.client.Pkg(), templates.ToGo(s.schema.Query.Name)),
+		fmt.Sprintf("%s.%s", s.sourceGenerator.client.Pkg(), name),
 	)
 
 	return &Query{
-		Name: s.schema.Query.Name,
-		Type: fields.StructType(),
+		Name: name,
+		Type: s.sourceGenerator.StructType(s.schema.Query.Name, fields),
 	}, nil
 }
 
@@ -216,14 +283,15 @@ func (s *Source) Mutation() (*Mutation, error) {
 		return nil, fmt.Errorf("generate failed for mutation struct type : %w", err)
 	}
 
+	name := s.generateConfig.TypeName(templates.ToGo(s.schema.Mutation.Name))
 	s.sourceGenerator.cfg.Models.Add(
 		s.schema.Mutation.Name,
-		fmt.Sprintf("%s.%s", s.sourceGenerator.client.Pkg(), templates.ToGo(s.schema.Mutation.Name)),
+		fmt.Sprintf("%s.%s", s.sourceGenerator.client.Pkg(), name),
 	)
 
 	return &Mutation{
-		Name: s.schema.Mutation.Name,
-		Type: fields.StructType(),
+		Name: name,
+		Type: s.sourceGenerator.StructType(s.schema.Mutation.Name, fields),
 	}, nil
 }
 
@@ -251,5 +319,5 @@ func getResponseStructName(operation *ast.OperationDefinition, generateConfig *c
 		}
 	}
 
-	return name
+	return generateConfig.TypeName(templates.ToGo(name))
 }
@@ -7,12 +7,16 @@ import (
 
 	"github.com/99designs/gqlgen/codegen/config"
 	"github.com/99designs/gqlgen/codegen/templates"
+	gqlgencConfig "github.com/pleclech/gqlgenc/config"
 	"github.com/vektah/gqlparser/v2/ast"
 )
 
 type Argument struct {
 	Variable string
 	Type     types.Type
+	// Required is set for a non-null variable whose go type may be nil, like a slice for a list,
+	// the generated method failing on nil before sending the request.
+	Required bool
 }
 
 type ResponseField struct {
@@ -26,27 +30,31 @@ type ResponseField struct {
 
 type ResponseFieldList []*ResponseField
 
+// StructType returns the struct type of the fields, see FieldNameCollision for the go names sharing a name.
 func (rs ResponseFieldList) StructType() *types.Struct {
+	vars, structTags := rs.structFields()
+	vars, structTags, _ = resolveFieldNames("", vars, structTags)
+
+	return types.NewStruct(vars, structTags)
+}
+
+// structFields returns the fields and tags of the struct type, go names may collide.
+func (rs ResponseFieldList) structFields() ([]*types.Var, []string) {
 	vars := make([]*types.Var, 0)
 	structTags := make([]string, 0)
 	for _, filed := range rs {
 		//  クエリーのフィールドの子階層がFragmentの場合、このフィールドにそのFragmentの型を追加する
 		if filed.IsFragmentSpread {
-			typ, ok := filed.ResponseFields.StructType().Underlying().(*types.Struct)
-			if !ok {
-				continue
-			}
-			for j := 0; j < typ.NumFields(); j++ {
-				vars = append(vars, typ.Field(j))
-				structTags = append(structTags, typ.Tag(j))
-			}
+			fragmentVars, fragmentTags := filed.ResponseFields.structFields()
+			vars = append(vars, fragmentVars...)
+			structTags = append(structTags, fragmentTags...)
 		} else {
 			vars = append(vars, types.NewVar(0, nil, templates.ToGo(filed.Name), filed.Type))
 			structTags = append(structTags, strings.Join(filed.Tags, " "))
 		}
 	}
 
-	return types.NewStruct(vars, structTags)
+	return vars, structTags
 }
 
 func (rs ResponseFieldList) IsFragment() bool {
@@ -74,18 +82,71 @@ type SourceGenerator struct {
 	cfg           *config.Config
 	binder        *config.Binder
 	client        config.PackageConfig
+	generate      *gqlgencConfig.GenerateConfig
 	StructSources []*StructSource
+	// FieldNameCollisions are the go field names resolved in the generated structs.
+	FieldNameCollisions []*FieldNameCollision
+	// Unions are the selections of union fields generated as interfaces.
+	Unions []*Union
 }
 
-func NewSourceGenerator(cfg *config.Config, client config.PackageConfig) *SourceGenerator {
+func NewSourceGenerator(cfg *config.Config, client config.PackageConfig, generate *gqlgencConfig.GenerateConfig) *SourceGenerator {
 	return &SourceGenerator{
 		cfg:           cfg,
 		binder:        cfg.NewBinder(),
 		client:        client,
+		generate:      generate,
 		StructSources: []*StructSource{},
 	}
 }
 
+// StructType returns the struct type named name of fields with their go names resolved.
+func (r *SourceGenerator) StructType(name string, fields ResponseFieldList) *types.Struct {
+	vars, tags := fields.structFields()
+	vars, tags, collisions := resolveFieldNames(name, vars, tags)
+	r.FieldNameCollisions = append(r.FieldNameCollisions, collisions...)
+
+	return types.NewStruct(vars, tags)
+}
+
+// jsonTag returns the json struct tag of a field, with the omitempty option for a field which may be null
+// when the json tags follow the nullability.
+func (r *SourceGenerator) jsonTag(name string, nullable bool) string {
+	if nullable && r.generate != nil && r.generate.JSONTags {
+		return fmt.Sprintf(`json:"%s,omitempty"`, name)
+	}
+
+	return fmt.Sprintf(`json:"%s"`, name)
+}
+
+// graphqlTag returns the graphql struct tag of a field of type typ, with the nonnull option for a non-null type
+// naming the decoder scalar for configured time and 64-bit integer scalars,
+// and the type of the objects of object types when their typenames are checked, and the options given
+func (r *SourceGenerator) graphqlTag(name string, typ *ast.Type, extra ...string) string {
+	var options string
+	if typ.NonNull {
+		options += ",nonnull"
+	}
+	for _, option := range extra {
+		options += "," + option
+	}
+	if r.generate != nil {
+		if _, ok := r.generate.TimeScalars[typ.Name()]; ok {
+			options += ",scalar=" + typ.Name()
+		}
+		for _, name := range r.generate.Int64Scalars {
+			if name == typ.Name() {
+				options += ",scalar=" + name
+			}
+		}
+		if definition := r.cfg.Schema.Types[typ.Name()]; r.generate.TypenameChecks && definition != nil && definition.Kind == ast.Object {
+			options += ",typename=" + typ.Name()
+		}
+	}
+
+	return fmt.Sprintf(`graphql:"%s%s"`, name, options)
+}
+
 func (r *SourceGenerator) NewResponseFields(selectionSet ast.SelectionSet, typeName string) ResponseFieldList {
 	responseFields := make(ResponseFieldList, 0, len(selectionSet))
 	for _, selection := range selectionSet {
@@ -105,7 +166,8 @@ func (r *SourceGenerator) NewResponseFieldsByDefinition(definition *ast.Definiti
 		var typ types.Type
 		if field.Type.Name() == "Query" || field.Type.Name() == "Mutation" {
 			var baseType types.Type
-			baseType, err := r.binder.FindType(r.client.Pkg().Path(), field.Type.Name())
+			name := r.generate.TypeName(templates.ToGo(field.Type.Name()))
+			baseType, err := r.binder.FindType(r.client.Pkg().Path(), name)
 			if err != nil {
 				if !strings.Contains(err.Error(), "unable to find type") {
 					return nil, fmt.Errorf("not found type: %w", err)
@@ -113,7 +175,7 @@ func (r *SourceGenerator) NewResponseFieldsByDefinition(definition *ast.Definiti
 
 				// create new type
 				baseType = types.NewPointer(types.NewNamed(
-					types.NewTypeName(0, r.client.Pkg(), templates.ToGo(field.Type.Name()), nil),
+					types.NewTypeName(0, r.client.Pkg(), name, nil),
 					nil,
 					nil,
 				))
@@ -132,9 +194,9 @@ func (r *SourceGenerator) NewResponseFieldsByDefinition(definition *ast.Definiti
 
 		var tags []string
 		if !field.Type.NonNull {
-			tags = append(tags, fmt.Sprintf(`json:"%s,omitempty"`, field.Name), fmt.Sprintf(`graphql:"%s"`, field.Name))
+			tags = append(tags, fmt.Sprintf(`json:"%s,omitempty"`, field.Name), r.graphqlTag(field.Name, field.Type))
 		} else {
-			tags = append(tags, fmt.Sprintf(`json:"%s"`, field.Name), fmt.Sprintf(`graphql:"%s"`, field.Name))
+			tags = append(tags, fmt.Sprintf(`json:"%s"`, field.Name), r.graphqlTag(field.Name, field.Type))
 		}
 
 		fields = append(fields, &ResponseField{
@@ -147,6 +209,33 @@ func (r *SourceGenerator) NewResponseFieldsByDefinition(definition *ast.Definiti
 	return fields, nil
 }
 
+// isConditional reports whether directives include or skip their selection depending on a variable,
+// the field being absent from the response when it is not selected.
+func isConditional(directives ast.DirectiveList) bool {
+	for _, name := range []string{"include", "skip"} {
+		directive := directives.ForName(name)
+		if directive == nil {
+			continue
+		}
+		if argument := directive.Arguments.ForName("if"); argument != nil && argument.Value.Kind == ast.Variable {
+			return true
+		}
+	}
+
+	return false
+}
+
+// optionalType returns the type of a field which may be absent from the response, nil when it is.
+// Pointers and slices are returned as is, nil already standing for an absent field.
+func optionalType(typ types.Type) types.Type {
+	switch typ.(type) {
+	case *types.Pointer, *types.Slice:
+		return typ
+	}
+
+	return types.NewPointer(typ)
+}
+
 func NewLayerTypeName(base, thisField string) string {
 	return fmt.Sprintf("%s_%s", base, thisField)
 }
@@ -155,10 +244,15 @@ func (r *SourceGenerator) NewResponseField(selection ast.Selection, typeName str
 	switch selection := selection.(type) {
 	case *ast.Field:
 		typeName = NewLayerTypeName(typeName, templates.ToGo(selection.Alias))
-		fieldsResponseFields := r.NewResponseFields(selection.SelectionSet, typeName)
+		var fieldsResponseFields ResponseFieldList
+		if !r.isUnionInterface(selection) {
+			fieldsResponseFields = r.NewResponseFields(selection.SelectionSet, typeName)
+		}
 
 		var baseType types.Type
 		switch {
+		case r.isUnionInterface(selection):
+			baseType = r.NewUnionType(selection, typeName)
 		case fieldsResponseFields.IsBasicType():
 			baseType = r.Type(selection.Definition.Type.Name())
 		case fieldsResponseFields.IsFragment():
@@ -166,13 +260,13 @@ func (r *SourceGenerator) NewResponseField(selection ast.Selection, typeName str
 			// if a child field is fragment, this field type became fragment.
 			baseType = fieldsResponseFields[0].Type
 		case fieldsResponseFields.IsStructType():
-			structType := fieldsResponseFields.StructType()
+			structType := r.StructType(typeName, fieldsResponseFields)
 			r.StructSources = append(r.StructSources, &StructSource{
-				Name: typeName,
+				Name: r.generate.TypeName(typeName),
 				Type: structType,
 			})
 			baseType = types.NewNamed(
-				types.NewTypeName(0, r.client.Pkg(), typeName, nil),
+				types.NewTypeName(0, r.client.Pkg(), r.generate.TypeName(typeName), nil),
 				structType,
 				nil,
 			)
@@ -185,10 +279,18 @@ func (r *SourceGenerator) NewResponseField(selection ast.Selection, typeName str
 		// GraphQLの定義がオプショナルのはtypeのポインタ型が返り、配列の定義場合はポインタのスライスの型になって返ってきます
 		// return pointer type then optional type or slice pointer then slice type of definition in GraphQL.
 		typ := r.binder.CopyModifiersFromAst(selection.Definition.Type, baseType)
+		var options []string
+		if length, ok := r.fixedLength(selection); ok {
+			typ = fixedListType(typ, length, !selection.Definition.Type.NonNull)
+			options = append(options, "fixed")
+		}
+		if isConditional(selection.Directives) {
+			typ = optionalType(typ)
+		}
 
 		tags := []string{
-			fmt.Sprintf(`json:"%s"`, selection.Alias),
-			fmt.Sprintf(`graphql:"%s"`, selection.Alias),
+			r.jsonTag(selection.Alias, !selection.Definition.Type.NonNull || isConditional(selection.Directives)),
+			r.graphqlTag(selection.Alias, selection.Definition.Type, options...),
 		}
 
 		return &ResponseField{
@@ -202,7 +304,7 @@ func (r *SourceGenerator) NewResponseField(selection ast.Selection, typeName str
 		// この構造体はテンプレート側で使われることはなく、ast.FieldでFragment判定するために使用する
 		fieldsResponseFields := r.NewResponseFields(selection.Definition.SelectionSet, NewLayerTypeName(typeName, templates.ToGo(selection.Name)))
 		typ := types.NewNamed(
-			types.NewTypeName(0, r.client.Pkg(), templates.ToGo(selection.Name), nil),
+			types.NewTypeName(0, r.client.Pkg(), r.generate.TypeName(templates.ToGo(selection.Name)), nil),
 			fieldsResponseFields.StructType(),
 			nil,
 		)
@@ -218,13 +320,13 @@ func (r *SourceGenerator) NewResponseField(selection ast.Selection, typeName str
 		// InlineFragmentは子要素をそのままstructとしてもつので、ここで、構造体の型を作成します
 		name := NewLayerTypeName(typeName, templates.ToGo(selection.TypeCondition))
 		fieldsResponseFields := r.NewResponseFields(selection.SelectionSet, name)
-		structType := fieldsResponseFields.StructType()
+		structType := r.StructType(name, fieldsResponseFields)
 		r.StructSources = append(r.StructSources, &StructSource{
-			Name: name,
+			Name: r.generate.TypeName(name),
 			Type: structType,
 		})
 		typ := types.NewNamed(
-			types.NewTypeName(0, r.client.Pkg(), name, nil),
+			types.NewTypeName(0, r.client.Pkg(), r.generate.TypeName(name), nil),
 			structType,
 			nil,
 		)
@@ -244,9 +346,11 @@ func (r *SourceGenerator) NewResponseField(selection ast.Selection, typeName str
 func (r *SourceGenerator) OperationArguments(variableDefinitions ast.VariableDefinitionList) []*Argument {
 	argumentTypes := make([]*Argument, 0, len(variableDefinitions))
 	for _, v := range variableDefinitions {
+		typ := r.binder.CopyModifiersFromAst(v.Type, r.Type(v.Type.Name()))
 		argumentTypes = append(argumentTypes, &Argument{
 			Variable: v.Variable,
-			Type:     r.binder.CopyModifiersFromAst(v.Type, r.Type(v.Type.Name())),
+			Type:     typ,
+			Required: v.Type.NonNull && config.IsNilable(typ),
 		})
 	}
 
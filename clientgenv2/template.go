@@ -2,26 +2,119 @@ package clientgenv2
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/99designs/gqlgen/codegen/config"
 	"github.com/99designs/gqlgen/codegen/templates"
+	"github.com/pleclech/gqlgenc/clientv2"
+	gqlgencConfig "github.com/pleclech/gqlgenc/config"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
 )
 
-func RenderTemplate(cfg *config.Config, query *Query, mutation *Mutation, fragments []*Fragment, operations []*Operation, operationResponses []*OperationResponse, structSources []*StructSource, generateClient bool, client config.PackageConfig) error {
+// TimeScalar is an integer scalar decoded into time.Duration or time.Time by the generated client
+type TimeScalar struct {
+	Name string
+	// Unit is the go expression of the unit, like time.Second
+	Unit string
+}
+
+// NewTimeScalars returns the configured time scalars sorted by name
+func NewTimeScalars(generateConfig *gqlgencConfig.GenerateConfig) ([]*TimeScalar, error) {
+	if generateConfig == nil {
+		return nil, nil
+	}
+
+	timeScalars := make([]*TimeScalar, 0, len(generateConfig.TimeScalars))
+	for name, timeScalar := range generateConfig.TimeScalars {
+		unit, err := timeScalar.UnitExpr()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		timeScalars = append(timeScalars, &TimeScalar{Name: name, Unit: unit})
+	}
+	sort.Slice(timeScalars, func(i, j int) bool {
+		return timeScalars[i].Name < timeScalars[j].Name
+	})
+
+	return timeScalars, nil
+}
+
+// NewEnvPrefix returns the prefix of the environment variables the generated client is read from, empty if none
+func NewEnvPrefix(generateConfig *gqlgencConfig.GenerateConfig) (string, error) {
+	if generateConfig == nil || generateConfig.EnvPrefix == "" {
+		return "", nil
+	}
+	if generateConfig.Executor || !generateConfig.ShouldGenerateClient() {
+		return "", fmt.Errorf("envPrefix: the client is read from the environment by clientv2, unlike the executor or without client")
+	}
+
+	return generateConfig.EnvPrefix, nil
+}
+
+// NewInt64Scalars returns the configured 64-bit integer scalars sorted by name
+func NewInt64Scalars(generateConfig *gqlgencConfig.GenerateConfig) []string {
+	if generateConfig == nil {
+		return nil
+	}
+
+	int64Scalars := append([]string(nil), generateConfig.Int64Scalars...)
+	sort.Strings(int64Scalars)
+
+	return int64Scalars
+}
+
+// NewPingQuery returns the probe query of the generated Ping method, validated against schema
+func NewPingQuery(schema *ast.Schema, generateConfig *gqlgencConfig.GenerateConfig) (string, error) {
+	query := clientv2.DefaultPingQuery
+	if generateConfig != nil && generateConfig.PingQuery != "" {
+		query = generateConfig.PingQuery
+	}
+
+	if _, errs := gqlparser.LoadQuery(schema, query); errs != nil {
+		return "", fmt.Errorf("%q: %w", query, errs)
+	}
+
+	return query, nil
+}
+
+// TemplateData is the data of the template of the generated client, built by Plugin.MutateConfig.
+type TemplateData struct {
+	Query    *Query
+	Mutation *Mutation
+	// Fragment, Operation and OperationResponse are the fragments, the operations and their responses.
+	Fragment          []*Fragment
+	Operation         []*Operation
+	OperationResponse []*OperationResponse
+	// GenerateClient is false for the models of the operations only, without the client.
+	GenerateClient bool
+	StructSources  []*StructSource
+	Unions         []*Union
+	Getters        []*Getters
+	Clones         []string
+	TimeScalars    []*TimeScalar
+	Int64Scalars   []string
+	TypenameChecks bool
+	Executor       bool
+	Services       []*Service
+	Batches        []*Batch
+	ContextValues  []*ContextValue
+	SchemaHash     string
+	PingQuery      string
+	EnvPrefix      string
+	DocumentMode   string
+	// Header is written above the package line, see NewHeader.
+	Header string
+}
+
+// RenderTemplate generates the client file of client from data.
+func RenderTemplate(cfg *config.Config, data *TemplateData, client config.PackageConfig) error {
 	if err := templates.Render(templates.Options{
 		PackageName: client.Package,
 		Filename:    client.Filename,
-		Data: map[string]interface{}{
-			"Query":             query,
-			"Mutation":          mutation,
-			"Fragment":          fragments,
-			"Operation":         operations,
-			"OperationResponse": operationResponses,
-			"GenerateClient":    generateClient,
-			"StructSources":     structSources,
-		},
-		Packages:   cfg.Packages,
-		PackageDoc: "// Code generated by github.com/Yamashou/gqlgenc, DO NOT EDIT.\n",
+		Data:        data,
+		Packages:    cfg.Packages,
+		PackageDoc:  data.Header,
 	}); err != nil {
 		return fmt.Errorf("%s generating failed: %w", client.Filename, err)
 	}
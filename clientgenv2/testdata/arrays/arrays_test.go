@@ -0,0 +1,59 @@
+package arrays_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pleclech/gqlgenc/clientgenv2/testdata/arrays/gen"
+	"github.com/pleclech/gqlgenc/clientv2"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFixedLists runs the generated client, TestFixedLists of clientgenv2 runs it after the generation.
+func TestFixedLists(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req clientv2.Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		switch req.OperationName {
+		case "GetPoints":
+			_, _ = w.Write([]byte(`{"data": {"points": [{"coordinates": [1, 2.5, 3], "label": "a"}, {"coordinates": [0, 0, 0]}]}}`))
+		case "GetShape":
+			if req.Variables["id"] == "broken" {
+				_, _ = w.Write([]byte(`{"data": {"shape": {"name": "triangle", "corners": [[0, 0], [1, 0], [0, 1]]}}}`))
+
+				return
+			}
+			_, _ = w.Write([]byte(`{"data": {"shape": {"name": "square", "corners": [[0, 0], [1, 0], [1, 1], [0, 1]]}}}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(server.Close)
+	client := gen.NewClient(server.Client(), server.URL)
+	ctx := context.Background()
+
+	points, err := client.GetPoints(ctx)
+	require.NoError(t, err)
+	require.Len(t, points.Points, 2)
+	require.Equal(t, [3]float64{1, 2.5, 3}, points.Points[0].Coordinates)
+	require.Equal(t, [3]float64{}, points.Points[1].Coordinates)
+
+	shape, err := client.GetShape(ctx, "square")
+	require.NoError(t, err)
+	require.Equal(t, &[4][]float64{{0, 0}, {1, 0}, {1, 1}, {0, 1}}, shape.Shape.Corners)
+
+	// a list of another length fails
+	_, err = client.GetShape(ctx, "broken")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `JSON array of 3 elements for [4][]float64 at "shape.corners`)
+}
@@ -0,0 +1,52 @@
+package batches_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pleclech/gqlgenc/clientgenv2/testdata/batches/gen"
+	"github.com/pleclech/gqlgenc/clientv2"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMutationBatches runs the generated client, TestMutationBatches of clientgenv2 runs it after the generation.
+func TestMutationBatches(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req clientv2.Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		// the mutations are sent in one document, run in a transaction
+		if req.OperationName != "SaveProfile" || r.Header.Get("X-Transaction") != "atomic" || !strings.Contains(req.Query, "@transaction") {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+		if req.Variables["UpdateUser_id"] != "1" || req.Variables["CreatePost_withAuthor"] != true {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": {
+			"UpdateUser_updateUser": {"id": "1", "name": "gopher"},
+			"CreatePost_post": {"id": "2", "title": "Go", "author": {"id": "1", "name": "gopher"}}
+		}}`))
+	}))
+	t.Cleanup(server.Close)
+	client := gen.NewClient(server.Client(), server.URL)
+
+	res, err := client.SaveProfile(context.Background(), "1", "gopher", gen.PostInput{Title: "Go", Tags: []string{}}, true)
+	require.NoError(t, err)
+	require.Equal(t, "gopher", res.UpdateUser.UpdateUser.Name)
+	require.Equal(t, "2", res.CreatePost.Post.ID)
+	require.Equal(t, "gopher", res.CreatePost.Post.Author.Name)
+}
@@ -0,0 +1,44 @@
+package clone_test
+
+import (
+	"testing"
+
+	"github.com/pleclech/gqlgenc/clientgenv2/testdata/clone/gen"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClone modifies the clones of the generated types, TestClone of clientgenv2 runs it after the generation.
+func TestClone(t *testing.T) {
+	t.Parallel()
+
+	var res *gen.GetUser
+	require.Nil(t, res.Clone())
+
+	name, city := "gopher", "Paris"
+	res = &gen.GetUser{User: &gen.GetUser_User{
+		ID:      "1",
+		Name:    &name,
+		Tags:    []string{"go"},
+		Address: &gen.GetUser_User_Address{City: &city},
+		Friends: []*gen.GetUser_User_Friends{{ID: "2", Name: &name}},
+	}}
+	clone := res.Clone()
+	require.Equal(t, res, clone)
+
+	// the pointers and slices of the clone are its own
+	*clone.User.Name = "edited"
+	clone.User.Tags[0] = "edited"
+	*clone.User.Address.City = "edited"
+	clone.User.Friends[0].ID = "edited"
+	require.Equal(t, "gopher", *res.User.Name)
+	require.Equal(t, []string{"go"}, res.User.Tags)
+	require.Equal(t, "Paris", *res.User.Address.City)
+	require.Equal(t, "2", res.User.Friends[0].ID)
+
+	// the name shared by the user and its friend is still shared in the clone
+	require.Same(t, clone.User.Name, clone.User.Friends[0].Name)
+
+	address := res.User.Address.Clone()
+	*address.City = "Lyon"
+	require.Equal(t, "Paris", *res.User.Address.City)
+}
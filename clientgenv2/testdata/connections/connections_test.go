@@ -0,0 +1,72 @@
+package connections_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pleclech/gqlgenc/clientgenv2/testdata/connections/gen"
+	"github.com/pleclech/gqlgenc/clientv2"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConnectionMerge runs the generated client, TestConnectionMerge of clientgenv2 runs it after the generation.
+func TestConnectionMerge(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req clientv2.Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		// two pages of users, the second after the cursor of the first
+		if req.Variables["after"] == nil {
+			_, _ = w.Write([]byte(`{"data": {"users": {"nodes": [{"id": "1", "name": "a"}, {"id": "2", "name": "b"}], "pageInfo": {"endCursor": "2", "hasNextPage": true}}}}`))
+
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": {"users": {"nodes": [{"id": "3", "name": "c"}], "pageInfo": {"endCursor": "3", "hasNextPage": false}}}}`))
+	}))
+	t.Cleanup(server.Close)
+	client := gen.NewClient(server.Client(), server.URL)
+	ctx := context.Background()
+
+	role := gen.RoleAdmin
+	require.Equal(t, `users({"role":"ADMIN"})`, gen.ListUsersConnectionKey(&role))
+	require.Equal(t, gen.ListUsersConnectionKey(&role), gen.ListAdminsConnectionKey(), "a literal argument is keyed as a variable of its value")
+	require.Equal(t, "friends", gen.ListFriendsConnectionKey())
+
+	connections := clientv2.NewConnections()
+	first, err := client.ListUsers(ctx, &role, nil, nil)
+	require.NoError(t, err)
+	merged := gen.CacheListUsers(connections, first, &role)
+	require.Len(t, merged.Users.Nodes, 2)
+
+	second, err := client.ListUsers(ctx, &role, nil, merged.Users.PageInfo.EndCursor)
+	require.NoError(t, err)
+	merged = gen.CacheListUsers(connections, second, &role)
+
+	var ids []string
+	for _, node := range merged.Users.Nodes {
+		ids = append(ids, node.ID)
+	}
+	require.Equal(t, []string{"1", "2", "3"}, ids, "the pages are merged under the same key")
+	require.False(t, merged.Users.PageInfo.HasNextPage)
+	require.Equal(t, "3", *merged.Users.PageInfo.EndCursor)
+
+	cached, ok := connections.Get(gen.ListUsersConnectionKey(&role))
+	require.True(t, ok)
+	require.Same(t, merged, cached)
+
+	// another filter is another connection
+	member := gen.RoleMember
+	other := gen.CacheListUsers(connections, second, &member)
+	require.Len(t, other.Users.Nodes, 1)
+
+	require.Nil(t, gen.MergeListFriends(nil, nil))
+}
@@ -0,0 +1,67 @@
+package contextvalues_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pleclech/gqlgenc/clientgenv2/testdata/contextvalues/gen"
+	"github.com/pleclech/gqlgenc/clientv2"
+	"github.com/stretchr/testify/require"
+)
+
+// TestContextValues runs the generated client, TestContextValues of clientgenv2 runs it after the generation.
+func TestContextValues(t *testing.T) {
+	t.Parallel()
+
+	ctx := gen.WithAuthToken(context.Background(), "secret")
+	token, ok := gen.AuthTokenFrom(ctx)
+	require.True(t, ok)
+	require.Equal(t, "secret", token)
+	_, ok = gen.TenantIDFrom(ctx)
+	require.False(t, ok)
+
+	requests := make(chan *http.Request, 1)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req clientv2.Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+		requests <- r
+		if req.OperationName == "UpdateUser" {
+			_, _ = w.Write([]byte(`{"data": {"updateUser": {"id": "1", "name": "gopher"}}}`))
+
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": {"user": {"id": "1", "name": "gopher"}}}`))
+	})
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	tenant := httptest.NewServer(handler)
+	t.Cleanup(tenant.Close)
+	client := gen.NewClient(server.Client(), server.URL)
+
+	// the values of the context are sent in their headers
+	_, err := client.GetUser(gen.WithTenantID(ctx, "acme"), "1")
+	require.NoError(t, err)
+	r := <-requests
+	require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+	require.Equal(t, "acme", r.Header.Get("X-Tenant-ID"))
+	require.Empty(t, r.Header.Get(clientv2.IdempotencyKeyHeader))
+
+	// the endpoint of the context overrides the base URL, and the key of the context is the idempotency key of the mutation
+	ctx = gen.WithIdempotencyKey(gen.WithEndpoint(ctx, tenant.URL+"/graphql"), "key")
+	endpoint, ok := gen.EndpointFrom(ctx)
+	require.True(t, ok)
+	require.Equal(t, tenant.URL+"/graphql", endpoint)
+	_, err = client.UpdateUser(ctx, "1", "gopher")
+	require.NoError(t, err)
+	r = <-requests
+	require.Equal(t, "/graphql", r.URL.Path)
+	require.Equal(t, "key", r.Header.Get(clientv2.IdempotencyKeyHeader))
+	require.Empty(t, r.Header.Get("X-Tenant-ID"))
+}
@@ -0,0 +1,53 @@
+package documents_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pleclech/gqlgenc/clientgenv2/testdata/documents/gen"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDocumentMode runs the generated client, TestDocumentMode of clientgenv2 runs it after the generation.
+func TestDocumentMode(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+		// the registry knows the documents by id, the requests carrying their text are rejected
+		if _, ok := req["query"]; ok {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		switch req["documentId"] {
+		case "user-v1":
+			_, _ = w.Write([]byte(`{"data": {"user": {"id": "1", "name": "gopher"}}}`))
+		case "sha256:" + gen.RenameOperation.QueryHash:
+			_, _ = w.Write([]byte(`{"data": {"rename": {"id": "1", "name": "golang"}}}`))
+		default:
+			_, _ = w.Write([]byte(`{"errors": [{"message": "unknown document"}]}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	client := gen.NewClient(server.Client(), server.URL)
+	ctx := context.Background()
+
+	user, err := client.GetUser(ctx, "1")
+	require.NoError(t, err)
+	require.Equal(t, "gopher", user.User.Name)
+	require.Equal(t, "user-v1", gen.GetUserOperation.DocumentID)
+
+	renamed, err := client.Rename(ctx, "1", "golang")
+	require.NoError(t, err)
+	require.Equal(t, "golang", renamed.Rename.Name)
+}
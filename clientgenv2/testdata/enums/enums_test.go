@@ -0,0 +1,70 @@
+package enums_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pleclech/gqlgenc/clientgenv2/testdata/enums/gen"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExhaustiveEnums runs the generated client, TestExhaustiveEnums of clientgenv2 runs it after the generation.
+func TestExhaustiveEnums(t *testing.T) {
+	t.Parallel()
+
+	role := "EDITOR"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"data": {"user": {"id": "1", "role": %q, "status": "SUSPENDED"}}}`, role)
+	}))
+	t.Cleanup(server.Close)
+	client := gen.NewClient(server.Client(), server.URL)
+
+	describe := func(role gen.Role) (string, error) {
+		var description string
+		err := role.Switch(func() error {
+			description = "admin"
+
+			return nil
+		}, func() error {
+			description = "editor"
+
+			return nil
+		}, func() error {
+			description = "viewer"
+
+			return nil
+		})
+
+		return description, err
+	}
+
+	// the decoded enum calls the function of its value
+	res, err := client.GetUser(context.Background(), "1")
+	require.NoError(t, err)
+	description, err := describe(res.User.Role)
+	require.NoError(t, err)
+	require.Equal(t, "editor", description)
+
+	var suspended bool
+	require.NoError(t, res.User.Status.Switch(func() error {
+		return nil
+	}, func() error {
+		suspended = true
+
+		return nil
+	}))
+	require.True(t, suspended)
+
+	// the error of the function is the one of the switch
+	err = gen.RoleAdmin.Switch(func() error {
+		return fmt.Errorf("admins are handled elsewhere")
+	}, nil, nil)
+	require.EqualError(t, err, "admins are handled elsewhere")
+
+	// a value the server added since the generation is not of the enum
+	_, err = describe(gen.Role("OWNER"))
+	require.EqualError(t, err, "OWNER is not a valid Role")
+}
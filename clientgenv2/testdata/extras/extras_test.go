@@ -0,0 +1,64 @@
+package extras_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pleclech/gqlgenc/clientgenv2/testdata/extras/gen"
+	"github.com/stretchr/testify/require"
+)
+
+// TestInputRawExtras runs the generated client, TestInputRawExtras of clientgenv2 runs it after the generation.
+func TestInputRawExtras(t *testing.T) {
+	t.Parallel()
+
+	bodies := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+		bodies <- string(body)
+		_, _ = w.Write([]byte(`{"data": {"updateUser": {"id": "1", "name": "gopher"}}}`))
+	}))
+	t.Cleanup(server.Close)
+	client := gen.NewClient(server.Client(), server.URL)
+
+	// the raw extras are sent along the typed fields, sorted by name, the ones of the nested inputs too
+	name := "gopher"
+	input := gen.UserInput{
+		ID:        "1",
+		Name:      &name,
+		Address:   &gen.AddressInput{City: "Paris", RawExtras: json.RawMessage(`{"zip": "75001"}`)},
+		RawExtras: json.RawMessage(`{"nickname": "gg", "locale": {"language": "fr"}}`),
+	}
+	_, err := client.UpdateUser(context.Background(), input)
+	require.NoError(t, err)
+	var req struct {
+		Variables struct {
+			Input json.RawMessage `json:"input"`
+		} `json:"variables"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(<-bodies), &req))
+	require.Equal(t, `{"id":"1","name":"gopher","address":{"city":"Paris","zip":"75001"},"locale":{"language":"fr"},"nickname":"gg"}`, string(req.Variables.Input))
+
+	// without raw extras, the input is encoded as its fields
+	encoded, err := json.Marshal(gen.AddressInput{City: "Paris"})
+	require.NoError(t, err)
+	require.Equal(t, `{"city":"Paris"}`, string(encoded))
+
+	// a raw extra cannot replace a typed field, even a field left out of the encoding
+	_, err = json.Marshal(gen.UserInput{ID: "1", RawExtras: json.RawMessage(`{"name": "gordon"}`)})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "raw extras: name is a field of the input")
+
+	_, err = json.Marshal(gen.UserInput{ID: "1", RawExtras: json.RawMessage(`["name"]`)})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "raw extras:")
+}
@@ -0,0 +1,32 @@
+package getters_test
+
+import (
+	"testing"
+
+	"github.com/pleclech/gqlgenc/clientgenv2/testdata/getters/gen"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetters walks the generated types with their getters, TestGetters of clientgenv2 runs it after the generation.
+func TestGetters(t *testing.T) {
+	t.Parallel()
+
+	var res *gen.GetUser
+	require.Nil(t, res.GetUser().GetAddress().GetCountry())
+	require.Equal(t, "", res.GetUser().GetAddress().GetCountry().GetCode())
+	require.Equal(t, "", res.GetUser().GetProfile().GetBio())
+	require.Empty(t, res.GetUser().GetFriends())
+
+	city := "Paris"
+	res = &gen.GetUser{User: &gen.GetUser_User{
+		Address: &gen.GetUser_User_Address{City: &city, Country: &gen.GetUser_User_Address_Country{Code: "FR"}},
+		Profile: gen.GetUser_User_Profile{Bio: "gopher"},
+	}}
+	require.Equal(t, &city, res.GetUser().GetAddress().GetCity())
+	require.Equal(t, "FR", res.GetUser().GetAddress().GetCountry().GetCode())
+	require.Equal(t, "gopher", res.GetUser().GetProfile().GetBio())
+
+	// the getter of the profile returns the profile of the user
+	res.GetUser().GetProfile().Bio = "edited"
+	require.Equal(t, "edited", res.User.Profile.Bio)
+}
@@ -0,0 +1,85 @@
+package raw_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pleclech/gqlgenc/clientgenv2/testdata/raw/gen"
+	"github.com/pleclech/gqlgenc/clientv2"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRawMethods runs the generated client, TestRawMethods of clientgenv2 runs it after the generation.
+func TestRawMethods(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+		var req clientv2.Request
+		if err := json.Unmarshal(body, &req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		switch req.OperationName {
+		case "":
+			// the ad-hoc queries of RawExecute are sent without operation name
+			if bytes.Contains(body, []byte("operationName")) || req.Variables["id"] != "1" {
+				w.WriteHeader(http.StatusBadRequest)
+
+				return
+			}
+			_, _ = w.Write([]byte(`{"data": {"user": {"name": "gopher"}}}`))
+		case "GetUser":
+			_, _ = w.Write([]byte(`{"data": {"user":  {"name": "gopher",  "id": "1", "extra": true}}}`))
+		case "Rename":
+			_, _ = w.Write([]byte(`{"data": {"rename": null}, "errors": [{"message": "forbidden", "path": ["rename"]}]}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(server.Close)
+	client := gen.NewClient(server.Client(), server.URL)
+	ctx := context.Background()
+
+	// the data is not decoded, the keys unknown to the response type and the spaces are kept
+	data, errs, err := client.GetUserRaw(ctx, "1")
+	require.NoError(t, err)
+	require.Empty(t, errs)
+	require.Equal(t, `{"user":  {"name": "gopher",  "id": "1", "extra": true}}`, string(data))
+
+	data, errs, err = client.RenameRaw(ctx, "1", "gophie")
+	require.NoError(t, err)
+	require.Equal(t, `{"rename": null}`, string(data))
+	require.Len(t, errs, 1)
+	require.Equal(t, "forbidden", errs[0].Message)
+
+	_, _, err = client.GetUsersRaw(ctx, []string{"1"})
+	var errResponse *clientv2.ErrorResponse
+	require.True(t, errors.As(err, &errResponse))
+	require.Equal(t, http.StatusInternalServerError, errResponse.NetworkError.Code)
+
+	_, _, err = client.GetUsersRaw(ctx, nil)
+	require.True(t, errors.Is(err, clientv2.ErrMissingVariable))
+
+	// RawExecute runs a query which is not generated, decoding its data into out
+	var out struct {
+		User struct {
+			Name string `graphql:"name"`
+		} `graphql:"user"`
+	}
+	require.NoError(t, client.RawExecute(ctx, `query ($id: ID!) { user(id: $id) { name } }`, map[string]interface{}{"id": "1"}, &out))
+	require.Equal(t, "gopher", out.User.Name)
+}
@@ -0,0 +1,56 @@
+package required_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pleclech/gqlgenc/clientgenv2/testdata/required/gen"
+	"github.com/pleclech/gqlgenc/clientv2"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRequiredVariables runs the generated client, TestRequiredVariables of clientgenv2 runs it after the generation.
+func TestRequiredVariables(t *testing.T) {
+	t.Parallel()
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = w.Write([]byte(`{"data": {}}`))
+	}))
+	t.Cleanup(server.Close)
+	client := gen.NewClient(server.Client(), server.URL)
+	ctx := context.Background()
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := client.GetUsers(ctx, nil, nil, 10)
+		require.True(t, errors.Is(err, clientv2.ErrMissingVariable))
+		require.EqualError(t, err, "GetUsers: missing required variable $ids")
+
+		_, err = client.GetUsersResult(ctx, nil, nil, 10)
+		require.EqualError(t, err, "GetUsers: missing required variable $ids")
+
+		_, err = client.TagUser(ctx, "1", []string{"go"}, nil)
+		require.EqualError(t, err, "TagUser: missing required variable $meta")
+
+		_, _, err = client.UserTagged(ctx, nil)
+		require.EqualError(t, err, "UserTagged: missing required variable $ids")
+
+		// no request is sent
+		require.Zero(t, atomic.LoadInt32(&requests))
+	})
+
+	t.Run("provided", func(t *testing.T) {
+		// an empty list is a value, the nil optional variables are not sent
+		_, err := client.GetUsers(ctx, []string{}, nil, 10)
+		require.NoError(t, err)
+
+		_, err = client.TagUser(ctx, "1", []string{"go"}, map[string]interface{}{"source": "test"})
+		require.NoError(t, err)
+		require.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	})
+}
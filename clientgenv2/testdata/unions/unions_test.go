@@ -0,0 +1,65 @@
+package unions_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pleclech/gqlgenc/clientgenv2/testdata/unions/gen"
+	"github.com/pleclech/gqlgenc/clientv2"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUnionInterfaces runs the generated client, TestUnionInterfaces of clientgenv2 runs it after the generation.
+func TestUnionInterfaces(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req clientv2.Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		switch req.OperationName {
+		case "Search":
+			_, _ = w.Write([]byte(`{"data": {"search": [
+				{"__typename": "User", "id": "1", "name": "gopher"},
+				{"__typename": "Org", "id": "2", "name": "golang", "members": 3}
+			]}}`))
+		case "Favorite":
+			_, _ = w.Write([]byte(`{"data": {"favorite": {"__typename": "Org", "id": "2", "members": 3}}}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(server.Close)
+	client := gen.NewClient(server.Client(), server.URL)
+	ctx := context.Background()
+
+	search, err := client.Search(ctx, "go")
+	require.NoError(t, err)
+	var results []string
+	for _, result := range search.Search {
+		switch r := result.(type) {
+		case *gen.Search_Search_User:
+			results = append(results, fmt.Sprintf("user %s %s", r.ID, r.Name))
+		case *gen.Search_Search_Org:
+			results = append(results, fmt.Sprintf("org %s %s of %d", r.ID, r.Name, r.Members))
+		default:
+			t.Fatalf("unexpected result %T", r)
+		}
+	}
+	require.Equal(t, []string{"user 1 gopher", "org 2 golang of 3"}, results)
+
+	favorite, err := client.Favorite(ctx)
+	require.NoError(t, err)
+	org, ok := favorite.Favorite.(*gen.Favorite_Favorite_Org)
+	require.True(t, ok, "%T", favorite.Favorite)
+	require.Equal(t, "2", org.ID)
+	require.Equal(t, 3, org.Members)
+}
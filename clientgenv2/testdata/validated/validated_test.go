@@ -0,0 +1,63 @@
+package validated_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pleclech/gqlgenc/clientgenv2/testdata/validated/gen"
+	"github.com/pleclech/gqlgenc/clientv2"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidatedScalars runs the generated client, TestValidatedScalars of clientgenv2 runs it after the generation.
+func TestValidatedScalars(t *testing.T) {
+	t.Parallel()
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		var req clientv2.Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		switch req.OperationName {
+		case "GetUser":
+			_, _ = w.Write([]byte(`{"data": {"user": {"id": "1", "email": "gopher@golang.org", "website": "https://go.dev"}}}`))
+		case "UpdateProfile":
+			_, _ = w.Write([]byte(`{"data": {"updateProfile": {"id": "1", "website": "https://go.dev"}}}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(server.Close)
+	client := gen.NewClient(server.Client(), server.URL)
+	ctx := context.Background()
+
+	// the invalid variables fail to marshal, before any request is sent
+	_, err := client.GetUser(ctx, "gopher")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `invalid Email "gopher"`)
+	website := gen.URL("go.dev")
+	_, err = client.UpdateProfile(ctx, gen.ProfileInput{Email: "gopher@golang.org", Website: &website})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `invalid URL "go.dev"`)
+	require.Zero(t, atomic.LoadInt32(&requests))
+
+	user, err := client.GetUser(ctx, "gopher@golang.org")
+	require.NoError(t, err)
+	require.Equal(t, gen.Email("gopher@golang.org"), user.User.Email)
+	require.Equal(t, gen.URL("https://go.dev"), *user.User.Website)
+
+	website = "https://go.dev"
+	profile, err := client.UpdateProfile(ctx, gen.ProfileInput{Email: "gopher@golang.org", Website: &website})
+	require.NoError(t, err)
+	require.Equal(t, "1", profile.UpdateProfile.ID)
+	require.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
@@ -0,0 +1,109 @@
+package variables_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pleclech/gqlgenc/clientgenv2/testdata/variables/gen"
+	"github.com/pleclech/gqlgenc/clientv2"
+	"github.com/stretchr/testify/require"
+)
+
+// TestVariables runs the generated client, TestOperationVariables of clientgenv2 runs it after the generation.
+func TestVariables(t *testing.T) {
+	t.Parallel()
+
+	var sent json.RawMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		var req struct {
+			Variables json.RawMessage `json:"variables"`
+		}
+		require.NoError(t, json.Unmarshal(body, &req))
+		sent = req.Variables
+		if sent == nil {
+			// no variables are sent for an empty map
+			sent = json.RawMessage(`{}`)
+		}
+		_, _ = w.Write([]byte(`{"data": {}}`))
+	}))
+	t.Cleanup(server.Close)
+	client := gen.NewClient(server.Client(), server.URL)
+
+	name := "gopher"
+	first := 5
+	tests := []struct {
+		name      string
+		variables gen.ListUsersVariables
+		want      string
+	}{
+		{"nil optional variables", gen.ListUsersVariables{}, `{}`},
+		{
+			"set optional variables",
+			gen.ListUsersVariables{Filter: &gen.UserFilter{Name: &name}, Names: []string{}, First: &first, Variables2: &name},
+			`{"filter": {"name": "gopher"}, "names": [], "first": 5, "variables": "gopher"}`,
+		},
+	}
+	for _, tt := range tests {
+		v := tt.variables
+		_, err := client.ListUsers(context.Background(), v.Filter, v.Names, v.First, v.Variables2)
+		require.NoError(t, err, tt.name)
+		require.JSONEq(t, tt.want, string(sent), tt.name)
+
+		variables, err := json.Marshal(v.Variables())
+		require.NoError(t, err, tt.name)
+		require.JSONEq(t, string(sent), string(variables), tt.name)
+	}
+
+	input := gen.CreateUserInput{Name: name}
+	_, err := client.CreateUser(context.Background(), input)
+	require.NoError(t, err)
+	variables, err := json.Marshal((&gen.CreateUserVariables{Input: input}).Variables())
+	require.NoError(t, err)
+	require.JSONEq(t, string(sent), string(variables))
+	require.JSONEq(t, `{"input": {"name": "gopher"}}`, string(sent))
+}
+
+// TestOperationMetadata checks the metadata of the operations given to the interceptors by the context of their requests.
+func TestOperationMetadata(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data": {}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	var operations []clientv2.Operation
+	record := func(ctx context.Context, req *http.Request, gqlInfo *clientv2.GQLRequestInfo, res interface{}, next clientv2.RequestInterceptorFunc) error {
+		operation, ok := clientv2.OperationFromContext(ctx)
+		require.True(t, ok)
+		require.Equal(t, operation.Name, gqlInfo.Request.OperationName)
+		operations = append(operations, operation)
+
+		return next(ctx, req, gqlInfo, res)
+	}
+	client := gen.NewClient(server.Client(), server.URL, record)
+
+	_, err := client.ListUsers(context.Background(), nil, nil, nil, nil)
+	require.NoError(t, err)
+	_, err = client.CreateUser(context.Background(), gen.CreateUserInput{})
+	require.NoError(t, err)
+
+	hash := func(document string) string {
+		sum := sha256.Sum256([]byte(document))
+
+		return hex.EncodeToString(sum[:])
+	}
+	require.Equal(t, []clientv2.Operation{
+		{Name: "ListUsers", Type: "query", QueryHash: hash(gen.ListUsersDocument)},
+		{Name: "CreateUser", Type: "mutation", QueryHash: hash(gen.CreateUserDocument)},
+	}, operations)
+	require.Equal(t, operations, []clientv2.Operation{gen.ListUsersOperation, gen.CreateUserOperation})
+}
@@ -0,0 +1,171 @@
+package clientgenv2
+
+import (
+	"fmt"
+	"go/types"
+
+	"github.com/99designs/gqlgen/codegen/templates"
+	gqlgencConfig "github.com/pleclech/gqlgenc/config"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Union is the selection of a field of a union type, generated with unionInterfaces as an interface
+// implemented by a struct per member of the union, the objects being decoded into the struct of their __typename.
+type Union struct {
+	// Name is the go name of the interface, Union the name of the union in the schema.
+	Name    string
+	Union   string
+	Members []*UnionMember
+	// Typename reports whether every member selects __typename, which the decoder dispatches the objects on.
+	Typename bool
+}
+
+// UnionMember is a member of the union of a Union, and its struct of the fields selected on it.
+type UnionMember struct {
+	Typename string
+	TypeName string
+}
+
+// isUnionInterface reports whether the field is generated as the interface of its union.
+func (r *SourceGenerator) isUnionInterface(field *ast.Field) bool {
+	if r.generate == nil || !r.generate.UnionInterfaces || len(field.SelectionSet) == 0 {
+		return false
+	}
+	definition := r.cfg.Schema.Types[field.Definition.Type.Name()]
+
+	return definition != nil && definition.Kind == ast.Union
+}
+
+// NewUnionType returns the interface of the selection of the field of a union type, named typeName,
+// and adds the structs of its members to the generated structs.
+func (r *SourceGenerator) NewUnionType(field *ast.Field, typeName string) types.Type {
+	definition := r.cfg.Schema.Types[field.Definition.Type.Name()]
+	name := r.generate.TypeName(typeName)
+	union := &Union{
+		Name:     name,
+		Union:    definition.Name,
+		Typename: true,
+	}
+
+	for _, member := range r.cfg.Schema.GetPossibleTypes(definition) {
+		selections := mergeSelections(r.memberSelections(field.SelectionSet, member.Name))
+		if !selectsTypename(selections) {
+			union.Typename = false
+		}
+
+		memberName := NewLayerTypeName(typeName, templates.ToGo(member.Name))
+		structType := r.StructType(memberName, r.NewResponseFields(selections, memberName))
+		r.StructSources = append(r.StructSources, &StructSource{
+			Name: r.generate.TypeName(memberName),
+			Type: structType,
+		})
+		union.Members = append(union.Members, &UnionMember{
+			Typename: member.Name,
+			TypeName: r.generate.TypeName(memberName),
+		})
+	}
+	r.Unions = append(r.Unions, union)
+
+	marker := types.NewFunc(0, r.client.Pkg(), "is"+name, types.NewSignature(nil, nil, nil, false))
+	iface := types.NewInterfaceType([]*types.Func{marker}, nil).Complete()
+
+	return types.NewNamed(types.NewTypeName(0, r.client.Pkg(), name, nil), iface, nil)
+}
+
+// memberSelections returns the selections of selectionSet applying to the member of a union, the fields selected on the union
+// and those of the fragments on the member, the union or an interface of the member flattened.
+func (r *SourceGenerator) memberSelections(selectionSet ast.SelectionSet, member string) ast.SelectionSet {
+	var selections ast.SelectionSet
+	for _, selection := range selectionSet {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			selections = append(selections, selection)
+		case *ast.InlineFragment:
+			if r.appliesTo(selection.TypeCondition, member) {
+				selections = append(selections, r.memberSelections(selection.SelectionSet, member)...)
+			}
+		case *ast.FragmentSpread:
+			if r.appliesTo(selection.Definition.TypeCondition, member) {
+				selections = append(selections, r.memberSelections(selection.Definition.SelectionSet, member)...)
+			}
+		}
+	}
+
+	return selections
+}
+
+// appliesTo reports whether a fragment of the type condition applies to the objects of the type member.
+func (r *SourceGenerator) appliesTo(typeCondition, member string) bool {
+	if typeCondition == "" || typeCondition == member {
+		return true
+	}
+	definition := r.cfg.Schema.Types[typeCondition]
+	if definition == nil {
+		return false
+	}
+	for _, possible := range r.cfg.Schema.GetPossibleTypes(definition) {
+		if possible.Name == member {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mergeSelections merges the fields of selections having the same alias, like a field selected on the union and in a fragment,
+// into one field of their selection sets merged.
+func mergeSelections(selections ast.SelectionSet) ast.SelectionSet {
+	merged := make(ast.SelectionSet, 0, len(selections))
+	fields := make(map[string]*ast.Field)
+	for _, selection := range selections {
+		field, ok := selection.(*ast.Field)
+		if !ok {
+			merged = append(merged, selection)
+
+			continue
+		}
+		if previous, ok := fields[field.Alias]; ok {
+			previous.SelectionSet = append(previous.SelectionSet, field.SelectionSet...)
+
+			continue
+		}
+		copied := *field
+		copied.SelectionSet = append(ast.SelectionSet(nil), field.SelectionSet...)
+		fields[field.Alias] = &copied
+		merged = append(merged, &copied)
+	}
+	for _, field := range fields {
+		field.SelectionSet = mergeSelections(field.SelectionSet)
+	}
+
+	return merged
+}
+
+// selectsTypename reports whether selections select __typename, unaliased.
+func selectsTypename(selections ast.SelectionSet) bool {
+	for _, selection := range selections {
+		if field, ok := selection.(*ast.Field); ok && field.Name == "__typename" && field.Alias == "__typename" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkUnions fails when the union interfaces cannot be decoded: the selections of unions must select __typename,
+// and the client registering the members into the decoder must be generated.
+func checkUnions(unions []*Union, generateConfig *gqlgencConfig.GenerateConfig) error {
+	if len(unions) == 0 {
+		return nil
+	}
+	if !generateConfig.ShouldGenerateClient() || generateConfig.Executor {
+		return fmt.Errorf("unionInterfaces: the members of %s are decoded by the generated client of clientv2, unlike the executor or without client", unions[0].Name)
+	}
+	for _, union := range unions {
+		if !union.Typename {
+			return fmt.Errorf("unionInterfaces: %s of the union %s does not select __typename on every member", union.Name, union.Union)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package clientgenv2
+
+import (
+	"fmt"
+	"go/types"
+
+	"github.com/99designs/gqlgen/codegen/templates"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Variables is the generated struct of the variables of an operation, whose Variables method returns them as sent.
+type Variables struct {
+	Name   string
+	Fields []*VariableField
+}
+
+// VariableField is a variable of an operation in its variables struct.
+type VariableField struct {
+	// Name is the name of the struct field.
+	Name     string
+	Variable string
+	Type     types.Type
+	// Optional reports whether the variable is nullable and omitted when nil.
+	Optional bool
+}
+
+// NewVariables returns the variables struct of operation, nil if it has no variables.
+// args are the arguments of the variables of the operation, in the order of their definitions,
+// typeName returns the name of the generated type of a go name.
+func NewVariables(operation *ast.OperationDefinition, args []*Argument, typeName func(name string) string) *Variables {
+	if len(operation.VariableDefinitions) == 0 {
+		return nil
+	}
+
+	variables := &Variables{
+		Name: typeName(templates.ToGo(operation.Name) + "Variables"),
+	}
+
+	// Variables is the method of every variables struct
+	names := map[string]bool{"Variables": true}
+	for i, definition := range operation.VariableDefinitions {
+		name := templates.ToGo(definition.Variable)
+		for n := 2; names[name]; n++ {
+			name = fmt.Sprintf("%s%d", templates.ToGo(definition.Variable), n)
+		}
+		names[name] = true
+
+		variables.Fields = append(variables.Fields, &VariableField{
+			Name:     name,
+			Variable: definition.Variable,
+			Type:     args[i].Type,
+			Optional: !definition.Type.NonNull && isNillable(args[i].Type),
+		})
+	}
+
+	return variables
+}
+
+// isNillable reports whether the values of typ can be nil.
+func isNillable(typ types.Type) bool {
+	switch typ.Underlying().(type) {
+	case *types.Pointer, *types.Slice, *types.Map, *types.Interface:
+		return true
+	}
+
+	return false
+}
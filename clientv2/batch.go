@@ -0,0 +1,79 @@
+package clientv2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pleclech/gqlgenc/graphqljson"
+)
+
+// Batch is implemented by the responses of the batches of operations sent in one document, generated by client v2
+// for mutationBatches. The root fields of each operation are aliased in the document with the prefix of the operation,
+// like UpdateUser_updateUser, the data of an operation being decoded into its response with the root fields of its prefix, unaliased.
+type Batch interface {
+	BatchResponses() []BatchResponse
+}
+
+// BatchResponse is the response of an operation of a batch.
+type BatchResponse struct {
+	// Prefix is the prefix of the aliases of the root fields of the operation, like UpdateUser_.
+	Prefix string
+	// Response is where the data of the operation is decoded, like a *UpdateUser.
+	Response interface{}
+}
+
+// WithHeader returns an interceptor setting the header name of the request to value,
+// like the transaction header of the batches of mutations.
+func WithHeader(name, value string) RequestInterceptor {
+	return func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		req.Header.Set(name, value)
+
+		return next(ctx, req, gqlInfo, res)
+	}
+}
+
+// unmarshalData decodes the response data into res, the data of each operation of a batch into its response.
+func unmarshalData(data json.RawMessage, res interface{}, options ...graphqljson.Option) error {
+	batch, ok := res.(Batch)
+	if !ok {
+		return graphqljson.UnmarshalData(data, res, options...)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("batch data: %w", err)
+	}
+	responses := batch.BatchResponses()
+	operations := make([]map[string]json.RawMessage, len(responses))
+	for i := range operations {
+		operations[i] = make(map[string]json.RawMessage)
+	}
+	for key, value := range fields {
+		// a key belongs to the longest prefix it has, the one of UpdateUser_ rather than Update_
+		i := -1
+		for j, response := range responses {
+			if strings.HasPrefix(key, response.Prefix) && (i == -1 || len(response.Prefix) > len(responses[i].Prefix)) {
+				i = j
+			}
+		}
+		if i == -1 {
+			return fmt.Errorf("batch data: field %s of no operation", key)
+		}
+		operations[i][strings.TrimPrefix(key, responses[i].Prefix)] = value
+	}
+
+	for i, response := range responses {
+		operation, err := json.Marshal(operations[i])
+		if err != nil {
+			return fmt.Errorf("batch data of the operation %s: %w", strings.TrimSuffix(response.Prefix, "_"), err)
+		}
+		if err := graphqljson.UnmarshalData(operation, response.Response, options...); err != nil {
+			return fmt.Errorf("batch data of the operation %s: %w", strings.TrimSuffix(response.Prefix, "_"), err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,213 @@
+package clientv2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// ErrNoRecording is returned by a client replaying responses for a request which was not recorded.
+var ErrNoRecording = errors.New("no recorded response")
+
+// recording is a response recorded in a cassette, for an operation and its variables.
+type recording struct {
+	OperationName string          `json:"operationName"`
+	Variables     json.RawMessage `json:"variables"`
+	StatusCode    int             `json:"statusCode"`
+	// Response is the JSON body of the response, Body the body which is not JSON, like an html error page.
+	Response json.RawMessage `json:"response,omitempty"`
+	Body     string          `json:"body,omitempty"`
+}
+
+// cassette is a file of recorded responses, read once by the replayer and written after each recording by the recorder.
+type cassette struct {
+	path string
+
+	mu         sync.Mutex
+	loaded     bool
+	loadErr    error
+	recordings []*recording
+}
+
+// WithRecorder returns an interceptor recording the responses received into the JSON file at path, to be replayed with WithReplayer,
+// like to capture the fixtures of offline tests from a real server. The responses are recorded by operation name and variables,
+// the last response of an operation called several times with the same variables replacing the previous ones.
+// The file is written after each response, replacing the file of a previous recording.
+// The interceptor is shared by the requests over which it records, the events of subscriptions are not recorded.
+func WithRecorder(path string) RequestInterceptor {
+	c := &cassette{path: path, loaded: true}
+
+	return func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		gqlInfo.recorder = c
+
+		return next(ctx, req, gqlInfo, res)
+	}
+}
+
+// WithReplayer returns an interceptor answering the requests with the responses recorded by WithRecorder into the file at path,
+// by operation name and variables, without sending them. A request which was not recorded fails with ErrNoRecording.
+func WithReplayer(path string) RequestInterceptor {
+	c := &cassette{path: path}
+
+	return func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		gqlInfo.replayer = c
+
+		return next(ctx, req, gqlInfo, res)
+	}
+}
+
+// send sends req, or replays its recorded response when responses are replayed.
+func (c *Client) send(req *http.Request, gqlInfo *GQLRequestInfo) (*http.Response, error) {
+	if gqlInfo.replayer == nil {
+		return c.Client.Do(req)
+	}
+
+	return gqlInfo.replayer.replay(gqlInfo.Request)
+}
+
+// recordResponse records the body of the response of the request when responses are recorded.
+func recordResponse(gqlInfo *GQLRequestInfo, statusCode int, body []byte) error {
+	if gqlInfo.recorder == nil || gqlInfo.replayer != nil {
+		return nil
+	}
+
+	return gqlInfo.recorder.record(gqlInfo.Request, statusCode, body)
+}
+
+// replay returns the response recorded for r.
+func (c *cassette) replay(r *Request) (*http.Response, error) {
+	variables, err := normalizeVariables(r.Variables)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.loaded {
+		c.loaded = true
+		c.loadErr = c.load()
+	}
+	if c.loadErr != nil {
+		return nil, c.loadErr
+	}
+
+	recorded := c.find(r.OperationName, variables)
+	if recorded == nil {
+		return nil, fmt.Errorf("%w for %s with the variables %s in %s", ErrNoRecording, r.OperationName, variables, c.path)
+	}
+
+	body := []byte(recorded.Body)
+	if recorded.Response != nil {
+		body = recorded.Response
+	}
+
+	return &http.Response{
+		Status:        http.StatusText(recorded.StatusCode),
+		StatusCode:    recorded.StatusCode,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}, nil
+}
+
+// record records the response of r and writes the cassette.
+func (c *cassette) record(r *Request, statusCode int, body []byte) error {
+	variables, err := normalizeVariables(r.Variables)
+	if err != nil {
+		return err
+	}
+
+	recorded := &recording{
+		OperationName: r.OperationName,
+		Variables:     variables,
+		StatusCode:    statusCode,
+	}
+	if json.Valid(body) {
+		recorded.Response = append(json.RawMessage(nil), body...)
+	} else {
+		recorded.Body = string(body)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if previous := c.find(r.OperationName, variables); previous != nil {
+		*previous = *recorded
+	} else {
+		c.recordings = append(c.recordings, recorded)
+	}
+
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(c.recordings); err != nil {
+		return fmt.Errorf("encode recordings: %w", err)
+	}
+	if err := ioutil.WriteFile(c.path, data.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("write recordings: %w", err)
+	}
+
+	return nil
+}
+
+// load reads the recordings of the cassette file.
+func (c *cassette) load() error {
+	data, err := ioutil.ReadFile(c.path)
+	if err != nil {
+		return fmt.Errorf("read recordings: %w", err)
+	}
+	if err := json.Unmarshal(data, &c.recordings); err != nil {
+		return fmt.Errorf("decode recordings of %s: %w", c.path, err)
+	}
+	for _, recorded := range c.recordings {
+		if recorded.Variables, err = normalizeVariables(recorded.Variables); err != nil {
+			return fmt.Errorf("decode recordings of %s: %w", c.path, err)
+		}
+	}
+
+	return nil
+}
+
+// find returns the recording of the operation and its normalized variables, nil if there is none.
+func (c *cassette) find(operationName string, variables json.RawMessage) *recording {
+	for _, recorded := range c.recordings {
+		if recorded.OperationName == operationName && bytes.Equal(recorded.Variables, variables) {
+			return recorded
+		}
+	}
+
+	return nil
+}
+
+// normalizeVariables returns the JSON encoding of the variables with the keys of their objects sorted and without spaces,
+// the same for the variables of a request whatever their go types and for the ones read from a cassette, null if there are none.
+func normalizeVariables(variables interface{}) (json.RawMessage, error) {
+	data, err := json.Marshal(variables)
+	if err != nil {
+		return nil, fmt.Errorf("encode variables: %w", err)
+	}
+
+	var decoded interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("decode variables: %w", err)
+	}
+	if m, ok := decoded.(map[string]interface{}); ok && len(m) == 0 {
+		decoded = nil
+	}
+
+	data, err = json.Marshal(decoded)
+	if err != nil {
+		return nil, fmt.Errorf("encode variables: %w", err)
+	}
+
+	return data, nil
+}
@@ -4,16 +4,72 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"net/http"
 
-	"github.com/Yamashou/gqlgenc/graphqljson"
+	"github.com/pleclech/gqlgenc/graphqljson"
 	"github.com/vektah/gqlparser/v2/gqlerror"
 )
 
 type GQLRequestInfo struct {
 	Request *Request
+
+	// options of the decoder unmarshaling the response data
+	decoderOptions []graphqljson.Option
+
+	// transforms of the response fields listed in the response extensions, by name
+	fieldTransforms map[string]FieldTransform
+
+	// whether queries are sent with GET, and the length of the URL above which they are sent with POST
+	getForQueries   bool
+	maxGetURLLength int
+
+	// how a response having graphql errors is handled
+	errorPolicy ErrorPolicy
+
+	// idempotency key of a mutation, generated once for all the attempts of the request
+	idempotencyKeys         bool
+	idempotencyKeyGenerator func() (string, error)
+	idempotencyKey          string
+
+	// reports the body sizes of the request and its response, nil if not asked
+	bodySizes func(sizes BodySizes)
+
+	// maximum size of the decompressed response body, 0 if unlimited
+	maxResponseBytes int64
+
+	// reports the time taken to decode the response, nil if not asked
+	decodeDurations func(d DecodeDuration)
+
+	// whether the comments of the response body are stripped
+	jsonComments bool
+
+	// collects the Apollo tracing extension of the response, nil if not asked
+	tracingCollector func(t ApolloTracing)
+
+	// surfaces the values of the keys along the data of the response, nil if not asked
+	envelopeExtractor func(envelope map[string]json.RawMessage)
+	envelopeKeys      []string
+
+	// rewrites the query sent, nil if not asked, and whether it was rewritten by a previous attempt
+	queryRewriter  func(operationName, query string) string
+	queryRewritten bool
+
+	// cassettes where the responses are recorded and from which they are replayed, nil if not asked
+	recorder *cassette
+	replayer *cassette
+
+	// extracts the data and the errors of the response body, nil for the standard responses
+	responseUnwrapper ResponseUnwrapper
+
+	// WebSocket transport of the subscriptions, nil for server-sent events
+	webSocket *webSocketConfig
+
+	// what the requests of the generated operations send for their document, and the request sent without its text, nil if sent with it
+	documentMode DocumentMode
+	persisted    *Request
 }
 
 func NewGQLRequestInfo(r *Request) *GQLRequestInfo {
@@ -26,6 +82,16 @@ type RequestInterceptorFunc func(ctx context.Context, req *http.Request, gqlInfo
 
 type RequestInterceptor func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error
 
+// WithDecoderOptions returns an interceptor configuring the decoder of the response data,
+// e.g. to register scalar converters.
+func WithDecoderOptions(options ...graphqljson.Option) RequestInterceptor {
+	return func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		gqlInfo.decoderOptions = append(gqlInfo.decoderOptions, options...)
+
+		return next(ctx, req, gqlInfo, res)
+	}
+}
+
 func ChainInterceptor(interceptors ...RequestInterceptor) RequestInterceptor {
 	n := len(interceptors)
 
@@ -50,13 +116,22 @@ type Client struct {
 	Client             *http.Client
 	BaseURL            string
 	RequestInterceptor RequestInterceptor
+	// Marshaler encodes the requests, encoding/json if nil. The responses are decoded by graphqljson.
+	Marshaler Marshaler
+
+	// errors matched by the errors of the graphql errors of a code, by code
+	errorCodes map[string]error
 }
 
 // Request represents an outgoing GraphQL request
 type Request struct {
-	Query         string                 `json:"query"`
+	Query         string                 `json:"query,omitempty"`
 	Variables     map[string]interface{} `json:"variables,omitempty"`
 	OperationName string                 `json:"operationName,omitempty"`
+	// Extensions are the extensions of the request, like the persistedQuery extension of the automatic persisted queries.
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+	// DocumentID is the id of the document registered in the operation registry of the server, sent instead of Query.
+	DocumentID string `json:"documentId,omitempty"`
 }
 
 // NewClient creates a new http client wrapper
@@ -70,6 +145,15 @@ func NewClient(client *http.Client, baseURL string, interceptors ...RequestInter
 	}
 }
 
+// ErrMissingVariable is matched by errors.Is for the errors of the generated methods called without a required variable,
+// like a nil slice for a non-null list, returned before sending the request.
+var ErrMissingVariable = errors.New("missing required variable")
+
+// MissingVariableError returns the error of the operation called without its required variable.
+func MissingVariableError(operationName, variable string) error {
+	return fmt.Errorf("%s: %w $%s", operationName, ErrMissingVariable, variable)
+}
+
 // GqlErrorList is the struct of a standard graphql error response
 type GqlErrorList struct {
 	Errors gqlerror.List `json:"errors"`
@@ -91,6 +175,9 @@ type ErrorResponse struct {
 	NetworkError *HTTPError `json:"networkErrors"`
 	// populated when http status code is OK but the server returned at least one graphql error
 	GqlErrors *gqlerror.List `json:"graphqlErrors"`
+
+	// errors registered for the codes of the graphql errors
+	codes []error
 }
 
 // HasErrors returns true when at least one error is declared
@@ -107,7 +194,10 @@ func (er *ErrorResponse) Error() string {
 	return string(content)
 }
 
+// Post sends a http POST request to the graphql endpoint with the given query then unpacks
 // the response into the given object.
+// operationName is always sent so the server runs the right operation when the query
+// document defines several of them.
 func (c *Client) Post(ctx context.Context, operationName, query string, respData interface{}, vars map[string]interface{}, interceptors ...RequestInterceptor) error {
 	r := &Request{
 		Query:         query,
@@ -116,7 +206,7 @@ func (c *Client) Post(ctx context.Context, operationName, query string, respData
 	}
 	gqlInfo := NewGQLRequestInfo(r)
 
-	requestBody, err := json.Marshal(r)
+	requestBody, err := c.marshal(r)
 	if err != nil {
 		return fmt.Errorf("encode: %w", err)
 	}
@@ -133,22 +223,86 @@ func (c *Client) Post(ctx context.Context, operationName, query string, respData
 	return f(ctx, req, gqlInfo, respData, c.do)
 }
 
-func (c *Client) do(_ context.Context, req *http.Request, _ *GQLRequestInfo, res interface{}) error {
-	resp, err := c.Client.Do(req)
+func (c *Client) do(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}) error {
+	if err := setIdempotencyKey(req, gqlInfo); err != nil {
+		return err
+	}
+	setCloseConnection(req)
+
+	// an interceptor retrying the request sends it again, rewind the body consumed by the previous attempt
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return fmt.Errorf("rewind request body failed: %w", err)
+		}
+		req.Body = body
+	}
+
+	if err := rewriteQuery(req, gqlInfo, c.marshal); err != nil {
+		return err
+	}
+	if err := persistDocument(ctx, req, gqlInfo, c.marshal); err != nil {
+		return err
+	}
+
+	req, err := getRequest(req, gqlInfo, c.marshal)
+	if err != nil {
+		return err
+	}
+
+	// the transport hides the size of the compressed responses it decompresses
+	if gqlInfo.bodySizes != nil && req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+
+	resp, err := c.send(req, gqlInfo)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := readResponseBody(req, resp, gqlInfo)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		// the connection dropped before the end of the body
+		if truncated := graphqljson.CheckTruncated(body); truncated != nil {
+			err = truncated
+		}
+	}
 	if err != nil {
 		return fmt.Errorf("failed to read response body: %w", err)
 	}
+	if err := recordResponse(gqlInfo, resp.StatusCode, body); err != nil {
+		return fmt.Errorf("failed to record response: %w", err)
+	}
+	body = graphqljson.TrimBOM(body)
+	body, err = stripComments(body, gqlInfo)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	collectTracing(body, gqlInfo)
+	extractEnvelope(body, gqlInfo)
+
+	body, err = transformFields(body, gqlInfo.fieldTransforms)
+	if err != nil {
+		return fmt.Errorf("failed to transform response fields: %w", err)
+	}
+	body, err = unwrapResponse(body, gqlInfo)
+	if err != nil && resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return fmt.Errorf("failed to unwrap response: %w", err)
+	}
+
+	return timeDecode(body, gqlInfo, func() error {
+		if gqlInfo.errorPolicy != ErrorPolicyNone {
+			if err := unmarshalPartialData(body, res, gqlInfo.decoderOptions...); err != nil {
+				return err
+			}
+		}
 
-	return parseResponse(body, resp.StatusCode, res)
+		return applyErrorPolicy(c.mapErrorCodes(parseResponse(body, resp.StatusCode, res, gqlInfo.decoderOptions...)), gqlInfo.errorPolicy)
+	})
 }
 
-func parseResponse(body []byte, httpCode int, result interface{}) error {
+func parseResponse(body []byte, httpCode int, result interface{}, options ...graphqljson.Option) error {
 	errResponse := &ErrorResponse{}
 	isKOCode := httpCode < 200 || 299 < httpCode
 	if isKOCode {
@@ -159,7 +313,7 @@ func parseResponse(body []byte, httpCode int, result interface{}) error {
 	}
 
 	// some servers return a graphql error with a non OK http code, try anyway to parse the body
-	if err := unmarshal(body, result); err != nil {
+	if err := unmarshal(body, result, options...); err != nil {
 		if gqlErr, ok := err.(*GqlErrorList); ok {
 			errResponse.GqlErrors = &gqlErr.Errors
 		} else if !isKOCode { // if is KO code there is already the http error, this error should not be returned
@@ -180,9 +334,22 @@ type response struct {
 	Errors json.RawMessage `json:"errors"`
 }
 
-func unmarshal(data []byte, res interface{}) error {
+// UnmarshalResponse decodes into res the data of the graphql response body as Post does, for the transports other than Client,
+// like the implementations of the Executor interface of generated clients.
+// The graphql errors of a response are returned as a *GqlErrorList, res being left as is, whether its data is null
+// or absent, as for a request failing before its execution. A response having neither data nor errors fails.
+// A leading UTF-8 byte order mark is trimmed, see graphqljson.TrimBOM.
+func UnmarshalResponse(body []byte, res interface{}, options ...graphqljson.Option) error {
+	return unmarshal(graphqljson.TrimBOM(body), res, options...)
+}
+
+func unmarshal(data []byte, res interface{}, options ...graphqljson.Option) error {
 	resp := response{}
 	if err := json.Unmarshal(data, &resp); err != nil {
+		if truncated := graphqljson.CheckTruncated(data); truncated != nil {
+			err = truncated
+		}
+
 		return fmt.Errorf("failed to decode data %s: %w", string(data), err)
 	}
 
@@ -196,7 +363,12 @@ func unmarshal(data []byte, res interface{}) error {
 		return errors
 	}
 
-	if err := graphqljson.UnmarshalData(resp.Data, res); err != nil {
+	// absent rather than null
+	if resp.Data == nil {
+		return fmt.Errorf("failed to decode data %s: response has neither data nor errors", string(data))
+	}
+
+	if err := unmarshalData(resp.Data, res, options...); err != nil {
 		return fmt.Errorf("failed to decode data into response %s: %w", string(data), err)
 	}
 
@@ -2,16 +2,32 @@ package clientv2
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/pleclech/gqlgenc/graphqljson"
+	"github.com/pleclech/gqlgenc/introspection"
 	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
 	"github.com/vektah/gqlparser/v2/ast"
 	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/parser"
 )
 
 const (
@@ -155,7 +171,7 @@ func TestUnmarshal(t *testing.T) {
 		t.Parallel()
 		r := &fakeRes{}
 		err := unmarshal([]byte(withBadDataFormat), r)
-		require.EqualError(t, err, "failed to decode data into response {\"data\": \"notAndObject\"}: : : : : json: cannot unmarshal string into Go value of type clientv2.fakeRes")
+		require.EqualError(t, err, "failed to decode data into response {\"data\": \"notAndObject\"}: : : expected object for struct target clientv2.fakeRes, got string")
 	})
 
 	t.Run("bad data format", func(t *testing.T) {
@@ -166,6 +182,41 @@ func TestUnmarshal(t *testing.T) {
 	})
 }
 
+func TestUnmarshalResponse(t *testing.T) {
+	t.Parallel()
+	wantErr := &GqlErrorList{Errors: gqlerror.List{{Message: "unauthorized"}}}
+
+	for name, body := range map[string]string{
+		"missing data": `{"errors": [{"message": "unauthorized"}]}`,
+		"null data":    `{"errors": [{"message": "unauthorized"}], "data": null}`,
+	} {
+		r := &fakeRes{}
+		err := UnmarshalResponse([]byte(body), r)
+		require.Equal(t, wantErr, err, name)
+		require.Equal(t, &fakeRes{}, r, name)
+
+		// with the partial data, the errors are returned the same
+		r = &fakeRes{}
+		err = parseResponse([]byte(body), http.StatusOK, r)
+		require.Equal(t, &ErrorResponse{GqlErrors: &wantErr.Errors}, err, name)
+		require.NoError(t, unmarshalPartialData([]byte(body), r), name)
+		require.Equal(t, &fakeRes{}, r, name)
+	}
+
+	t.Run("null data without errors", func(t *testing.T) {
+		t.Parallel()
+		r := &fakeRes{}
+		require.NoError(t, UnmarshalResponse([]byte(`{"data": null}`), r))
+		require.Equal(t, &fakeRes{}, r)
+	})
+
+	t.Run("neither data nor errors", func(t *testing.T) {
+		t.Parallel()
+		err := UnmarshalResponse([]byte(`{}`), &fakeRes{})
+		require.EqualError(t, err, "failed to decode data {}: response has neither data nor errors")
+	})
+}
+
 func TestParseResponse(t *testing.T) {
 	t.Parallel()
 	t.Run("single error", func(t *testing.T) {
@@ -280,3 +331,2242 @@ func TestChainInterceptor(t *testing.T) {
 	err = chain(parentContext, req, parentGQLInfo, responseMessage, invoker)
 	require.Equal(t, outputError, err, "chain must return invokers's error")
 }
+
+func TestPostOperationName(t *testing.T) {
+	t.Parallel()
+
+	const document = `query GetUser { user { name } }
+query GetRepository { repository { name } }`
+
+	// the server runs the operation selected by operationName and answers with its root field
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		query, gqlErr := parser.ParseQuery(&ast.Source{Input: req.Query})
+		if gqlErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		operation := query.Operations.ForName(req.OperationName)
+		if operation == nil {
+			_, _ = w.Write([]byte(`{"errors":[{"message":"unknown operation"}]}`))
+
+			return
+		}
+
+		field := operation.SelectionSet[0].(*ast.Field)
+		_, _ = fmt.Fprintf(w, `{"data":{"%s":{"name":"%s"}}}`, field.Name, operation.Name)
+	}))
+	t.Cleanup(server.Close)
+
+	type result struct {
+		User *struct {
+			Name string
+		}
+		Repository *struct {
+			Name string
+		}
+	}
+
+	c := NewClient(server.Client(), server.URL)
+
+	t.Run("first operation", func(t *testing.T) {
+		t.Parallel()
+		var res result
+		err := c.Post(context.Background(), "GetUser", document, &res, nil)
+		require.NoError(t, err)
+		require.NotNil(t, res.User)
+		require.Equal(t, "GetUser", res.User.Name)
+		require.Nil(t, res.Repository)
+	})
+
+	t.Run("second operation", func(t *testing.T) {
+		t.Parallel()
+		var res result
+		err := c.Post(context.Background(), "GetRepository", document, &res, nil)
+		require.NoError(t, err)
+		require.NotNil(t, res.Repository)
+		require.Equal(t, "GetRepository", res.Repository.Name)
+		require.Nil(t, res.User)
+	})
+
+	t.Run("unknown operation", func(t *testing.T) {
+		t.Parallel()
+		var res result
+		err := c.Post(context.Background(), "GetOrganization", document, &res, nil)
+		require.IsType(t, &ErrorResponse{}, err)
+	})
+}
+
+// an ad-hoc query is posted without operation name, as the generated RawExecute does, see the raw fixture of clientgenv2
+func TestPostRawQuery(t *testing.T) {
+	t.Parallel()
+
+	var got Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+		if bytes.Contains(body, []byte("operationName")) {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+		if err := json.Unmarshal(body, &got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"data":{"user":{"id":"1","name":"Ichiro","friends":[{"name":"Jiro"}]}}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	type user struct {
+		ID      string `graphql:"id"`
+		Name    string `graphql:"name"`
+		Friends []struct {
+			Name string `graphql:"name"`
+		} `graphql:"friends"`
+	}
+	var res struct {
+		User *user `graphql:"user"`
+	}
+
+	c := NewClient(server.Client(), server.URL)
+	query := `query ($id: ID!) { user(id: $id) { id name friends { name } } }`
+	err := c.Post(context.Background(), "", query, &res, map[string]interface{}{"id": "1"})
+	require.NoError(t, err)
+	require.Equal(t, query, got.Query)
+	require.Equal(t, map[string]interface{}{"id": "1"}, got.Variables)
+	require.NotNil(t, res.User)
+	require.Equal(t, "1", res.User.ID)
+	require.Equal(t, "Ichiro", res.User.Name)
+	require.Len(t, res.User.Friends, 1)
+	require.Equal(t, "Jiro", res.User.Friends[0].Name)
+}
+
+func TestWithFieldTransform(t *testing.T) {
+	t.Parallel()
+
+	var compressed bytes.Buffer
+	w := gzip.NewWriter(&compressed)
+	_, err := w.Write([]byte(`{"content":"` + strings.Repeat("gqlgenc", 100) + `"}`))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	blob := base64.StdEncoding.EncodeToString(compressed.Bytes())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{
+			"data": {"user": {"name": "gopher", "files": [{"blob": %q}]}},
+			"extensions": {"fieldTransforms": [{"path": ["user", "files", 0, "blob"], "name": "gzip"}]}
+		}`, blob)
+	}))
+	t.Cleanup(server.Close)
+
+	gunzip := func(value []byte) ([]byte, error) {
+		b, err := base64.StdEncoding.DecodeString(string(value))
+		if err != nil {
+			return nil, err
+		}
+		r, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+
+		return ioutil.ReadAll(r)
+	}
+
+	type result struct {
+		User struct {
+			Name  string
+			Files []struct {
+				Blob struct {
+					Content string
+				}
+			}
+		}
+	}
+
+	c := NewClient(server.Client(), server.URL)
+
+	t.Run("registered transform", func(t *testing.T) {
+		t.Parallel()
+		var res result
+		err := c.Post(context.Background(), "GetUser", "query GetUser { user { name files { blob } } }", &res, nil, WithFieldTransform("gzip", gunzip))
+		require.NoError(t, err)
+		require.Equal(t, "gopher", res.User.Name)
+		require.Len(t, res.User.Files, 1)
+		require.Equal(t, strings.Repeat("gqlgenc", 100), res.User.Files[0].Blob.Content)
+	})
+
+	t.Run("unregistered transform", func(t *testing.T) {
+		t.Parallel()
+		var res result
+		err := c.Post(context.Background(), "GetUser", "query GetUser { user { name files { blob } } }", &res, nil, WithFieldTransform("zstd", gunzip))
+		require.EqualError(t, err, `failed to transform response fields: no field transform "gzip" registered for [user files 0 blob]`)
+	})
+}
+
+func TestWithIdempotencyKeys(t *testing.T) {
+	t.Parallel()
+
+	// the server fails the first attempt of each call and records the keys of all the attempts
+	var mu sync.Mutex
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+		if len(keys)%2 == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"rename":{"name":"gopher"}}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	retry := func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		if err := next(ctx, req, gqlInfo, res); err != nil {
+			return next(ctx, req, gqlInfo, res)
+		}
+
+		return nil
+	}
+
+	// the subtests share the recorded keys, they do not run in parallel
+	const mutation = `mutation Rename { rename(name: "gopher") { name } }`
+	var res struct {
+		Rename struct {
+			Name string
+		}
+	}
+
+	t.Run("uuid", func(t *testing.T) {
+		c := NewClient(server.Client(), server.URL, WithIdempotencyKeys(true), retry)
+		keys = nil
+		require.NoError(t, c.Post(context.Background(), "Rename", mutation, &res, nil))
+		require.NoError(t, c.Post(context.Background(), "Rename", mutation, &res, nil))
+		require.Equal(t, "gopher", res.Rename.Name)
+		require.Len(t, keys, 4)
+		require.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, keys[0])
+		require.Equal(t, keys[0], keys[1], "retries of a call share the key")
+		require.Equal(t, keys[2], keys[3], "retries of a call share the key")
+		require.NotEqual(t, keys[0], keys[2], "calls have different keys")
+	})
+
+	t.Run("custom generator", func(t *testing.T) {
+		n := 0
+		generate := func() (string, error) {
+			n++
+
+			return fmt.Sprintf("key-%d", n), nil
+		}
+		c := NewClient(server.Client(), server.URL, WithIdempotencyKeys(true), WithIdempotencyKeyGenerator(generate), retry)
+		keys = nil
+		require.NoError(t, c.Post(context.Background(), "Rename", mutation, &res, nil))
+		require.NoError(t, c.Post(context.Background(), "Rename", mutation, &res, nil))
+		require.Equal(t, []string{"key-1", "key-1", "key-2", "key-2"}, keys)
+	})
+
+	t.Run("query", func(t *testing.T) {
+		c := NewClient(server.Client(), server.URL, WithIdempotencyKeys(true), retry)
+		keys = nil
+		require.NoError(t, c.Post(context.Background(), "GetUser", `query GetUser { user { name } }`, &res, nil))
+		require.Equal(t, []string{"", ""}, keys)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		c := NewClient(server.Client(), server.URL, WithIdempotencyKeys(false), retry)
+		keys = nil
+		require.NoError(t, c.Post(context.Background(), "Rename", mutation, &res, nil))
+		require.Equal(t, []string{"", ""}, keys)
+	})
+}
+
+func TestWithQueryRewriter(t *testing.T) {
+	t.Parallel()
+
+	// the server fails the first attempt of each call and records the queries of all the attempts
+	var (
+		mu      sync.Mutex
+		queries []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		if r.Method == http.MethodPost {
+			var req Request
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+
+				return
+			}
+			query = req.Query
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		queries = append(queries, query)
+		if len(queries)%2 == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"user":{"name":"Gopher"}}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	retry := func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		if err := next(ctx, req, gqlInfo, res); err != nil {
+			return next(ctx, req, gqlInfo, res)
+		}
+
+		return nil
+	}
+	var rewrites []string
+	rewrite := func(operationName, query string) string {
+		rewrites = append(rewrites, operationName)
+
+		return strings.Replace(query, "user(id: $id)", "user(id: $id) @cached(ttl: 60)", 1)
+	}
+	var sent string
+	record := func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		err := next(ctx, req, gqlInfo, res)
+		sent = gqlInfo.Request.Query
+
+		return err
+	}
+
+	const (
+		query     = `query User($id: ID!) { user(id: $id) { name } }`
+		rewritten = `query User($id: ID!) { user(id: $id) @cached(ttl: 60) { name } }`
+	)
+	var res struct {
+		User struct {
+			Name string `graphql:"name"`
+		} `graphql:"user"`
+	}
+	vars := map[string]interface{}{"id": "1"}
+
+	// the subtests share the recorded queries, they do not run in parallel
+	t.Run("post", func(t *testing.T) {
+		c := NewClient(server.Client(), server.URL, record, WithQueryRewriter(rewrite), retry)
+		queries, rewrites = nil, nil
+		require.NoError(t, c.Post(context.Background(), "User", query, &res, vars))
+		require.Equal(t, "Gopher", res.User.Name)
+		require.Equal(t, []string{rewritten, rewritten}, queries)
+		require.Equal(t, []string{"User"}, rewrites, "retries of a call share the rewritten query")
+		require.Equal(t, rewritten, sent)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		c := NewClient(server.Client(), server.URL, WithGetForQueries(true), WithQueryRewriter(rewrite), retry)
+		queries, rewrites = nil, nil
+		require.NoError(t, c.Post(context.Background(), "User", query, &res, vars))
+		require.Equal(t, []string{rewritten, rewritten}, queries)
+	})
+}
+
+func TestCheckSchemaHash(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data": {"__schema": {
+			"queryType": {"name": "Query"},
+			"types": [
+				{"kind": "OBJECT", "name": "Query", "fields": [{"name": "hello", "args": [], "type": {"kind": "SCALAR", "name": "String"}}]},
+				{"kind": "SCALAR", "name": "String"}
+			],
+			"directives": []
+		}}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	schema := gqlparser.MustLoadSchema(&ast.Source{Input: `type Query { hello: String }`})
+	hash := introspection.SchemaHash(schema)
+	c := NewClient(server.Client(), server.URL)
+
+	t.Run("matching", func(t *testing.T) {
+		t.Parallel()
+		require.NoError(t, c.CheckSchemaHash(context.Background(), hash, nil))
+	})
+
+	t.Run("mismatched", func(t *testing.T) {
+		t.Parallel()
+		err := c.CheckSchemaHash(context.Background(), "generated", nil)
+		require.Equal(t, &SchemaDriftError{Generated: "generated", Server: hash}, err)
+	})
+
+	t.Run("mismatched reported", func(t *testing.T) {
+		t.Parallel()
+		var reported []error
+		require.NoError(t, c.CheckSchemaHash(context.Background(), "generated", func(err error) {
+			reported = append(reported, err)
+		}))
+		require.Equal(t, []error{&SchemaDriftError{Generated: "generated", Server: hash}}, reported)
+	})
+}
+
+func TestWithBodySizes(t *testing.T) {
+	t.Parallel()
+
+	// the server records the sizes of the bodies it receives and sends, gzip compressed when accepted
+	var mu sync.Mutex
+	var received, sent []int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		response := []byte(`{"data":{"user":{"name":"` + strings.Repeat("gopher", 50) + `"}}}`)
+		if r.Header.Get("Accept-Encoding") == "gzip" {
+			var compressed bytes.Buffer
+			gz := gzip.NewWriter(&compressed)
+			_, _ = gz.Write(response)
+			_ = gz.Close()
+			response = compressed.Bytes()
+			w.Header().Set("Content-Encoding", "gzip")
+		}
+
+		mu.Lock()
+		received = append(received, int64(len(body)))
+		sent = append(sent, int64(len(response)))
+		mu.Unlock()
+		_, _ = w.Write(response)
+	}))
+	t.Cleanup(server.Close)
+
+	var res struct {
+		User struct {
+			Name string
+		}
+	}
+	var reported []BodySizes
+	c := NewClient(server.Client(), server.URL, WithBodySizes(func(sizes BodySizes) {
+		reported = append(reported, sizes)
+	}))
+	require.NoError(t, c.Post(context.Background(), "GetUser", `query GetUser { user { name } }`, &res, nil))
+	require.NoError(t, c.Post(context.Background(), "GetUserAgain", `query GetUserAgain { user { name } }`, &res, nil, func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		req.Header.Set("Accept-Encoding", "identity")
+
+		return next(ctx, req, gqlInfo, res)
+	}))
+	require.Equal(t, strings.Repeat("gopher", 50), res.User.Name)
+
+	require.Equal(t, []BodySizes{
+		{OperationName: "GetUser", Request: received[0], Response: sent[0]},
+		{OperationName: "GetUserAgain", Request: received[1], Response: sent[1]},
+	}, reported)
+	require.Less(t, sent[0], sent[1], "the first response is compressed")
+}
+
+func TestWithDecodeDurations(t *testing.T) {
+	t.Parallel()
+
+	// the server returns as many users as asked
+	var mu sync.Mutex
+	var sent []int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		users := make([]string, int(req.Variables["n"].(float64)))
+		for i := range users {
+			users[i] = fmt.Sprintf(`{"id":"%d","name":"gopher","friends":[{"name":"gophie"},{"name":"gopherine"}]}`, i)
+		}
+		response := []byte(`{"data":{"users":[` + strings.Join(users, ",") + `]}}`)
+
+		mu.Lock()
+		sent = append(sent, int64(len(response)))
+		mu.Unlock()
+		_, _ = w.Write(response)
+	}))
+	t.Cleanup(server.Close)
+
+	var reported []DecodeDuration
+	c := NewClient(server.Client(), server.URL, WithDecodeDurations(func(d DecodeDuration) {
+		reported = append(reported, d)
+	}))
+	const query = `query GetUsers ($n: Int!) { users(n: $n) { id name friends { name } } }`
+	for _, n := range []int{1, 20000} {
+		var res struct {
+			Users []struct {
+				ID      string
+				Name    string
+				Friends []struct {
+					Name string
+				}
+			}
+		}
+		require.NoError(t, c.Post(context.Background(), "GetUsers", query, &res, map[string]interface{}{"n": n}))
+		require.Len(t, res.Users, n)
+	}
+
+	require.Len(t, reported, 2)
+	for i, d := range reported {
+		require.Equal(t, "GetUsers", d.OperationName)
+		require.Equal(t, sent[i], d.Size)
+		require.True(t, d.Duration > 0)
+	}
+	// 20000 times the users take longer to decode
+	require.True(t, reported[1].Duration > reported[0].Duration, "%v is not longer than %v", reported[1].Duration, reported[0].Duration)
+}
+
+func TestWithJSONComments(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{
+			// mocked response
+			"data": {"something": "some data" /* fixed */}
+		}`))
+	}))
+	t.Cleanup(server.Close)
+	c := NewClient(server.Client(), server.URL)
+
+	res := &fakeRes{}
+	require.NoError(t, c.Post(context.Background(), "", "{ something }", res, nil, WithJSONComments()))
+	require.Equal(t, "some data", res.Something)
+
+	// standard JSON forbids comments
+	err := c.Post(context.Background(), "", "{ something }", &fakeRes{}, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid character '/' looking for beginning of object key string")
+}
+
+func TestIterator(t *testing.T) {
+	t.Parallel()
+
+	// fake server paginating 7 users, the cursor is the index of the last user of the page
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		start := 0
+		if after, ok := req.Variables["after"].(string); ok {
+			if after == "fail" {
+				_, _ = w.Write([]byte(`{"errors":[{"message":"page unavailable"}]}`))
+
+				return
+			}
+			fmt.Sscanf(after, "%d", &start)
+			start++
+		}
+		end := start + int(req.Variables["first"].(float64))
+		if end > 7 {
+			end = 7
+		}
+
+		nodes := make([]string, 0, end-start)
+		for i := start; i < end; i++ {
+			nodes = append(nodes, fmt.Sprintf(`{"name":"user%d"}`, i))
+		}
+		endCursor := fmt.Sprintf("%d", end-1)
+		if cursor, ok := req.Variables["failAfter"].(string); ok && cursor == endCursor {
+			endCursor = "fail"
+		}
+		_, _ = fmt.Fprintf(w, `{"data":{"users":{"nodes":[%s],"pageInfo":{"endCursor":%q,"hasNextPage":%t}}}}`, strings.Join(nodes, ","), endCursor, end < 7)
+	}))
+	t.Cleanup(server.Close)
+
+	type user struct {
+		Name string `graphql:"name"`
+	}
+	var requests int
+	newIterator := func(ctx context.Context, pageSize int, vars map[string]interface{}) *Iterator[*user] {
+		c := NewClient(server.Client(), server.URL)
+
+		return NewIterator(ctx, pageSize, func(ctx context.Context, first int, after *string) ([]*user, PageInfo, error) {
+			requests++
+			var res struct {
+				Users struct {
+					Nodes    []*user `graphql:"nodes"`
+					PageInfo struct {
+						EndCursor   *string `graphql:"endCursor"`
+						HasNextPage bool    `graphql:"hasNextPage"`
+					} `graphql:"pageInfo"`
+				} `graphql:"users"`
+			}
+			pageVars := map[string]interface{}{"first": first, "after": after}
+			for k, v := range vars {
+				pageVars[k] = v
+			}
+			if err := c.Post(ctx, "ListUsers", `query ListUsers ($first: Int!, $after: String) { users(first: $first, after: $after) { nodes { name } pageInfo { endCursor hasNextPage } } }`, &res, pageVars); err != nil {
+				return nil, PageInfo{}, err
+			}
+
+			return res.Users.Nodes, PageInfo{EndCursor: res.Users.PageInfo.EndCursor, HasNextPage: res.Users.PageInfo.HasNextPage}, nil
+		})
+	}
+
+	t.Run("all pages", func(t *testing.T) {
+		requests = 0
+		it := newIterator(context.Background(), 3, nil)
+		var names []string
+		for it.Next() {
+			names = append(names, it.Node().Name)
+		}
+		require.NoError(t, it.Err())
+		require.Equal(t, []string{"user0", "user1", "user2", "user3", "user4", "user5", "user6"}, names)
+		require.Equal(t, 3, requests)
+		require.False(t, it.Next())
+		require.Nil(t, it.Node())
+	})
+
+	t.Run("page error", func(t *testing.T) {
+		requests = 0
+		it := newIterator(context.Background(), 2, map[string]interface{}{"failAfter": "3"})
+		var names []string
+		for it.Next() {
+			names = append(names, it.Node().Name)
+		}
+		require.Equal(t, []string{"user0", "user1", "user2", "user3"}, names)
+		require.Error(t, it.Err())
+		require.Contains(t, it.Err().Error(), "fetch page 3 failed")
+		require.Contains(t, it.Err().Error(), "page unavailable")
+		require.Equal(t, 3, requests)
+	})
+
+	t.Run("context canceled", func(t *testing.T) {
+		requests = 0
+		ctx, cancel := context.WithCancel(context.Background())
+		it := newIterator(ctx, 3, nil)
+		require.True(t, it.Next())
+		cancel()
+		require.False(t, it.Next())
+		require.True(t, errors.Is(it.Err(), context.Canceled))
+		require.Equal(t, 1, requests)
+	})
+
+	t.Run("cursor not moving", func(t *testing.T) {
+		cursor := "0"
+		it := NewIterator(context.Background(), 0, func(ctx context.Context, first int, after *string) ([]string, PageInfo, error) {
+			require.Equal(t, DefaultPageSize, first)
+
+			return []string{"node"}, PageInfo{EndCursor: &cursor, HasNextPage: true}, nil
+		})
+		require.True(t, it.Next())
+		require.False(t, it.Next())
+		require.EqualError(t, it.Err(), "fetch page 2 failed: end cursor did not move")
+	})
+}
+
+func TestWithTracingCollector(t *testing.T) {
+	t.Parallel()
+
+	// sample of https://github.com/apollographql/apollo-tracing
+	const tracing = `{
+		"version": 1,
+		"startTime": "2017-07-28T14:20:32.106Z",
+		"endTime": "2017-07-28T14:20:32.109Z",
+		"duration": 2694443,
+		"parsing": {"startOffset": 34953, "duration": 351736},
+		"validation": {"startOffset": 412349, "duration": 670107},
+		"execution": {
+			"resolvers": [
+				{"path": ["hero"], "parentType": "Query", "fieldName": "hero", "returnType": "Character", "startOffset": 1172456, "duration": 215657},
+				{"path": ["hero", "friends", 1, "name"], "parentType": "Droid", "fieldName": "name", "returnType": "String!", "startOffset": 1903307, "duration": 73098}
+			]
+		}
+	}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		if req.OperationName == "Untraced" {
+			_, _ = w.Write([]byte(`{"data":{"hero":{"name":"R2-D2"}}}`))
+
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"hero":{"name":"R2-D2"}},"extensions":{"tracing":` + tracing + `}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	var collected []ApolloTracing
+	c := NewClient(server.Client(), server.URL, WithTracingCollector(func(t ApolloTracing) {
+		collected = append(collected, t)
+	}))
+
+	var res struct {
+		Hero struct {
+			Name string `graphql:"name"`
+		} `graphql:"hero"`
+	}
+	require.NoError(t, c.Post(context.Background(), "Traced", `query Traced { hero { name } }`, &res, nil))
+	require.NoError(t, c.Post(context.Background(), "Untraced", `query Untraced { hero { name } }`, &res, nil))
+	require.Equal(t, "R2-D2", res.Hero.Name)
+
+	require.Len(t, collected, 1)
+	got := collected[0]
+	require.Equal(t, "Traced", got.OperationName)
+	require.Equal(t, 1, got.Version)
+	require.Equal(t, time.Date(2017, 7, 28, 14, 20, 32, 106000000, time.UTC), got.StartTime)
+	require.Equal(t, time.Date(2017, 7, 28, 14, 20, 32, 109000000, time.UTC), got.EndTime)
+	require.Equal(t, 2694443*time.Nanosecond, got.Duration)
+	require.Equal(t, ApolloTracingPhase{StartOffset: 34953, Duration: 351736}, got.Parsing)
+	require.Equal(t, ApolloTracingPhase{StartOffset: 412349, Duration: 670107}, got.Validation)
+	require.Len(t, got.Execution.Resolvers, 2)
+	require.Equal(t, &ApolloTracingResolver{
+		Path:        []interface{}{"hero", "friends", float64(1), "name"},
+		ParentType:  "Droid",
+		FieldName:   "name",
+		ReturnType:  "String!",
+		StartOffset: 1903307,
+		Duration:    73098,
+	}, got.Execution.Resolvers[1])
+	require.Equal(t, "hero.friends.1.name", got.Execution.Resolvers[1].PathString())
+	require.Equal(t, "hero", got.Execution.Resolvers[0].PathString())
+}
+
+func TestTruncatedResponse(t *testing.T) {
+	t.Parallel()
+
+	const body = `{"data":{"user":{"name":"Gopher","friends":[{"name":"Gophie"}]}}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		switch req.OperationName {
+		case "DroppedConnection":
+			// the connection is closed before the announced length is sent
+			w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+			_, _ = w.Write([]byte(body[:50]))
+		case "TruncatedBody":
+			_, _ = w.Write([]byte(body[:30]))
+		default:
+			_, _ = w.Write([]byte(body))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	c := NewClient(server.Client(), server.URL)
+	var res struct {
+		User struct {
+			Name    string `graphql:"name"`
+			Friends []struct {
+				Name string `graphql:"name"`
+			} `graphql:"friends"`
+		} `graphql:"user"`
+	}
+
+	for _, tt := range []struct {
+		operationName string
+		want          graphqljson.TruncatedResponseError
+	}{
+		{"DroppedConnection", graphqljson.TruncatedResponseError{Read: 45, Path: "data.user.friends[0]"}},
+		{"TruncatedBody", graphqljson.TruncatedResponseError{Read: 23, Path: "data.user.name"}},
+	} {
+		err := c.Post(context.Background(), tt.operationName, `query User { user { name friends { name } } }`, &res, nil)
+		require.True(t, errors.Is(err, graphqljson.ErrTruncatedResponse), tt.operationName)
+		var truncated *graphqljson.TruncatedResponseError
+		require.True(t, errors.As(err, &truncated), tt.operationName)
+		require.Equal(t, tt.want, *truncated, tt.operationName)
+	}
+
+	// a schema mismatch is not a truncation
+	var mismatch struct {
+		User struct {
+			Name int `graphql:"name"`
+		} `graphql:"user"`
+	}
+	err := c.Post(context.Background(), "User", `query User { user { name } }`, &mismatch, nil)
+	require.Error(t, err)
+	require.False(t, errors.Is(err, graphqljson.ErrTruncatedResponse))
+}
+
+func TestWithCloseConnection(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu          sync.Mutex
+		connections int
+		closed      []bool
+	)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		closed = append(closed, r.Close && r.Header.Get("Connection") == "close")
+		mu.Unlock()
+
+		_, _ = w.Write([]byte(`{"data":{"user":{"name":"Gopher"}}}`))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			connections++
+			mu.Unlock()
+		}
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+
+	c := NewClient(server.Client(), server.URL)
+	post := func(ctx context.Context) {
+		t.Helper()
+		var res struct {
+			User struct {
+				Name string `graphql:"name"`
+			} `graphql:"user"`
+		}
+		require.NoError(t, c.Post(ctx, "User", `query User { user { name } }`, &res, nil))
+		require.Equal(t, "Gopher", res.User.Name)
+	}
+	countConnections := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return connections
+	}
+
+	// kept alive
+	post(context.Background())
+	post(context.Background())
+	require.Equal(t, 1, countConnections())
+
+	// each request closes its connection, the first one reusing the idle one, and the next request opens a new one
+	closeCtx := WithCloseConnection(context.Background())
+	post(closeCtx)
+	post(closeCtx)
+	require.Equal(t, 2, countConnections())
+	post(context.Background())
+	require.Equal(t, 3, countConnections())
+	post(context.Background())
+	require.Equal(t, 3, countConnections())
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []bool{false, false, true, true, false, false}, closed)
+}
+
+func TestWithPartialData(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{
+			"data": {"user": {"name": "Gopher", "friends": [{"name": "Gophie"}, null]}, "viewer": null},
+			"errors": [
+				{"message": "friend not found", "path": ["user", "friends", 1]},
+				{"message": "not logged in", "path": ["viewer"]}
+			]
+		}`))
+	}))
+	t.Cleanup(server.Close)
+
+	type query struct {
+		User struct {
+			Name    string `graphql:"name"`
+			Friends []*struct {
+				Name string `graphql:"name"`
+			} `graphql:"friends"`
+		} `graphql:"user"`
+		Viewer *struct {
+			Name string `graphql:"name"`
+		} `graphql:"viewer"`
+	}
+	c := NewClient(server.Client(), server.URL)
+
+	t.Run("decoded with the errors", func(t *testing.T) {
+		t.Parallel()
+		var res query
+		errs, err := GraphQLErrors(c.Post(context.Background(), "User", `query User { user { name friends { name } } viewer { name } }`, &res, nil, WithPartialData()))
+		require.NoError(t, err)
+		require.Len(t, errs, 2)
+		require.Equal(t, "Gopher", res.User.Name)
+		require.Len(t, res.User.Friends, 2)
+		require.Equal(t, "Gophie", res.User.Friends[0].Name)
+		require.Nil(t, res.User.Friends[1])
+		require.Nil(t, res.Viewer)
+
+		require.Equal(t, gqlerror.List{errs[0]}, ErrorsAt(errs, "user"))
+		require.Equal(t, gqlerror.List{errs[0]}, ErrorsAt(errs, "user", "friends", 1))
+		require.Empty(t, ErrorsAt(errs, "user", "friends", 0))
+		require.Equal(t, gqlerror.List{errs[1]}, ErrorsAt(errs, "viewer"))
+		require.Equal(t, errs, ErrorsAt(errs))
+	})
+
+	t.Run("not decoded by default", func(t *testing.T) {
+		t.Parallel()
+		var res query
+		err := c.Post(context.Background(), "User", `query User { user { name friends { name } } viewer { name } }`, &res, nil)
+		errs, err := GraphQLErrors(err)
+		require.NoError(t, err)
+		require.Len(t, errs, 2)
+		require.Empty(t, res.User.Name)
+	})
+
+	t.Run("other errors", func(t *testing.T) {
+		t.Parallel()
+		want := errors.New("boom")
+		errs, err := GraphQLErrors(want)
+		require.Nil(t, errs)
+		require.Equal(t, want, err)
+
+		errs, err = GraphQLErrors(nil)
+		require.Nil(t, errs)
+		require.NoError(t, err)
+	})
+}
+
+func TestWithErrorPolicy(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("down") != "" {
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte(`{"errors": [{"message": "upstream down"}]}`))
+
+			return
+		}
+		_, _ = w.Write([]byte(`{
+			"data": {"user": {"name": "Gopher"}, "viewer": null},
+			"errors": [{"message": "not logged in", "path": ["viewer"]}]
+		}`))
+	}))
+	t.Cleanup(server.Close)
+
+	type query struct {
+		User struct {
+			Name string `graphql:"name"`
+		} `graphql:"user"`
+		Viewer *struct {
+			Name string `graphql:"name"`
+		} `graphql:"viewer"`
+	}
+	const q = `query User { user { name } viewer { name } }`
+
+	for _, tt := range []struct {
+		name     string
+		policy   ErrorPolicy
+		wantName string
+		wantErrs int
+	}{
+		{name: "none", policy: ErrorPolicyNone, wantErrs: 1},
+		{name: "all", policy: ErrorPolicyAll, wantName: "Gopher", wantErrs: 1},
+		{name: "ignore", policy: ErrorPolicyIgnore, wantName: "Gopher"},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := NewClient(server.Client(), server.URL, WithErrorPolicy(tt.policy))
+			var res query
+			err := c.Post(context.Background(), "User", q, &res, nil)
+			if tt.wantErrs == 0 {
+				require.NoError(t, err)
+			}
+			errs, err := GraphQLErrors(err)
+			require.NoError(t, err)
+			require.Len(t, errs, tt.wantErrs)
+			require.Equal(t, tt.wantName, res.User.Name)
+			require.Nil(t, res.Viewer)
+		})
+	}
+
+	t.Run("ignore keeps the http errors", func(t *testing.T) {
+		t.Parallel()
+		c := NewClient(server.Client(), server.URL+"?down=1", WithErrorPolicy(ErrorPolicyIgnore))
+		var res query
+		err := c.Post(context.Background(), "User", q, &res, nil)
+		var errResponse *ErrorResponse
+		require.True(t, errors.As(err, &errResponse))
+		require.Equal(t, http.StatusBadGateway, errResponse.NetworkError.Code)
+	})
+}
+
+func TestWithGetForQueries(t *testing.T) {
+	t.Parallel()
+
+	type received struct {
+		method      string
+		contentType string
+		req         Request
+	}
+	var (
+		mu   sync.Mutex
+		reqs []received
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := received{method: r.Method, contentType: r.Header.Get("Content-Type")}
+		switch r.Method {
+		case http.MethodGet:
+			params := r.URL.Query()
+			got.req.Query = params.Get("query")
+			got.req.OperationName = params.Get("operationName")
+			if variables := params.Get("variables"); variables != "" {
+				if err := json.Unmarshal([]byte(variables), &got.req.Variables); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+
+					return
+				}
+			}
+		default:
+			if err := json.NewDecoder(r.Body).Decode(&got.req); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+
+				return
+			}
+		}
+		mu.Lock()
+		reqs = append(reqs, got)
+		mu.Unlock()
+
+		_, _ = w.Write([]byte(`{"data":{"user":{"name":"Gopher"}}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	const (
+		query    = `query User($id: ID!) { user(id: $id) { name } }`
+		mutation = `mutation Rename($id: ID!) { user: rename(id: $id, name: "Gopher") { name } }`
+	)
+	var res struct {
+		User struct {
+			Name string `graphql:"name"`
+		} `graphql:"user"`
+	}
+	vars := map[string]interface{}{"id": "1 & 2"}
+
+	c := NewClient(server.Client(), server.URL, WithGetForQueries(true))
+	require.NoError(t, c.Post(context.Background(), "User", query, &res, vars))
+	require.NoError(t, c.Post(context.Background(), "Rename", mutation, &res, vars))
+	// too long for a GET
+	require.NoError(t, c.Post(context.Background(), "User", query, &res, vars, WithMaxGetURLLength(len(server.URL)+10)))
+	require.NoError(t, c.Post(context.Background(), "User", query, &res, vars, WithGetForQueries(false)))
+	require.Equal(t, "Gopher", res.User.Name)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []received{
+		{method: http.MethodGet, req: Request{Query: query, Variables: vars, OperationName: "User"}},
+		{method: http.MethodPost, contentType: "application/json; charset=utf-8", req: Request{Query: mutation, Variables: vars, OperationName: "Rename"}},
+		{method: http.MethodPost, contentType: "application/json; charset=utf-8", req: Request{Query: query, Variables: vars, OperationName: "User"}},
+		{method: http.MethodPost, contentType: "application/json; charset=utf-8", req: Request{Query: query, Variables: vars, OperationName: "User"}},
+	}, reqs)
+}
+
+func TestWithDocumentMode(t *testing.T) {
+	t.Parallel()
+
+	// the server records the bodies of the POST requests and the parameters of the GET ones
+	var (
+		mu     sync.Mutex
+		bodies []map[string]interface{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := make(map[string]interface{})
+		if r.Method == http.MethodGet {
+			for key := range r.URL.Query() {
+				body[key] = r.URL.Query().Get(key)
+			}
+		} else if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+		mu.Lock()
+		bodies = append(bodies, body)
+		mu.Unlock()
+
+		_, _ = w.Write([]byte(`{"data":{"user":{"name":"Gopher"}}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	const query = `query User($id: ID!) { user(id: $id) { name } }`
+	var res struct {
+		User struct {
+			Name string `graphql:"name"`
+		} `graphql:"user"`
+	}
+	vars := map[string]interface{}{"id": "1"}
+	operation := Operation{Name: "User", Type: "query", QueryHash: "abc", DocumentID: "sha256:abc"}
+	ctx := ContextWithOperation(context.Background(), operation)
+	var sent string
+	record := func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		err := next(ctx, req, gqlInfo, res)
+		sent = gqlInfo.Request.Query
+
+		return err
+	}
+	post := func(t *testing.T, ctx context.Context, interceptors ...RequestInterceptor) map[string]interface{} {
+		t.Helper()
+		mu.Lock()
+		bodies = nil
+		mu.Unlock()
+		c := NewClient(server.Client(), server.URL, record)
+		require.NoError(t, c.Post(ctx, "User", query, &res, vars, interceptors...))
+		require.Equal(t, "Gopher", res.User.Name)
+		require.Equal(t, query, sent, "the interceptors see the text of the document")
+		mu.Lock()
+		defer mu.Unlock()
+		require.Len(t, bodies, 1)
+
+		return bodies[0]
+	}
+
+	// the subtests share the recorded bodies, they do not run in parallel
+	t.Run("full document", func(t *testing.T) {
+		require.Equal(t, map[string]interface{}{
+			"query":         query,
+			"operationName": "User",
+			"variables":     map[string]interface{}{"id": "1"},
+		}, post(t, ctx))
+	})
+
+	t.Run("persisted hash", func(t *testing.T) {
+		require.Equal(t, map[string]interface{}{
+			"operationName": "User",
+			"variables":     map[string]interface{}{"id": "1"},
+			"extensions": map[string]interface{}{
+				"persistedQuery": map[string]interface{}{"version": float64(1), "sha256Hash": "abc"},
+			},
+		}, post(t, ctx, WithDocumentMode(PersistedHash)))
+	})
+
+	t.Run("registered id", func(t *testing.T) {
+		require.Equal(t, map[string]interface{}{
+			"documentId":    "sha256:abc",
+			"operationName": "User",
+			"variables":     map[string]interface{}{"id": "1"},
+		}, post(t, ctx, WithDocumentMode(RegisteredID)))
+	})
+
+	t.Run("get", func(t *testing.T) {
+		require.Equal(t, map[string]interface{}{
+			"documentId":    "sha256:abc",
+			"operationName": "User",
+			"variables":     `{"id":"1"}`,
+		}, post(t, ctx, WithDocumentMode(RegisteredID), WithGetForQueries(true)))
+	})
+
+	t.Run("without operation", func(t *testing.T) {
+		require.Equal(t, query, post(t, context.Background(), WithDocumentMode(RegisteredID))["query"])
+	})
+
+	t.Run("not registered", func(t *testing.T) {
+		c := NewClient(server.Client(), server.URL)
+		ctx := ContextWithOperation(context.Background(), Operation{Name: "User", Type: "query"})
+		err := c.Post(ctx, "User", query, &res, vars, WithDocumentMode(RegisteredID))
+		require.EqualError(t, err, "no registered document id for the operation User")
+	})
+}
+
+func TestMarshaler(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu     sync.Mutex
+		bodies []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+
+		_, _ = w.Write([]byte(`{"data":{"user":{"name":"Gopher"}}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	var marshaled []interface{}
+	c := NewClient(server.Client(), server.URL)
+	// an indenting marshaler, telling its bodies apart from the ones of encoding/json
+	c.Marshaler = MarshalerFunc(func(v interface{}) ([]byte, error) {
+		marshaled = append(marshaled, v)
+
+		return json.MarshalIndent(v, "", "\t")
+	})
+
+	var res struct {
+		User struct {
+			Name string `graphql:"name"`
+		} `graphql:"user"`
+	}
+	const query = `query User($id: ID!) { user(id: $id) { name } }`
+	vars := map[string]interface{}{"id": "1"}
+	require.NoError(t, c.Post(context.Background(), "User", query, &res, vars))
+	require.Equal(t, "Gopher", res.User.Name)
+
+	require.Equal(t, []interface{}{&Request{Query: query, Variables: vars, OperationName: "User"}}, marshaled)
+	mu.Lock()
+	require.Equal(t, []string{"{\n\t\"query\": \"query User($id: ID!) { user(id: $id) { name } }\",\n\t\"variables\": {\n\t\t\"id\": \"1\"\n\t},\n\t\"operationName\": \"User\"\n}"}, bodies)
+	mu.Unlock()
+
+	c.Marshaler = MarshalerFunc(func(interface{}) ([]byte, error) {
+		return nil, errors.New("boom")
+	})
+	require.EqualError(t, c.Post(context.Background(), "User", query, &res, vars), "encode: boom")
+}
+
+func TestList(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		value interface{}
+		want  string
+	}{
+		{List("1"), `["1"]`},
+		{List(1, 2), `[1,2]`},
+		{List[string](), `[]`},
+	} {
+		got, err := json.Marshal(tt.value)
+		require.NoError(t, err)
+		require.JSONEq(t, tt.want, string(got))
+	}
+
+	var got Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"users":[]}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	var res struct {
+		Users []struct {
+			ID string `graphql:"id"`
+		} `graphql:"users"`
+	}
+	c := NewClient(server.Client(), server.URL)
+	require.NoError(t, c.Post(context.Background(), "Users", `query Users($ids: [ID!]!) { users(ids: $ids) { id } }`, &res, map[string]interface{}{"ids": List("1")}))
+	require.Equal(t, []interface{}{"1"}, got.Variables["ids"])
+}
+
+func TestGroupBy(t *testing.T) {
+	t.Parallel()
+
+	type event struct {
+		Day  string `graphql:"day"`
+		Name string `graphql:"name"`
+	}
+	var res struct {
+		Events []event `graphql:"events"`
+	}
+	require.NoError(t, UnmarshalResponse([]byte(`{"data": {"events": [
+		{"day": "tue", "name": "a"},
+		{"day": "mon", "name": "b"},
+		{"day": "tue", "name": "c"},
+		{"day": "wed", "name": "d"},
+		{"day": "mon", "name": "e"}
+	]}}`), &res))
+
+	// the days in the order they are first seen, the events of a day in the order of the list
+	groups := GroupBy(res.Events, func(e event) string { return e.Day })
+	require.Equal(t, []Group[string, event]{
+		{Key: "tue", Values: []event{{"tue", "a"}, {"tue", "c"}}},
+		{Key: "mon", Values: []event{{"mon", "b"}, {"mon", "e"}}},
+		{Key: "wed", Values: []event{{"wed", "d"}}},
+	}, groups)
+
+	require.Empty(t, GroupBy(nil, func(e event) string { return e.Day }))
+}
+
+func TestClone(t *testing.T) {
+	t.Parallel()
+
+	type node struct {
+		Name     *string
+		Children []*node
+		Parent   *node
+		Extra    map[string]interface{}
+		Any      interface{}
+		Pair     [2]*string
+		At       time.Time
+	}
+	name := "root"
+	at := time.Now()
+	root := &node{
+		Name:  &name,
+		Extra: map[string]interface{}{"list": []interface{}{"a"}, "object": map[string]interface{}{"b": 1}},
+		Any:   []int{1},
+		Pair:  [2]*string{&name, nil},
+		At:    at,
+	}
+	root.Children = []*node{{Name: &name, Parent: root}}
+
+	clone := Clone(root)
+	require.Equal(t, root.Extra, clone.Extra)
+	require.True(t, clone.At.Equal(at))
+	// the values pointed several times are copied once, the cycles are kept
+	require.Same(t, clone.Name, clone.Children[0].Name)
+	require.Same(t, clone.Name, clone.Pair[0])
+	require.Same(t, clone, clone.Children[0].Parent)
+
+	*clone.Name = "edited"
+	clone.Children[0].Children = []*node{{}}
+	clone.Extra["list"].([]interface{})[0] = "edited"
+	clone.Extra["object"].(map[string]interface{})["b"] = 2
+	clone.Any.([]int)[0] = 2
+	require.Equal(t, "root", name)
+	require.Empty(t, root.Children[0].Children)
+	require.Equal(t, map[string]interface{}{"list": []interface{}{"a"}, "object": map[string]interface{}{"b": 1}}, root.Extra)
+	require.Equal(t, []int{1}, root.Any)
+
+	require.Nil(t, Clone((*node)(nil)))
+	require.Equal(t, node{}, Clone(node{}))
+}
+
+func TestWithMaxResponseBytes(t *testing.T) {
+	t.Parallel()
+
+	// the responses are gzip compressed when accepted, far smaller than their decompressed size
+	name := strings.Repeat("gopher", 100)
+	response := []byte(`{"data":{"user":{"name":"` + name + `"}}}`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := response
+		if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			var compressed bytes.Buffer
+			gz := gzip.NewWriter(&compressed)
+			_, _ = gz.Write(response)
+			_ = gz.Close()
+			body = compressed.Bytes()
+			w.Header().Set("Content-Encoding", "gzip")
+		}
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+
+	c := NewClient(server.Client(), server.URL)
+	for _, tt := range []struct {
+		name         string
+		interceptors []RequestInterceptor
+	}{
+		{"decompressed by the transport", nil},
+		{"decompressed for the body sizes", []RequestInterceptor{WithBodySizes(func(sizes BodySizes) {})}},
+		{"not compressed", []RequestInterceptor{func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+			req.Header.Set("Accept-Encoding", "identity")
+
+			return next(ctx, req, gqlInfo, res)
+		}}},
+	} {
+		var res struct {
+			User struct {
+				Name string `graphql:"name"`
+			} `graphql:"user"`
+		}
+		under := append([]RequestInterceptor{WithMaxResponseBytes(int64(len(response)))}, tt.interceptors...)
+		require.NoError(t, c.Post(context.Background(), "User", `query User { user { name } }`, &res, nil, under...), tt.name)
+		require.Equal(t, name, res.User.Name, tt.name)
+
+		over := append([]RequestInterceptor{WithMaxResponseBytes(int64(len(response) - 1))}, tt.interceptors...)
+		err := c.Post(context.Background(), "User", `query User { user { name } }`, &res, nil, over...)
+		require.True(t, errors.Is(err, ErrResponseTooLarge), "%s: %v", tt.name, err)
+		require.EqualError(t, err, fmt.Sprintf("failed to read response body: response too large: more than %d bytes", len(response)-1), tt.name)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	t.Parallel()
+
+	type event struct {
+		UserCreated struct {
+			Name string `graphql:"name"`
+		} `graphql:"userCreated"`
+	}
+	const query = `subscription OnUserCreated { userCreated { name } }`
+
+	// the server streams the events written by the handler of each test
+	newClient := func(t *testing.T, write func(w http.ResponseWriter, r *http.Request, flush func())) *Client {
+		t.Helper()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+			var req Request
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			require.Equal(t, "OnUserCreated", req.OperationName)
+
+			w.Header().Set("Content-Type", "text/event-stream")
+			write(w, r, w.(http.Flusher).Flush)
+		}))
+		t.Cleanup(server.Close)
+
+		return NewClient(server.Client(), server.URL)
+	}
+
+	t.Run("events", func(t *testing.T) {
+		t.Parallel()
+		c := newClient(t, func(w http.ResponseWriter, r *http.Request, flush func()) {
+			_, _ = io.WriteString(w, ": keep alive\n\nevent: next\ndata: {\"data\": {\"userCreated\": {\"name\": \"Gopher\"}}}\n\n")
+			flush()
+			_, _ = io.WriteString(w, "event: next\r\ndata: {\"data\": null,\r\ndata: \"errors\": [{\"message\": \"forbidden\"}]}\r\n\r\n")
+			_, _ = io.WriteString(w, "event: ping\ndata: {}\n\ndata: {\"data\": {\"userCreated\": {\"name\": \"Gophie\"}}}\n\nevent: complete\n\n")
+		})
+
+		sub, err := c.Subscribe(context.Background(), "OnUserCreated", query, nil)
+		require.NoError(t, err)
+		defer sub.Close()
+
+		var res event
+		require.NoError(t, sub.Next(&res))
+		require.Equal(t, "Gopher", res.UserCreated.Name)
+
+		errs, err := GraphQLErrors(sub.Next(&res))
+		require.NoError(t, err)
+		require.Equal(t, "forbidden", errs[0].Message)
+
+		require.NoError(t, sub.Next(&res))
+		require.Equal(t, "Gophie", res.UserCreated.Name)
+
+		require.Equal(t, io.EOF, sub.Next(&res))
+	})
+
+	t.Run("dropped connection", func(t *testing.T) {
+		t.Parallel()
+		c := newClient(t, func(w http.ResponseWriter, r *http.Request, flush func()) {
+			_, _ = io.WriteString(w, "event: next\ndata: {\"data\": {\"userCreated\": {\"name\": \"Gopher\"}}}\n\nevent: next\ndata: {\"da")
+		})
+
+		sub, err := c.Subscribe(context.Background(), "OnUserCreated", query, nil)
+		require.NoError(t, err)
+		defer sub.Close()
+
+		var res event
+		require.NoError(t, sub.Next(&res))
+		err = sub.Next(&res)
+		require.True(t, errors.Is(err, io.ErrUnexpectedEOF), err)
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		t.Parallel()
+		c := newClient(t, func(w http.ResponseWriter, r *http.Request, flush func()) {
+			_, _ = io.WriteString(w, "event: next\ndata: {\"data\": {\"userCreated\": {\"name\": \"Gopher\"}}}\n\n")
+			flush()
+			// no event until the client cancels the subscription
+			<-r.Context().Done()
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		sub, err := c.Subscribe(ctx, "OnUserCreated", query, nil)
+		require.NoError(t, err)
+		defer sub.Close()
+
+		var res event
+		require.NoError(t, sub.Next(&res))
+		cancel()
+		require.Equal(t, context.Canceled, sub.Next(&res))
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		t.Parallel()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"errors": [{"message": "unknown field"}]}`))
+		}))
+		t.Cleanup(server.Close)
+
+		_, err := NewClient(server.Client(), server.URL).Subscribe(context.Background(), "OnUserCreated", query, nil)
+		errs, err := GraphQLErrors(err)
+		require.NoError(t, err)
+		require.Equal(t, "unknown field", errs[0].Message)
+	})
+}
+
+func TestWithWebSocket(t *testing.T) {
+	t.Parallel()
+
+	type event struct {
+		UserCreated struct {
+			Name string `graphql:"name"`
+		} `graphql:"userCreated"`
+	}
+	const query = `subscription OnUserCreated { userCreated { name } }`
+
+	// the server upgrades to the first subprotocol offered it supports and runs handle over the connection
+	newClient := func(t *testing.T, supported []string, handle func(ws *webSocket, r *http.Request)) *Client {
+		t.Helper()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "websocket", r.Header.Get("Upgrade"))
+			var protocol string
+			for _, offered := range strings.Split(r.Header.Get("Sec-WebSocket-Protocol"), ",") {
+				for _, name := range supported {
+					if protocol == "" && strings.TrimSpace(offered) == name {
+						protocol = name
+					}
+				}
+			}
+
+			conn, rw, err := w.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			defer conn.Close()
+			_, _ = fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\nSec-WebSocket-Protocol: %s\r\n\r\n",
+				webSocketAccept(r.Header.Get("Sec-WebSocket-Key")), protocol)
+			require.NoError(t, rw.Flush())
+
+			handle(&webSocket{conn: conn, reader: rw.Reader, protocol: protocol}, r)
+		}))
+		t.Cleanup(server.Close)
+
+		return NewClient(server.Client(), server.URL)
+	}
+	// read reads the next message sent by the client, masked
+	read := func(t *testing.T, ws *webSocket, typ string) *webSocketMessage {
+		t.Helper()
+		message, err := ws.readMessage()
+		require.NoError(t, err)
+		require.Equal(t, typ, message.Type)
+
+		return message
+	}
+	write := func(ws *webSocket, messages ...string) {
+		for _, message := range messages {
+			_ = ws.writeFrame(opText, []byte(message))
+		}
+	}
+
+	t.Run(GraphQLTransportWS, func(t *testing.T) {
+		t.Parallel()
+		c := newClient(t, []string{GraphQLTransportWS, GraphQLWS}, func(ws *webSocket, r *http.Request) {
+			require.Equal(t, "Bearer header", r.Header.Get("Authorization"))
+			require.Equal(t, "graphql-transport-ws, graphql-ws", r.Header.Get("Sec-WebSocket-Protocol"))
+
+			// the auth payload round trips to the server
+			init := read(t, ws, "connection_init")
+			require.JSONEq(t, `{"authToken": "secret"}`, string(init.Payload))
+			write(ws, `{"type": "ping"}`, `{"type": "connection_ack"}`)
+			read(t, ws, "pong")
+
+			subscribe := read(t, ws, "subscribe")
+			require.Equal(t, "1", subscribe.ID)
+			var req Request
+			require.NoError(t, json.Unmarshal(subscribe.Payload, &req))
+			require.Equal(t, "OnUserCreated", req.OperationName)
+
+			write(ws,
+				`{"id": "1", "type": "next", "payload": {"data": {"userCreated": {"name": "Gopher"}}}}`,
+				`{"type": "ping"}`,
+				`{"id": "1", "type": "next", "payload": {"data": null, "errors": [{"message": "forbidden"}]}}`,
+			)
+			// a message in a text frame and its continuation, around a ping frame
+			first, second := `{"id": "1", "type": "next", `, `"payload": {"data": {"userCreated": {"name": "Gophie"}}}}`
+			_, _ = ws.conn.Write(append([]byte{opText, byte(len(first))}, first...))
+			_, _ = ws.conn.Write([]byte{0x80 | opPing, 0})
+			_, _ = ws.conn.Write(append([]byte{0x80, byte(len(second))}, second...))
+			write(ws, `{"id": "1", "type": "error", "payload": [{"message": "deleted"}]}`)
+			read(t, ws, "pong")
+		})
+
+		sub, err := c.Subscribe(context.Background(), "OnUserCreated", query, nil,
+			func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+				req.Header.Set("Authorization", "Bearer header")
+
+				return next(ctx, req, gqlInfo, res)
+			},
+			WithWebSocket(map[string]interface{}{"authToken": "secret"}),
+		)
+		require.NoError(t, err)
+		defer sub.Close()
+
+		var res event
+		require.NoError(t, sub.Next(&res))
+		require.Equal(t, "Gopher", res.UserCreated.Name)
+
+		errs, err := GraphQLErrors(sub.Next(&res))
+		require.NoError(t, err)
+		require.Equal(t, "forbidden", errs[0].Message)
+
+		require.NoError(t, sub.Next(&res))
+		require.Equal(t, "Gophie", res.UserCreated.Name)
+
+		// the error of the operation ends the subscription
+		errs, err = GraphQLErrors(sub.Next(&res))
+		require.NoError(t, err)
+		require.Equal(t, "deleted", errs[0].Message)
+		require.Equal(t, io.EOF, sub.Next(&res))
+	})
+
+	t.Run(GraphQLWS, func(t *testing.T) {
+		t.Parallel()
+		closed := make(chan []string, 1)
+		c := newClient(t, []string{GraphQLWS}, func(ws *webSocket, r *http.Request) {
+			init := read(t, ws, "connection_init")
+			require.JSONEq(t, `{"token": "secret"}`, string(init.Payload))
+			write(ws, `{"type": "connection_ack"}`, `{"type": "ka"}`)
+
+			start := read(t, ws, "start")
+			require.Equal(t, "1", start.ID)
+			write(ws, `{"id": "1", "type": "data", "payload": {"data": {"userCreated": {"name": "Gopher"}}}}`, `{"type": "ka"}`)
+
+			// the client stops the subscription and terminates the connection when closing it
+			var messages []string
+			for {
+				message, err := ws.readMessage()
+				var closeErr *WebSocketCloseError
+				if errors.As(err, &closeErr) {
+					messages = append(messages, fmt.Sprint(closeErr.Code))
+
+					break
+				}
+				require.NoError(t, err)
+				messages = append(messages, message.ID+message.Type)
+			}
+			closed <- messages
+		})
+
+		sub, err := c.Subscribe(context.Background(), "OnUserCreated", query, nil, WithWebSocket(map[string]interface{}{"token": "secret"}))
+		require.NoError(t, err)
+
+		var res event
+		require.NoError(t, sub.Next(&res))
+		require.Equal(t, "Gopher", res.UserCreated.Name)
+		require.NoError(t, sub.Close())
+		require.Equal(t, []string{"1stop", "connection_terminate", "1000"}, <-closed)
+	})
+
+	t.Run("complete", func(t *testing.T) {
+		t.Parallel()
+		c := newClient(t, []string{GraphQLWS}, func(ws *webSocket, r *http.Request) {
+			read(t, ws, "connection_init")
+			write(ws, `{"type": "connection_ack"}`)
+			read(t, ws, "start")
+			write(ws, `{"id": "1", "type": "complete"}`)
+		})
+
+		sub, err := c.Subscribe(context.Background(), "OnUserCreated", query, nil, WithWebSocket(nil))
+		require.NoError(t, err)
+		defer sub.Close()
+
+		var res event
+		require.Equal(t, io.EOF, sub.Next(&res))
+		require.Equal(t, io.EOF, sub.Next(&res))
+	})
+
+	t.Run("connection_error", func(t *testing.T) {
+		t.Parallel()
+		c := newClient(t, []string{GraphQLWS}, func(ws *webSocket, r *http.Request) {
+			read(t, ws, "connection_init")
+			write(ws, `{"type": "connection_error", "payload": {"message": "invalid token"}}`)
+		})
+
+		_, err := c.Subscribe(context.Background(), "OnUserCreated", query, nil, WithWebSocket(map[string]interface{}{"token": "wrong"}))
+		require.True(t, errors.Is(err, ErrConnectionRejected), err)
+		require.Contains(t, err.Error(), "invalid token")
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		t.Parallel()
+		c := newClient(t, []string{GraphQLTransportWS}, func(ws *webSocket, r *http.Request) {
+			read(t, ws, "connection_init")
+			_ = ws.writeFrame(opClose, append([]byte{0x11, 0x33}, "Forbidden"...))
+		})
+
+		_, err := c.Subscribe(context.Background(), "OnUserCreated", query, nil, WithWebSocket(nil))
+		require.True(t, errors.Is(err, ErrConnectionRejected), err)
+		var closeErr *WebSocketCloseError
+		require.True(t, errors.As(err, &closeErr))
+		require.Equal(t, &WebSocketCloseError{Code: 4403, Reason: "Forbidden"}, closeErr)
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		t.Parallel()
+		c := newClient(t, []string{GraphQLTransportWS}, func(ws *webSocket, r *http.Request) {
+			read(t, ws, "connection_init")
+			write(ws, `{"type": "connection_ack"}`)
+			read(t, ws, "subscribe")
+			// no event until the client closes the connection
+			_, _ = ws.readMessage()
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		sub, err := c.Subscribe(ctx, "OnUserCreated", query, nil, WithWebSocket(nil))
+		require.NoError(t, err)
+
+		cancel()
+		var res event
+		require.Equal(t, context.Canceled, sub.Next(&res))
+		require.NoError(t, sub.Close())
+	})
+
+	t.Run("close", func(t *testing.T) {
+		t.Parallel()
+		c := newClient(t, []string{GraphQLTransportWS}, func(ws *webSocket, r *http.Request) {
+			read(t, ws, "connection_init")
+			write(ws, `{"type": "connection_ack"}`)
+			read(t, ws, "subscribe")
+			// no event until the client completes the subscription
+			read(t, ws, "complete")
+		})
+
+		sub, err := c.Subscribe(context.Background(), "OnUserCreated", query, nil, WithWebSocket(nil))
+		require.NoError(t, err)
+
+		// Close ends the subscription from another goroutine, while Next waits for an event
+		errs := make(chan error, 1)
+		go func() {
+			var res event
+			errs <- sub.Next(&res)
+		}()
+		require.NoError(t, sub.Close())
+		require.Error(t, <-errs)
+	})
+
+	t.Run("message too large", func(t *testing.T) {
+		t.Parallel()
+		c := newClient(t, []string{GraphQLTransportWS}, func(ws *webSocket, r *http.Request) {
+			read(t, ws, "connection_init")
+			write(ws, `{"type": "connection_ack"}`)
+			read(t, ws, "subscribe")
+			// a message of two continued frames, each below the limit of a frame, the message above it
+			// the text frame without its fin bit, then the final continuation frame
+			for _, first := range []byte{opText, 0x80} {
+				frame := binary.BigEndian.AppendUint64([]byte{first, 127}, maxWebSocketFrame/2+1)
+				if _, err := ws.conn.Write(append(frame, make([]byte, maxWebSocketFrame/2+1)...)); err != nil {
+					return
+				}
+			}
+		})
+
+		sub, err := c.Subscribe(context.Background(), "OnUserCreated", query, nil, WithWebSocket(nil))
+		require.NoError(t, err)
+		defer sub.Close()
+
+		var res event
+		err = sub.Next(&res)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), fmt.Sprintf("websocket message of more than %d bytes", maxWebSocketFrame))
+	})
+
+	t.Run("subprotocols", func(t *testing.T) {
+		t.Parallel()
+		c := newClient(t, []string{GraphQLWS}, func(ws *webSocket, r *http.Request) {})
+
+		_, err := c.Subscribe(context.Background(), "OnUserCreated", query, nil, WithWebSocket(nil, GraphQLTransportWS))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `the server selected the subprotocol "", not one of graphql-transport-ws`)
+
+		_, err = c.Subscribe(context.Background(), "OnUserCreated", query, nil, WithWebSocket(nil, "graphql-sse"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `unknown websocket subprotocol "graphql-sse"`)
+	})
+}
+
+func TestWithEnvelopeExtractor(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		switch req.OperationName {
+		case "Failing":
+			_, _ = w.Write([]byte(`{"errors":[{"message":"forbidden"}],"meta":{"requestId":"2"}}`))
+		case "Bare":
+			_, _ = w.Write([]byte(`{"data":{"hero":{"name":"R2-D2"}}}`))
+		default:
+			_, _ = w.Write([]byte(`{"data":{"hero":{"name":"R2-D2"}},"meta":{"requestId":"1"},"gateway":"edge","extensions":{}}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	c := NewClient(server.Client(), server.URL)
+
+	var res struct {
+		Hero struct {
+			Name string `graphql:"name"`
+		} `graphql:"hero"`
+	}
+	var envelope map[string]json.RawMessage
+	extract := func(e map[string]json.RawMessage) {
+		envelope = e
+	}
+
+	require.NoError(t, c.Post(context.Background(), "Hero", `query Hero { hero { name } }`, &res, nil, WithEnvelopeExtractor(extract)))
+	require.Equal(t, "R2-D2", res.Hero.Name)
+	require.Equal(t, map[string]json.RawMessage{"meta": json.RawMessage(`{"requestId":"1"}`)}, envelope)
+
+	require.NoError(t, c.Post(context.Background(), "Hero", `query Hero { hero { name } }`, &res, nil, WithEnvelopeExtractor(extract, "meta", "gateway", "missing")))
+	require.Equal(t, map[string]json.RawMessage{"meta": json.RawMessage(`{"requestId":"1"}`), "gateway": json.RawMessage(`"edge"`)}, envelope)
+
+	require.NoError(t, c.Post(context.Background(), "Bare", `query Bare { hero { name } }`, &res, nil, WithEnvelopeExtractor(extract)))
+	require.Empty(t, envelope)
+
+	// the metadata of the responses having errors is extracted too
+	err := c.Post(context.Background(), "Failing", `query Failing { hero { name } }`, &res, nil, WithEnvelopeExtractor(extract))
+	require.Error(t, err)
+	require.Equal(t, map[string]json.RawMessage{"meta": json.RawMessage(`{"requestId":"2"}`)}, envelope)
+}
+
+func TestPing(t *testing.T) {
+	t.Parallel()
+
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		queries = append(queries, req.Query)
+
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`unauthorized`))
+
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"__typename":"Query"}}`))
+	}))
+	t.Cleanup(server.Close)
+	c := NewClient(server.Client(), server.URL)
+	authorize := func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		req.Header.Set("Authorization", "Bearer token")
+
+		return next(ctx, req, gqlInfo, res)
+	}
+
+	t.Run("healthy", func(t *testing.T) {
+		latency, err := c.Ping(context.Background(), "", authorize)
+		require.NoError(t, err)
+		require.True(t, latency > 0)
+
+		_, err = c.Ping(context.Background(), "query Probe { __typename }", authorize)
+		require.NoError(t, err)
+		require.Equal(t, []string{DefaultPingQuery, "query Probe { __typename }"}, queries[len(queries)-2:])
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		latency, err := c.Ping(context.Background(), "")
+		require.Error(t, err)
+		require.Zero(t, latency)
+		var errResponse *ErrorResponse
+		require.True(t, errors.As(err, &errResponse))
+		require.Equal(t, http.StatusUnauthorized, errResponse.NetworkError.Code)
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		unreachable := httptest.NewServer(http.NotFoundHandler())
+		unreachable.Close()
+
+		_, err := NewClient(http.DefaultClient, unreachable.URL).Ping(context.Background(), "")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "ping failed: request failed: ")
+		require.Contains(t, err.Error(), "connection refused")
+	})
+}
+
+func TestNewClientFromEnv(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"something":"` + r.Header.Get("Authorization") + `"}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	t.Run("set", func(t *testing.T) {
+		t.Setenv("MYAPI_ENDPOINT", server.URL)
+		t.Setenv("MYAPI_TOKEN", "token")
+		t.Setenv("MYAPI_TIMEOUT", "30s")
+
+		cfg, err := ConfigFromEnv("MYAPI")
+		require.NoError(t, err)
+		require.Equal(t, &EnvConfig{Endpoint: server.URL, Token: "token", Timeout: 30 * time.Second}, cfg)
+
+		c, err := NewClientFromEnv("MYAPI")
+		require.NoError(t, err)
+		require.Equal(t, server.URL, c.BaseURL)
+		require.Equal(t, 30*time.Second, c.Client.Timeout)
+
+		res := &fakeRes{}
+		require.NoError(t, c.Post(context.Background(), "", "{ something }", res, nil))
+		require.Equal(t, "Bearer token", res.Something)
+	})
+
+	t.Run("optional", func(t *testing.T) {
+		t.Setenv("ENDPOINT", server.URL)
+		t.Setenv("TOKEN", "")
+		t.Setenv("TIMEOUT", "")
+
+		c, err := NewClientFromEnv("")
+		require.NoError(t, err)
+		require.Zero(t, c.Client.Timeout)
+
+		res := &fakeRes{}
+		require.NoError(t, c.Post(context.Background(), "", "{ something }", res, nil))
+		require.Empty(t, res.Something)
+	})
+
+	t.Run("unset endpoint", func(t *testing.T) {
+		// t.Setenv restores the variable unset by the test
+		t.Setenv("MYAPI_ENDPOINT", "")
+		require.NoError(t, os.Unsetenv("MYAPI_ENDPOINT"))
+
+		_, err := NewClientFromEnv("MYAPI")
+		require.EqualError(t, err, "environment variable MYAPI_ENDPOINT of the graphql endpoint is not set")
+	})
+
+	t.Run("invalid timeout", func(t *testing.T) {
+		t.Setenv("MYAPI_ENDPOINT", server.URL)
+		t.Setenv("MYAPI_TIMEOUT", "soon")
+
+		_, err := ConfigFromEnv("MYAPI")
+		require.EqualError(t, err, `environment variable MYAPI_TIMEOUT: time: invalid duration "soon"`)
+
+		t.Setenv("MYAPI_TIMEOUT", "-1s")
+		_, err = ConfigFromEnv("MYAPI")
+		require.EqualError(t, err, "environment variable MYAPI_TIMEOUT: timeout -1s is not positive")
+	})
+}
+
+func TestWithRecorder(t *testing.T) {
+	t.Parallel()
+
+	// the server returns the user asked, and fails for the unknown ones
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		filter := req.Variables["filter"].(map[string]interface{})
+		if filter["id"] == "0" {
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte("<html>bad gateway</html>"))
+
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"data": {"user": {"id": %q, "name": "gopher %s"}}}`, filter["id"], filter["id"])
+	}))
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	const query = `query GetUser ($filter: UserFilter!) { user(filter: $filter) { id name } }`
+	type user struct {
+		User struct {
+			ID   string
+			Name string
+		}
+	}
+
+	recorder := NewClient(server.Client(), server.URL, WithRecorder(path))
+	for _, id := range []string{"1", "2", "0"} {
+		var res user
+		err := recorder.Post(context.Background(), "GetUser", query, &res, map[string]interface{}{"filter": map[string]interface{}{"id": id, "active": true}})
+		if id == "0" {
+			require.Error(t, err)
+		} else {
+			require.NoError(t, err)
+			require.Equal(t, "gopher "+id, res.User.Name)
+		}
+	}
+	server.Close()
+
+	recorded, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(recorded), `"name": "gopher 2"`)
+	require.Contains(t, string(recorded), `"body": "<html>bad gateway</html>"`)
+
+	// the variables of other go types and key orders get the same responses, without the server
+	replayer := NewClient(server.Client(), server.URL, WithReplayer(path))
+	type filter struct {
+		ID     string `json:"id"`
+		Active bool   `json:"active"`
+	}
+	for _, id := range []string{"2", "1"} {
+		var res user
+		require.NoError(t, replayer.Post(context.Background(), "GetUser", query, &res, map[string]interface{}{"filter": filter{ID: id, Active: true}}))
+		require.Equal(t, id, res.User.ID)
+		require.Equal(t, "gopher "+id, res.User.Name)
+	}
+
+	var res user
+	err = replayer.Post(context.Background(), "GetUser", query, &res, map[string]interface{}{"filter": filter{ID: "0", Active: true}})
+	var errResponse *ErrorResponse
+	require.True(t, errors.As(err, &errResponse))
+	require.Equal(t, &HTTPError{Code: http.StatusBadGateway, Message: "Response body <html>bad gateway</html>"}, errResponse.NetworkError)
+
+	err = replayer.Post(context.Background(), "GetUser", query, &res, map[string]interface{}{"filter": filter{ID: "3"}})
+	require.True(t, errors.Is(err, ErrNoRecording))
+}
+
+func TestConnections(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "users", ConnectionKey("users", nil))
+	require.Equal(t, `users({"first":null,"role":"ADMIN"})`, ConnectionKey("users", map[string]interface{}{"role": "ADMIN", "first": nil}))
+
+	connections := NewConnections()
+	merge := func(merged, page interface{}) interface{} {
+		return append(merged.([]string), page.([]string)...)
+	}
+	require.Equal(t, []string{"a"}, connections.Merge("users", []string{"a"}, merge))
+	require.Equal(t, []string{"a", "b", "c"}, connections.Merge("users", []string{"b", "c"}, merge))
+	require.Equal(t, []string{"d"}, connections.Merge("admins", []string{"d"}, merge))
+
+	cached, ok := connections.Get("users")
+	require.True(t, ok)
+	require.Equal(t, []string{"a", "b", "c"}, cached)
+
+	connections.Delete("users")
+	_, ok = connections.Get("users")
+	require.False(t, ok)
+}
+
+func TestRegisterErrorCode(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data": {"user": null}, "errors": [
+			{"message": "no user 1", "path": ["user"], "extensions": {"code": "NOT_FOUND"}},
+			{"message": "internal", "extensions": {"code": "INTERNAL"}}
+		]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	errNotFound := errors.New("not found")
+	errUnauthenticated := errors.New("unauthenticated")
+	var res struct {
+		User *struct {
+			Name string `graphql:"name"`
+		} `graphql:"user"`
+	}
+	const query = `query User { user(id: 1) { name } }`
+
+	c := NewClient(server.Client(), server.URL)
+	c.RegisterErrorCode("NOT_FOUND", errNotFound)
+	c.RegisterErrorCode("UNAUTHENTICATED", errUnauthenticated)
+	err := c.Post(context.Background(), "User", query, &res, nil)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, errNotFound), "the code of a graphql error matches its error")
+	require.False(t, errors.Is(err, errUnauthenticated))
+	var errResponse *ErrorResponse
+	require.True(t, errors.As(err, &errResponse))
+	require.Len(t, *errResponse.GqlErrors, 2)
+
+	// without registered codes the errors match none
+	c = NewClient(server.Client(), server.URL)
+	err = c.Post(context.Background(), "User", query, &res, nil)
+	require.False(t, errors.Is(err, errNotFound))
+}
+
+// testBatch is the response of a batch of the mutations UpdateUser and Update.
+type testBatch struct {
+	UpdateUser struct {
+		UpdateUser struct {
+			Name string `graphql:"name"`
+		} `graphql:"updateUser"`
+	}
+	Update struct {
+		Update struct {
+			ID string `graphql:"id"`
+		} `graphql:"update"`
+		Count int `graphql:"count"`
+	}
+}
+
+func (b *testBatch) BatchResponses() []BatchResponse {
+	return []BatchResponse{
+		{Prefix: "UpdateUser_", Response: &b.UpdateUser},
+		{Prefix: "Update_", Response: &b.Update},
+	}
+}
+
+func TestBatch(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Transaction") != "atomic" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"UpdateUser_updateUser":{"name":"Gopher"},"Update_update":{"id":"1"},"Update_count":2}}`))
+	}))
+	t.Cleanup(server.Close)
+	c := NewClient(server.Client(), server.URL)
+	ctx := context.Background()
+
+	t.Run("responses", func(t *testing.T) {
+		t.Parallel()
+		var res testBatch
+		require.NoError(t, c.Post(ctx, "Save", `mutation Save { UpdateUser_updateUser: updateUser { name } }`, &res, nil, WithHeader("X-Transaction", "atomic")))
+		// each operation gets the fields of the longest prefix they have
+		require.Equal(t, "Gopher", res.UpdateUser.UpdateUser.Name)
+		require.Equal(t, "1", res.Update.Update.ID)
+		require.Equal(t, 2, res.Update.Count)
+	})
+
+	t.Run("header", func(t *testing.T) {
+		t.Parallel()
+		var res testBatch
+		err := c.Post(ctx, "Save", `mutation Save { UpdateUser_updateUser: updateUser { name } }`, &res, nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "400")
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		t.Parallel()
+		var res testBatch
+		err := UnmarshalResponse([]byte(`{"data":{"Delete_delete":true}}`), &res)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "batch data: field Delete_delete of no operation")
+	})
+}
+
+func TestWithResponseUnwrapper(t *testing.T) {
+	t.Parallel()
+
+	// the gateway nests the data and the errors of the responses in its envelope
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("fail") != "" {
+			_, _ = w.Write([]byte(`{"result": {"problems": [{"message": "user not found", "path": ["user"]}]}}`))
+
+			return
+		}
+		_, _ = w.Write([]byte(`{"requestId": "42", "result": {"payload": {"user": {"name": "Gopher"}}}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	unwrap := func(raw json.RawMessage) (json.RawMessage, json.RawMessage, error) {
+		var envelope struct {
+			Result *struct {
+				Payload  json.RawMessage `json:"payload"`
+				Problems json.RawMessage `json:"problems"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal(raw, &envelope); err != nil {
+			return nil, nil, err
+		}
+		if envelope.Result == nil {
+			return nil, nil, errors.New("no result")
+		}
+
+		return envelope.Result.Payload, envelope.Result.Problems, nil
+	}
+	type user struct {
+		User struct {
+			Name string `graphql:"name"`
+		} `graphql:"user"`
+	}
+	const query = `query User { user { name } }`
+	ctx := context.Background()
+
+	t.Run("data", func(t *testing.T) {
+		t.Parallel()
+		c := NewClient(server.Client(), server.URL, WithResponseUnwrapper(unwrap))
+		var res user
+		require.NoError(t, c.Post(ctx, "User", query, &res, nil))
+		require.Equal(t, "Gopher", res.User.Name)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		c := NewClient(server.Client(), server.URL+"?fail=1", WithResponseUnwrapper(unwrap))
+		var res user
+		errs, err := GraphQLErrors(c.Post(ctx, "User", query, &res, nil))
+		require.NoError(t, err)
+		require.Len(t, errs, 1)
+		require.Equal(t, "user not found", errs[0].Message)
+	})
+
+	t.Run("unwrap error", func(t *testing.T) {
+		t.Parallel()
+		c := NewClient(server.Client(), server.URL, WithResponseUnwrapper(func(raw json.RawMessage) (json.RawMessage, json.RawMessage, error) {
+			return nil, nil, errors.New("no result")
+		}))
+		var res user
+		err := c.Post(ctx, "User", query, &res, nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to unwrap response: no result")
+	})
+
+	t.Run("standard", func(t *testing.T) {
+		t.Parallel()
+		data, errs, err := StandardResponseUnwrapper(json.RawMessage(`{"data": {"user": null}, "errors": [{"message": "boom"}]}`))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"user": null}`, string(data))
+		require.JSONEq(t, `[{"message": "boom"}]`, string(errs))
+	})
+}
+
+func TestWithContextValues(t *testing.T) {
+	t.Parallel()
+
+	type tokenKey struct{}
+	token := func(ctx context.Context) (string, bool) {
+		v, ok := ctx.Value(tokenKey{}).(string)
+
+		return v, ok
+	}
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header
+		_, _ = w.Write([]byte(`{"data": {"user": {"name": "Gopher"}}}`))
+	}))
+	t.Cleanup(server.Close)
+	var res struct {
+		User struct {
+			Name string `graphql:"name"`
+		} `graphql:"user"`
+	}
+	const query = `query User { user { name } }`
+
+	t.Run("header", func(t *testing.T) {
+		// the header is set for the contexts carrying the value only
+		c := NewClient(server.Client(), server.URL, WithHeader("Authorization", "default"), WithContextHeader("Authorization", "Bearer ", token))
+		require.NoError(t, c.Post(context.WithValue(context.Background(), tokenKey{}, "secret"), "User", query, &res, nil))
+		require.Equal(t, "Bearer secret", (<-headers).Get("Authorization"))
+		require.NoError(t, c.Post(context.Background(), "User", query, &res, nil))
+		require.Equal(t, "default", (<-headers).Get("Authorization"))
+	})
+
+	t.Run("invalid endpoint", func(t *testing.T) {
+		c := NewClient(server.Client(), server.URL, WithContextEndpoint(token))
+		err := c.Post(context.WithValue(context.Background(), tokenKey{}, "http://[::1"), "User", query, &res, nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid context endpoint")
+	})
+}
+
+func TestResponseBOM(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("bom") != "" {
+			// the byte order mark written by some gateways
+			_, _ = w.Write([]byte("\xef\xbb\xbf"))
+		}
+		_, _ = w.Write([]byte("\r\n" + `{"data": {"user": {"name": "Gopher"}}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	type query struct {
+		User struct {
+			Name string `graphql:"name"`
+		} `graphql:"user"`
+	}
+	for _, url := range []string{server.URL + "?bom=1", server.URL} {
+		var res query
+		require.NoError(t, NewClient(server.Client(), url).Post(context.Background(), "User", `query User { user { name } }`, &res, nil))
+		require.Equal(t, "Gopher", res.User.Name)
+	}
+
+	var res query
+	require.NoError(t, UnmarshalResponse([]byte("\xef\xbb\xbf"+`{"data": {"user": {"name": "Gopher"}}}`), &res))
+	require.Equal(t, "Gopher", res.User.Name)
+}
@@ -0,0 +1,76 @@
+package clientv2
+
+import (
+	"reflect"
+)
+
+// Clone returns a deep copy of v, like a decoded response shared by several callers and copied by one to modify it.
+// The pointers, slices, maps and interfaces of v are copied recursively, a value pointed several times in v being copied once.
+// The unexported fields of the structs are copied as is, like the wall clock of a time.Time, and the channels and functions are shared.
+func Clone[T any](v T) T {
+	src := reflect.ValueOf(&v).Elem()
+	dst := reflect.New(src.Type()).Elem()
+	cloneValue(dst, src, make(map[uintptr]reflect.Value))
+
+	return dst.Interface().(T)
+}
+
+// cloneValue sets dst, a settable value of the type of src, to a deep copy of src,
+// the copies of the pointers copied so far being in pointers, by address.
+func cloneValue(dst, src reflect.Value, pointers map[uintptr]reflect.Value) {
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+		if copied, ok := pointers[src.Pointer()]; ok && copied.Type() == src.Type() {
+			dst.Set(copied)
+
+			return
+		}
+		copied := reflect.New(src.Type().Elem())
+		pointers[src.Pointer()] = copied
+		cloneValue(copied.Elem(), src.Elem(), pointers)
+		dst.Set(copied)
+	case reflect.Struct:
+		// the unexported fields are copied along the struct, the exported ones are copied again deeply
+		dst.Set(src)
+		for i := 0; i < src.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				cloneValue(dst.Field(i), src.Field(i), pointers)
+			}
+		}
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.MakeSlice(src.Type(), src.Len(), src.Len()))
+		for i := 0; i < src.Len(); i++ {
+			cloneValue(dst.Index(i), src.Index(i), pointers)
+		}
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			cloneValue(dst.Index(i), src.Index(i), pointers)
+		}
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.MakeMapWithSize(src.Type(), src.Len()))
+		iter := src.MapRange()
+		for iter.Next() {
+			value := reflect.New(src.Type().Elem()).Elem()
+			cloneValue(value, iter.Value(), pointers)
+			dst.SetMapIndex(iter.Key(), value)
+		}
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+		value := reflect.New(src.Elem().Type()).Elem()
+		cloneValue(value, src.Elem(), pointers)
+		dst.Set(value)
+	default:
+		dst.Set(src)
+	}
+}
@@ -0,0 +1,36 @@
+package clientv2
+
+import "errors"
+
+// RegisterErrorCode makes the errors of the responses having a graphql error of the code in its extensions match target
+// with errors.Is, like RegisterErrorCode("NOT_FOUND", ErrNotFound) for errors.Is(err, ErrNotFound) on the errors of
+// {"errors": [{"message": "no user", "extensions": {"code": "NOT_FOUND"}}]}. The error stays an *ErrorResponse for errors.As.
+// The codes are registered before sending requests, RegisterErrorCode is not safe to call concurrently with them.
+func (c *Client) RegisterErrorCode(code string, target error) {
+	if c.errorCodes == nil {
+		c.errorCodes = make(map[string]error)
+	}
+	c.errorCodes[code] = target
+}
+
+// Unwrap returns the errors registered for the codes of the graphql errors, see Client.RegisterErrorCode.
+func (er *ErrorResponse) Unwrap() []error {
+	return er.codes
+}
+
+// mapErrorCodes adds to the *ErrorResponse err the errors registered for the codes of its graphql errors and returns err.
+func (c *Client) mapErrorCodes(err error) error {
+	var errResponse *ErrorResponse
+	if len(c.errorCodes) == 0 || !errors.As(err, &errResponse) || errResponse.GqlErrors == nil {
+		return err
+	}
+
+	for _, gqlErr := range *errResponse.GqlErrors {
+		code, _ := gqlErr.Extensions["code"].(string)
+		if target, ok := c.errorCodes[code]; ok {
+			errResponse.codes = append(errResponse.codes, target)
+		}
+	}
+
+	return err
+}
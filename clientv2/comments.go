@@ -0,0 +1,28 @@
+package clientv2
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/pleclech/gqlgenc/graphqljson"
+)
+
+// WithJSONComments returns an interceptor accepting the line and block comments of the response bodies,
+// written by some mock servers, which standard JSON forbids. They are stripped before the response is decoded,
+// see graphqljson.NewCommentReader.
+func WithJSONComments() RequestInterceptor {
+	return func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		gqlInfo.jsonComments = true
+
+		return next(ctx, req, gqlInfo, res)
+	}
+}
+
+// stripComments returns the response body without its comments when they are accepted.
+func stripComments(body []byte, gqlInfo *GQLRequestInfo) ([]byte, error) {
+	if !gqlInfo.jsonComments {
+		return body, nil
+	}
+
+	return graphqljson.StripComments(body)
+}
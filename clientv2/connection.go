@@ -0,0 +1,23 @@
+package clientv2
+
+import (
+	"context"
+	"net/http"
+)
+
+type closeConnectionKey struct{}
+
+// WithCloseConnection returns a copy of ctx making the requests sent with it close their connection after the response,
+// sending a Connection: close header instead of keeping the connection alive in the pool of the transport.
+// It works around proxies and other intermediaries mishandling reused connections, for the operations which need it.
+func WithCloseConnection(ctx context.Context) context.Context {
+	return context.WithValue(ctx, closeConnectionKey{}, true)
+}
+
+// setCloseConnection marks req to close its connection after the response when its context asks to.
+// The transport sends the Connection: close header of the request and does not reuse its connection.
+func setCloseConnection(req *http.Request) {
+	if closeConnection, _ := req.Context().Value(closeConnectionKey{}).(bool); closeConnection {
+		req.Close = true
+	}
+}
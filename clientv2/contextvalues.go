@@ -0,0 +1,55 @@
+package clientv2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ContextValue returns the value of a request carried by its context, and whether the context carries one,
+// like the AuthTokenFrom helper generated by client v2 for the contextValues.
+type ContextValue func(ctx context.Context) (string, bool)
+
+// WithContextHeader returns an interceptor setting the header name of the requests to prefix followed by the value
+// carried by their context, leaving the header unchanged for the contexts carrying none.
+func WithContextHeader(name, prefix string, from ContextValue) RequestInterceptor {
+	return func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		if value, ok := from(ctx); ok {
+			req.Header.Set(name, prefix+value)
+		}
+
+		return next(ctx, req, gqlInfo, res)
+	}
+}
+
+// WithContextEndpoint returns an interceptor sending the requests to the endpoint carried by their context
+// rather than to the base URL of the client, like the endpoint of a region or of a tenant.
+func WithContextEndpoint(from ContextValue) RequestInterceptor {
+	return func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		if endpoint, ok := from(ctx); ok {
+			u, err := url.Parse(endpoint)
+			if err != nil {
+				return fmt.Errorf("invalid context endpoint: %w", err)
+			}
+			req.URL = u
+			req.Host = u.Host
+		}
+
+		return next(ctx, req, gqlInfo, res)
+	}
+}
+
+// WithContextIdempotencyKey returns an interceptor using the key carried by the context of a mutation as its idempotency key,
+// enabling the idempotency keys for the request, see WithIdempotencyKeys.
+// The mutations whose context carries no key, or an empty one, get a generated key when the idempotency keys are enabled.
+func WithContextIdempotencyKey(from ContextValue) RequestInterceptor {
+	return func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		if key, ok := from(ctx); ok && key != "" {
+			gqlInfo.idempotencyKeys = true
+			gqlInfo.idempotencyKey = key
+		}
+
+		return next(ctx, req, gqlInfo, res)
+	}
+}
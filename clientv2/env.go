@@ -0,0 +1,89 @@
+package clientv2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// The environment variables read by ConfigFromEnv, after their prefix and an underscore, like GITHUB_ENDPOINT.
+const (
+	EnvEndpoint = "ENDPOINT"
+	EnvToken    = "TOKEN"
+	EnvTimeout  = "TIMEOUT"
+)
+
+// EnvConfig is the configuration of a client read from the environment by ConfigFromEnv.
+type EnvConfig struct {
+	// Endpoint is the url of the graphql endpoint, from the required ENDPOINT variable.
+	Endpoint string
+	// Token is sent as a bearer token in the Authorization header of the requests, from the TOKEN variable, not sent if empty.
+	Token string
+	// Timeout is the timeout of the http client, from the TIMEOUT variable parsed by time.ParseDuration, like 30s, none if 0.
+	Timeout time.Duration
+}
+
+// ConfigFromEnv reads the configuration of a client from the environment variables of prefix,
+// prefix_ENDPOINT, prefix_TOKEN and prefix_TIMEOUT, or ENDPOINT, TOKEN and TIMEOUT without prefix.
+// It fails when the endpoint is not set or the timeout is not a positive duration.
+func ConfigFromEnv(prefix string) (*EnvConfig, error) {
+	name := func(variable string) string {
+		if prefix == "" {
+			return variable
+		}
+
+		return prefix + "_" + variable
+	}
+
+	cfg := &EnvConfig{
+		Endpoint: os.Getenv(name(EnvEndpoint)),
+		Token:    os.Getenv(name(EnvToken)),
+	}
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("environment variable %s of the graphql endpoint is not set", name(EnvEndpoint))
+	}
+
+	if timeout := os.Getenv(name(EnvTimeout)); timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable %s: %w", name(EnvTimeout), err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("environment variable %s: timeout %s is not positive", name(EnvTimeout), timeout)
+		}
+		cfg.Timeout = d
+	}
+
+	return cfg, nil
+}
+
+// HTTPClient returns an http client having the timeout of the configuration.
+func (c *EnvConfig) HTTPClient() *http.Client {
+	return &http.Client{Timeout: c.Timeout}
+}
+
+// Interceptors returns the interceptors sending the token of the configuration, none without token.
+func (c *EnvConfig) Interceptors() []RequestInterceptor {
+	if c.Token == "" {
+		return nil
+	}
+
+	return []RequestInterceptor{func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+
+		return next(ctx, req, gqlInfo, res)
+	}}
+}
+
+// NewClientFromEnv creates a client with the configuration read from the environment variables of prefix, see ConfigFromEnv.
+// The interceptors run after the one sending the token, and may replace its header.
+func NewClientFromEnv(prefix string, interceptors ...RequestInterceptor) (*Client, error) {
+	cfg, err := ConfigFromEnv(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClient(cfg.HTTPClient(), cfg.Endpoint, append(cfg.Interceptors(), interceptors...)...), nil
+}
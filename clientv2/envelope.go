@@ -0,0 +1,47 @@
+package clientv2
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// defaultEnvelopeKey is the key of the metadata added to the responses by some gateways, like {"data": {...}, "meta": {"requestId": "..."}}.
+const defaultEnvelopeKey = "meta"
+
+// WithEnvelopeExtractor returns an interceptor calling extract with the values of keys found along the data of each response,
+// by key, "meta" if no keys are given, to surface the metadata of gateways wrapping the response without decoding it into the data.
+// extract is called for the responses having graphql errors too, not for the bodies which are not JSON objects.
+func WithEnvelopeExtractor(extract func(envelope map[string]json.RawMessage), keys ...string) RequestInterceptor {
+	if len(keys) == 0 {
+		keys = []string{defaultEnvelopeKey}
+	}
+
+	return func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		gqlInfo.envelopeExtractor = extract
+		gqlInfo.envelopeKeys = keys
+
+		return next(ctx, req, gqlInfo, res)
+	}
+}
+
+// extractEnvelope calls the envelope extractor of the request with the values of its keys in the response body.
+func extractEnvelope(body []byte, gqlInfo *GQLRequestInfo) {
+	if gqlInfo.envelopeExtractor == nil {
+		return
+	}
+
+	var resp map[string]json.RawMessage
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return
+	}
+
+	envelope := make(map[string]json.RawMessage, len(gqlInfo.envelopeKeys))
+	for _, key := range gqlInfo.envelopeKeys {
+		if value, ok := resp[key]; ok {
+			envelope[key] = value
+		}
+	}
+
+	gqlInfo.envelopeExtractor(envelope)
+}
@@ -0,0 +1,91 @@
+package clientv2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// DefaultMaxGetURLLength is the length of the URL above which a query is sent with POST rather than GET,
+// the limit of many proxies and CDNs being about 2KB.
+const DefaultMaxGetURLLength = 2048
+
+// WithGetForQueries returns an interceptor sending the queries with GET when enabled, as GraphQL over HTTP allows,
+// so they can be cached by CDNs. The query, the operation name and the JSON encoded variables are URL parameters.
+// Mutations are always sent with POST, and so are the queries whose URL would be longer than DefaultMaxGetURLLength,
+// or the length set by WithMaxGetURLLength.
+func WithGetForQueries(enabled bool) RequestInterceptor {
+	return func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		gqlInfo.getForQueries = enabled
+
+		return next(ctx, req, gqlInfo, res)
+	}
+}
+
+// WithMaxGetURLLength returns an interceptor setting the length of the URL above which a query is sent with POST
+// rather than GET, see WithGetForQueries.
+func WithMaxGetURLLength(n int) RequestInterceptor {
+	return func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		gqlInfo.maxGetURLLength = n
+
+		return next(ctx, req, gqlInfo, res)
+	}
+}
+
+// getRequest returns the GET request of the query of req when queries are sent with GET, req otherwise,
+// its variables being encoded by marshal.
+func getRequest(req *http.Request, gqlInfo *GQLRequestInfo, marshal func(v interface{}) ([]byte, error)) (*http.Request, error) {
+	if !gqlInfo.getForQueries || req.Method != http.MethodPost || operationType(gqlInfo.Request) != ast.Query {
+		return req, nil
+	}
+
+	params := req.URL.Query()
+	if persisted := gqlInfo.persisted; persisted != nil {
+		if persisted.Extensions != nil {
+			extensions, err := marshal(persisted.Extensions)
+			if err != nil {
+				return nil, fmt.Errorf("encode extensions: %w", err)
+			}
+			params.Set("extensions", string(extensions))
+		}
+		if persisted.DocumentID != "" {
+			params.Set("documentId", persisted.DocumentID)
+		}
+	} else {
+		params.Set("query", gqlInfo.Request.Query)
+	}
+	if gqlInfo.Request.OperationName != "" {
+		params.Set("operationName", gqlInfo.Request.OperationName)
+	}
+	if len(gqlInfo.Request.Variables) > 0 {
+		variables, err := marshal(gqlInfo.Request.Variables)
+		if err != nil {
+			return nil, fmt.Errorf("encode variables: %w", err)
+		}
+		params.Set("variables", string(variables))
+	}
+
+	u := *req.URL
+	u.RawQuery = params.Encode()
+
+	maxLength := gqlInfo.maxGetURLLength
+	if maxLength <= 0 {
+		maxLength = DefaultMaxGetURLLength
+	}
+	if len(u.String()) > maxLength {
+		return req, nil
+	}
+
+	get := req.Clone(req.Context())
+	get.Method = http.MethodGet
+	get.URL = &u
+	get.Host = req.Host
+	get.Body = nil
+	get.GetBody = nil
+	get.ContentLength = 0
+	get.Header.Del("Content-Type")
+
+	return get, nil
+}
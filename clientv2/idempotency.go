@@ -0,0 +1,96 @@
+package clientv2
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// IdempotencyKeyHeader is the header carrying the idempotency key of a mutation.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// WithIdempotencyKeys returns an interceptor attaching an Idempotency-Key header to mutations when enabled,
+// so backends supporting it can dedupe retried mutations.
+// The key is generated once per call of Post and reused by every retry an interceptor makes by calling next again.
+// Keys are random UUIDs unless another generator is given with WithIdempotencyKeyGenerator.
+func WithIdempotencyKeys(enabled bool) RequestInterceptor {
+	return func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		gqlInfo.idempotencyKeys = enabled
+
+		return next(ctx, req, gqlInfo, res)
+	}
+}
+
+// WithIdempotencyKeyGenerator returns an interceptor generating the idempotency keys with generate.
+func WithIdempotencyKeyGenerator(generate func() (string, error)) RequestInterceptor {
+	return func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		gqlInfo.idempotencyKeyGenerator = generate
+
+		return next(ctx, req, gqlInfo, res)
+	}
+}
+
+// setIdempotencyKey sets the idempotency key header of req when the request is a mutation,
+// generating the key on the first attempt.
+func setIdempotencyKey(req *http.Request, gqlInfo *GQLRequestInfo) error {
+	if !gqlInfo.idempotencyKeys || !isMutation(gqlInfo.Request) {
+		return nil
+	}
+
+	if gqlInfo.idempotencyKey == "" {
+		generate := gqlInfo.idempotencyKeyGenerator
+		if generate == nil {
+			generate = newUUID
+		}
+
+		key, err := generate()
+		if err != nil {
+			return fmt.Errorf("generate idempotency key failed: %w", err)
+		}
+		gqlInfo.idempotencyKey = key
+	}
+	req.Header.Set(IdempotencyKeyHeader, gqlInfo.idempotencyKey)
+
+	return nil
+}
+
+// isMutation reports whether the operation run by r is a mutation.
+func isMutation(r *Request) bool {
+	return operationType(r) == ast.Mutation
+}
+
+// operationType returns the type of the operation run by r, empty if the query does not parse or does not define it.
+func operationType(r *Request) ast.Operation {
+	query, err := parser.ParseQuery(&ast.Source{Input: r.Query})
+	if err != nil {
+		return ""
+	}
+
+	var operation *ast.OperationDefinition
+	if r.OperationName == "" && len(query.Operations) == 1 {
+		operation = query.Operations[0]
+	} else {
+		operation = query.Operations.ForName(r.OperationName)
+	}
+	if operation == nil {
+		return ""
+	}
+
+	return operation.Operation
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf(": %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
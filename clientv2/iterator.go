@@ -0,0 +1,111 @@
+package clientv2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// DefaultPageSize is the number of nodes fetched by page when an iterator is given no page size.
+const DefaultPageSize = 100
+
+// PageInfo is the pagination state of a page of a Relay connection.
+type PageInfo struct {
+	EndCursor   *string
+	HasNextPage bool
+}
+
+// PageFunc fetches the page of at most first nodes of type T after the cursor after, nil for the first page,
+// and returns its nodes and page info.
+type PageFunc[T any] func(ctx context.Context, first int, after *string) ([]T, PageInfo, error)
+
+// Iterator iterates over the nodes of type T of a Relay connection, fetching the next page
+// with the end cursor of the current one when its nodes are consumed:
+//
+//	for it.Next() {
+//		node := it.Node()
+//	}
+//	if err := it.Err(); err != nil {
+//		return err
+//	}
+type Iterator[T any] struct {
+	ctx      context.Context
+	pageSize int
+	fetch    PageFunc[T]
+
+	page  int
+	after *string
+	done  bool
+	nodes []T
+	node  T
+	err   error
+}
+
+// NewIterator returns an iterator fetching the pages of pageSize nodes with fetch, DefaultPageSize if pageSize is not positive.
+// The iteration stops with the error of ctx when it is done.
+func NewIterator[T any](ctx context.Context, pageSize int, fetch PageFunc[T]) *Iterator[T] {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	return &Iterator[T]{
+		ctx:      ctx,
+		pageSize: pageSize,
+		fetch:    fetch,
+	}
+}
+
+// Next advances to the next node, fetching the next page if needed.
+// It returns false at the end of the connection or on error, see Err.
+func (it *Iterator[T]) Next() bool {
+	if it.err == nil {
+		it.err = it.ctx.Err()
+	}
+
+	for it.err == nil && len(it.nodes) == 0 && !it.done {
+		it.fetchPage()
+	}
+
+	if it.err != nil || len(it.nodes) == 0 {
+		var zero T
+		it.node = zero
+
+		return false
+	}
+
+	it.node, it.nodes = it.nodes[0], it.nodes[1:]
+
+	return true
+}
+
+// fetchPage fetches the page after the current one.
+func (it *Iterator[T]) fetchPage() {
+	it.page++
+	nodes, pageInfo, err := it.fetch(it.ctx, it.pageSize, it.after)
+	switch {
+	case err != nil:
+	case pageInfo.HasNextPage && pageInfo.EndCursor == nil:
+		err = errors.New("next page without end cursor")
+	case pageInfo.HasNextPage && it.after != nil && *pageInfo.EndCursor == *it.after:
+		err = errors.New("end cursor did not move")
+	}
+	if err != nil {
+		it.err = fmt.Errorf("fetch page %d failed: %w", it.page, err)
+
+		return
+	}
+
+	it.nodes = nodes
+	it.after = pageInfo.EndCursor
+	it.done = !pageInfo.HasNextPage
+}
+
+// Node returns the current node, the zero value of T once the iteration stopped.
+func (it *Iterator[T]) Node() T {
+	return it.node
+}
+
+// Err returns the error which stopped the iteration, nil at the end of the connection.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
@@ -0,0 +1,37 @@
+package clientv2
+
+// List returns the values in a slice, to pass a single value to a list argument of a generated method,
+// like client.GetUsers(ctx, clientv2.List(id)). The value is sent as a list of one element, [value],
+// which GraphQL servers also accept as the single value itself.
+func List[T any](values ...T) []T {
+	if values == nil {
+		return []T{}
+	}
+
+	return values
+}
+
+// Group is the values of a list having the same key, see GroupBy.
+type Group[K comparable, T any] struct {
+	Key    K
+	Values []T
+}
+
+// GroupBy groups the values of a decoded list by the key returned by key, like the events of a response by day.
+// The groups are in the order of the first value of their key in the list, and their values in the order of the list.
+func GroupBy[K comparable, T any](values []T, key func(T) K) []Group[K, T] {
+	var groups []Group[K, T]
+	indexes := make(map[K]int)
+	for _, value := range values {
+		k := key(value)
+		i, ok := indexes[k]
+		if !ok {
+			i = len(groups)
+			indexes[k] = i
+			groups = append(groups, Group[K, T]{Key: k})
+		}
+		groups[i].Values = append(groups[i].Values, value)
+	}
+
+	return groups
+}
@@ -0,0 +1,28 @@
+package clientv2
+
+import (
+	"encoding/json"
+)
+
+// Marshaler encodes the requests sent by Client in JSON, like the Marshal function of encoding/json
+// or of a faster JSON library. It must honor the json tags of Request.
+type Marshaler interface {
+	Marshal(v interface{}) ([]byte, error)
+}
+
+// MarshalerFunc is a function used as a Marshaler, e.g. MarshalerFunc(jsoniter.ConfigCompatibleWithStandardLibrary.Marshal).
+type MarshalerFunc func(v interface{}) ([]byte, error)
+
+// Marshal calls f(v).
+func (f MarshalerFunc) Marshal(v interface{}) ([]byte, error) {
+	return f(v)
+}
+
+// marshal encodes v with the marshaler of the client, encoding/json if it has none.
+func (c *Client) marshal(v interface{}) ([]byte, error) {
+	if c.Marshaler == nil {
+		return json.Marshal(v)
+	}
+
+	return c.Marshaler.Marshal(v)
+}
@@ -0,0 +1,69 @@
+package clientv2
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// ConnectionKey returns the cache key of a connection of the @connection(key:, filter:) directive, the key of the directive
+// followed by the JSON of the values of its filter arguments, like users({"role":"ADMIN"}), key alone without filter.
+// The pagination arguments are not part of the key, so that the pages of a connection share it.
+func ConnectionKey(key string, filter map[string]interface{}) string {
+	if len(filter) == 0 {
+		return key
+	}
+
+	// the keys of maps are encoded sorted, the key is stable
+	values, err := json.Marshal(filter)
+	if err != nil {
+		return fmt.Sprintf("%s(%v)", key, filter)
+	}
+
+	return key + "(" + string(values) + ")"
+}
+
+// Connections caches the connections merged from their pages by connection key, see ConnectionKey,
+// for a normalized cache holding each connection once whatever the pages fetched. It is safe for concurrent use.
+type Connections struct {
+	mu     sync.Mutex
+	merged map[string]interface{}
+}
+
+// NewConnections returns an empty cache of connections.
+func NewConnections() *Connections {
+	return &Connections{merged: make(map[string]interface{})}
+}
+
+// Merge merges page into the connection cached under key with merge, given the cached connection and the page,
+// and caches and returns the connection merge returns. The first page of a key is cached as is.
+// merge is the generated function of the @connection directive of the operation, like MergeListUsers.
+func (c *Connections) Merge(key string, page interface{}, merge func(merged, page interface{}) interface{}) interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if merged, ok := c.merged[key]; ok {
+		page = merge(merged, page)
+	}
+	c.merged[key] = page
+
+	return page
+}
+
+// Get returns the connection cached under key and whether there is one.
+func (c *Connections) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	merged, ok := c.merged[key]
+
+	return merged, ok
+}
+
+// Delete removes the connection cached under key, its next page being cached as the first one.
+func (c *Connections) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.merged, key)
+}
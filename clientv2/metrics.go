@@ -0,0 +1,136 @@
+package clientv2
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// BodySizes are the sizes in bytes of the body of a request and of the body of its response.
+type BodySizes struct {
+	OperationName string
+	Request       int64
+	// Response is the size received, before decompression.
+	Response int64
+}
+
+// WithBodySizes returns an interceptor calling report with the body sizes of each request sent and its response.
+// It asks for a gzip compressed response unless the request already sets Accept-Encoding,
+// and decompresses it itself, so the reported size is the size received.
+func WithBodySizes(report func(sizes BodySizes)) RequestInterceptor {
+	return func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		gqlInfo.bodySizes = report
+
+		return next(ctx, req, gqlInfo, res)
+	}
+}
+
+// DecodeDuration is the time taken to decode the body of a response, once received.
+type DecodeDuration struct {
+	OperationName string
+	// Size is the size in bytes of the body decoded, after decompression.
+	Size     int64
+	Duration time.Duration
+}
+
+// WithDecodeDurations returns an interceptor calling report with the time taken to decode each response,
+// its graphql errors and its data, separately from the time of its round trip, and with each event of a subscription.
+func WithDecodeDurations(report func(d DecodeDuration)) RequestInterceptor {
+	return func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		gqlInfo.decodeDurations = report
+
+		return next(ctx, req, gqlInfo, res)
+	}
+}
+
+// timeDecode calls decode, reporting how long it took for body when decode durations are asked.
+func timeDecode(body []byte, gqlInfo *GQLRequestInfo, decode func() error) error {
+	if gqlInfo.decodeDurations == nil {
+		return decode()
+	}
+
+	start := time.Now()
+	err := decode()
+	gqlInfo.decodeDurations(DecodeDuration{
+		OperationName: gqlInfo.Request.OperationName,
+		Size:          int64(len(body)),
+		Duration:      time.Since(start),
+	})
+
+	return err
+}
+
+// ErrResponseTooLarge is the error of a response body larger than the maximum set by WithMaxResponseBytes.
+var ErrResponseTooLarge = errors.New("response too large")
+
+// WithMaxResponseBytes returns an interceptor failing with ErrResponseTooLarge on the responses whose body exceeds n bytes,
+// before decoding them and without reading more than n+1 bytes, to guard against responses exhausting the memory.
+// The limit is on the size of the decompressed body, whether the transport or WithBodySizes decompresses it.
+func WithMaxResponseBytes(n int64) RequestInterceptor {
+	return func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		gqlInfo.maxResponseBytes = n
+
+		return next(ctx, req, gqlInfo, res)
+	}
+}
+
+// readResponseBody reads the body of resp, reporting the body sizes when asked to
+// and failing with ErrResponseTooLarge when its decompressed size exceeds the maximum.
+// On error it returns the part of the body read.
+func readResponseBody(req *http.Request, resp *http.Response, gqlInfo *GQLRequestInfo) ([]byte, error) {
+	var r io.Reader = resp.Body
+	var counter *countingReader
+	if gqlInfo.bodySizes != nil {
+		counter = &countingReader{r: resp.Body}
+		r = counter
+		if resp.Header.Get("Content-Encoding") == "gzip" && !resp.Uncompressed {
+			gz, err := gzip.NewReader(counter)
+			if err != nil {
+				return nil, fmt.Errorf("gzip: %w", err)
+			}
+			defer gz.Close()
+			r = gz
+		}
+	}
+
+	// read a byte more than the maximum to tell a body of the maximum size from a larger one
+	if gqlInfo.maxResponseBytes > 0 {
+		r = io.LimitReader(r, gqlInfo.maxResponseBytes+1)
+	}
+
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return body, fmt.Errorf(": %w", err)
+	}
+	if gqlInfo.maxResponseBytes > 0 && int64(len(body)) > gqlInfo.maxResponseBytes {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrResponseTooLarge, gqlInfo.maxResponseBytes)
+	}
+
+	if counter != nil {
+		gqlInfo.bodySizes(BodySizes{
+			OperationName: gqlInfo.Request.OperationName,
+			Request:       req.ContentLength,
+			Response:      counter.n,
+		})
+	}
+
+	return body, nil
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+
+	return n, err
+}
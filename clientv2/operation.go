@@ -0,0 +1,34 @@
+package clientv2
+
+import "context"
+
+// Operation is the metadata of a generated operation, given to the interceptors by the context of its requests,
+// for middleware, caching or logging to tell the operations apart without matching their queries.
+type Operation struct {
+	// Name is the name of the operation, like GetUser.
+	Name string
+	// Type is the type of the operation, one of query, mutation or subscription.
+	Type string
+	// QueryHash is the hex SHA-256 hash of the document of the operation, as sent,
+	// the hash of the automatic persisted queries.
+	QueryHash string
+	// DocumentID is the id of the document in the operation registry of the server, sent instead of the document
+	// by WithDocumentMode(RegisteredID), empty if not registered.
+	DocumentID string
+}
+
+type operationKey struct{}
+
+// ContextWithOperation returns a copy of ctx carrying the metadata of the operation of the requests sent with it,
+// as done by the generated operation methods.
+func ContextWithOperation(ctx context.Context, operation Operation) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// OperationFromContext returns the metadata of the operation carried by ctx and whether it carries one,
+// see ContextWithOperation.
+func OperationFromContext(ctx context.Context) (Operation, bool) {
+	operation, ok := ctx.Value(operationKey{}).(Operation)
+
+	return operation, ok
+}
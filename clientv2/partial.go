@@ -0,0 +1,121 @@
+package clientv2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/pleclech/gqlgenc/graphqljson"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// ErrorPolicy is how the responses having graphql errors are handled, as the error policies of Apollo.
+type ErrorPolicy int
+
+const (
+	// ErrorPolicyNone returns the graphql errors and discards the data of the response, the default.
+	ErrorPolicyNone ErrorPolicy = iota
+	// ErrorPolicyAll decodes the data of the response and returns the graphql errors, as WithPartialData.
+	ErrorPolicyAll
+	// ErrorPolicyIgnore decodes the data of the response and drops the graphql errors, Post returning no error for them.
+	// The network and decoding errors are still returned.
+	ErrorPolicyIgnore
+)
+
+// WithErrorPolicy returns an interceptor handling the responses having graphql errors as policy tells,
+// for the requests of a client when given to NewClient. The data of a response is decoded only when it is not null.
+func WithErrorPolicy(policy ErrorPolicy) RequestInterceptor {
+	return func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		gqlInfo.errorPolicy = policy
+
+		return next(ctx, req, gqlInfo, res)
+	}
+}
+
+// WithPartialData returns an interceptor decoding into the response the data of the responses having graphql errors,
+// the fields resolved by a server despite the errors of others. Post still returns the errors, see GraphQLErrors.
+// It is WithErrorPolicy(ErrorPolicyAll).
+func WithPartialData() RequestInterceptor {
+	return WithErrorPolicy(ErrorPolicyAll)
+}
+
+// applyErrorPolicy returns err, the error of a decoded response, as policy tells.
+func applyErrorPolicy(err error, policy ErrorPolicy) error {
+	if policy != ErrorPolicyIgnore {
+		return err
+	}
+	if _, other := GraphQLErrors(err); other != nil {
+		return other
+	}
+
+	return nil
+}
+
+// GraphQLErrors splits an error returned by Post into the graphql errors of a response
+// and the other errors, like network or decoding errors.
+func GraphQLErrors(err error) (gqlerror.List, error) {
+	var errResponse *ErrorResponse
+	if errors.As(err, &errResponse) && errResponse.NetworkError == nil && errResponse.GqlErrors != nil {
+		return *errResponse.GqlErrors, nil
+	}
+
+	return nil, err
+}
+
+// ErrorsAt returns the errors whose path starts with path, made of field names and list indexes,
+// like ErrorsAt(errs, "user", "friends", 1) for the errors of the second friend of the user.
+func ErrorsAt(errs gqlerror.List, path ...interface{}) gqlerror.List {
+	var found gqlerror.List
+	for _, err := range errs {
+		if hasPathPrefix(err.Path, path) {
+			found = append(found, err)
+		}
+	}
+
+	return found
+}
+
+// hasPathPrefix reports whether path starts with the field names and list indexes of prefix.
+func hasPathPrefix(path ast.Path, prefix []interface{}) bool {
+	if len(path) < len(prefix) {
+		return false
+	}
+
+	for i, element := range prefix {
+		switch element := element.(type) {
+		case string:
+			if path[i] != ast.PathName(element) {
+				return false
+			}
+		case int:
+			if path[i] != ast.PathIndex(element) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// unmarshalPartialData decodes into res the data of a response body having graphql errors.
+// The errors and the bodies which are not valid responses are reported by parseResponse.
+func unmarshalPartialData(body []byte, res interface{}, options ...graphqljson.Option) error {
+	var resp response
+	if err := json.Unmarshal(body, &resp); err != nil || len(resp.Errors) == 0 {
+		return nil
+	}
+	if len(resp.Data) == 0 || string(resp.Data) == "null" {
+		return nil
+	}
+
+	if err := unmarshalData(resp.Data, res, options...); err != nil {
+		return fmt.Errorf("failed to decode partial data into response %s: %w", string(body), err)
+	}
+
+	return nil
+}
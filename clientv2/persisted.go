@@ -0,0 +1,76 @@
+package clientv2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// DocumentMode is what the requests of the generated operations send for their document.
+type DocumentMode int
+
+const (
+	// FullDocument sends the text of the document, the default.
+	FullDocument DocumentMode = iota
+	// PersistedHash sends the SHA-256 hash of the document, Operation.QueryHash, in the persistedQuery extension
+	// of the automatic persisted queries instead of its text, for the servers knowing the document by its hash.
+	PersistedHash
+	// RegisteredID sends the id of the document registered in the operation registry of the server, Operation.DocumentID,
+	// as the documentId of the trusted documents instead of its text, for the servers allowing the registered documents only.
+	RegisteredID
+)
+
+// WithDocumentMode returns an interceptor sending the documents of the generated operations as mode tells,
+// their text omitted from the request but for FullDocument, which makes the payloads smaller and the servers
+// knowing the documents in advance run no other query. The document is identified by the Operation carried
+// by the context of the request, see ContextWithOperation: the requests without one, like RawExecute or Ping,
+// and the subscriptions send their text. The text stays in gqlInfo.Request for the interceptors,
+// and there is no fallback to the text for a server not knowing the document, the request failing as the server answers.
+func WithDocumentMode(mode DocumentMode) RequestInterceptor {
+	return func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		gqlInfo.documentMode = mode
+
+		return next(ctx, req, gqlInfo, res)
+	}
+}
+
+// persistDocument sets the body of req to the request sent without the text of the document when the document mode
+// of the request tells so, encoded by marshal.
+func persistDocument(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, marshal func(v interface{}) ([]byte, error)) error {
+	gqlInfo.persisted = nil
+	operation, ok := OperationFromContext(ctx)
+	if gqlInfo.documentMode == FullDocument || !ok {
+		return nil
+	}
+
+	persisted := *gqlInfo.Request
+	persisted.Query = ""
+	switch gqlInfo.documentMode {
+	case PersistedHash:
+		if operation.QueryHash == "" {
+			return fmt.Errorf("no persisted query hash for the operation %s", operation.Name)
+		}
+		persisted.Extensions = map[string]interface{}{
+			"persistedQuery": map[string]interface{}{
+				"version":    1,
+				"sha256Hash": operation.QueryHash,
+			},
+		}
+	case RegisteredID:
+		if operation.DocumentID == "" {
+			return fmt.Errorf("no registered document id for the operation %s", operation.Name)
+		}
+		persisted.DocumentID = operation.DocumentID
+	default:
+		return fmt.Errorf("unknown document mode %d", gqlInfo.documentMode)
+	}
+
+	body, err := marshal(&persisted)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+	setBody(req, body)
+	gqlInfo.persisted = &persisted
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package clientv2
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultPingQuery is the probe query of Ping, answered by every server whatever its schema.
+const DefaultPingQuery = "{ __typename }"
+
+// Ping runs the probe query, DefaultPingQuery if empty, to check that the server is reachable
+// and accepts the credentials set by the interceptors, and returns the latency of the round trip.
+// The data of the probe is not decoded, an http error, like an *HTTPError of code 401, or graphql errors fail the ping.
+func (c *Client) Ping(ctx context.Context, query string, interceptors ...RequestInterceptor) (time.Duration, error) {
+	if query == "" {
+		query = DefaultPingQuery
+	}
+
+	start := time.Now()
+	if err := c.Post(ctx, "", query, ignoredData{}, nil, interceptors...); err != nil {
+		return 0, fmt.Errorf("ping failed: %w", err)
+	}
+
+	return time.Since(start), nil
+}
@@ -0,0 +1,16 @@
+package clientv2
+
+import (
+	"encoding/json"
+)
+
+// RawData is the data of a response left undecoded, the exact bytes of its data key, decoded into by Post
+// for the callers forwarding or post-processing the payload, like proxies.
+type RawData json.RawMessage
+
+// UnmarshalGraphQLResponse keeps a copy of data, see graphqljson.ResponseUnmarshaler.
+func (d *RawData) UnmarshalGraphQLResponse(data json.RawMessage) error {
+	*d = append((*d)[:0], data...)
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package clientv2
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// WithQueryRewriter returns an interceptor replacing the query sent by the one returned by rewrite,
+// given the operation name and the query, like to add a directive or to append a fragment at runtime.
+// The query is rewritten once per call of Post, right before sending the first attempt, and the next
+// interceptors see the rewritten query in gqlInfo.Request once it is sent.
+// The rewritten query bypasses the validation of the generation: it must still be valid for the server,
+// and the fields it adds must be in the type the response is decoded into, the decoder failing on unknown fields.
+func WithQueryRewriter(rewrite func(operationName, query string) string) RequestInterceptor {
+	return func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		gqlInfo.queryRewriter = rewrite
+
+		return next(ctx, req, gqlInfo, res)
+	}
+}
+
+// rewriteQuery rewrites the query of the request on the first attempt and sets the body of req encoded by marshal.
+func rewriteQuery(req *http.Request, gqlInfo *GQLRequestInfo, marshal func(v interface{}) ([]byte, error)) error {
+	if gqlInfo.queryRewriter == nil {
+		return nil
+	}
+
+	if !gqlInfo.queryRewritten {
+		gqlInfo.Request.Query = gqlInfo.queryRewriter(gqlInfo.Request.OperationName, gqlInfo.Request.Query)
+		gqlInfo.queryRewritten = true
+	}
+
+	body, err := marshal(gqlInfo.Request)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+	setBody(req, body)
+
+	return nil
+}
+
+// setBody sets the body of req, rewound by its GetBody.
+func setBody(req *http.Request, body []byte) {
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.ContentLength = int64(len(body))
+}
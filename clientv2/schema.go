@@ -0,0 +1,65 @@
+package clientv2
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pleclech/gqlgenc/introspection"
+	"github.com/vektah/gqlparser/v2/validator"
+)
+
+// SchemaDriftError is the error of CheckSchemaHash when the schema of the server
+// is not the one the client was generated from.
+type SchemaDriftError struct {
+	// Generated is the hash of the schema the client was generated from.
+	Generated string
+	// Server is the hash of the current schema of the server.
+	Server string
+}
+
+func (e *SchemaDriftError) Error() string {
+	return fmt.Sprintf("schema drift: client generated from schema %s, server schema is %s", e.Generated, e.Server)
+}
+
+// SchemaHash fetches the schema of the server by introspection and returns its hash, see introspection.SchemaHash.
+func (c *Client) SchemaHash(ctx context.Context, interceptors ...RequestInterceptor) (string, error) {
+	var res introspection.Query
+	if err := c.Post(ctx, "Query", introspection.Introspection, &res, nil, interceptors...); err != nil {
+		return "", fmt.Errorf("introspection query failed: %w", err)
+	}
+
+	schema, err := validator.ValidateSchemaDocument(introspection.ParseIntrospectionQuery(c.BaseURL, res))
+	if err != nil {
+		return "", fmt.Errorf("validation error: %w", err)
+	}
+
+	return introspection.SchemaHash(schema), nil
+}
+
+// CheckSchemaHash compares hash, the hash of the schema the client was generated from,
+// with the hash of the current schema of the server, to catch a client deployed against another schema.
+// A drift, as a *SchemaDriftError, or a failed introspection is returned when report is nil,
+// else it is passed to report, like a logger, and nil returned for the check not to be fatal.
+func (c *Client) CheckSchemaHash(ctx context.Context, hash string, report func(err error), interceptors ...RequestInterceptor) error {
+	err := c.checkSchemaHash(ctx, hash, interceptors...)
+	if err != nil && report != nil {
+		report(err)
+
+		return nil
+	}
+
+	return err
+}
+
+func (c *Client) checkSchemaHash(ctx context.Context, hash string, interceptors ...RequestInterceptor) error {
+	serverHash, err := c.SchemaHash(ctx, interceptors...)
+	if err != nil {
+		return fmt.Errorf("schema check failed: %w", err)
+	}
+
+	if serverHash != hash {
+		return &SchemaDriftError{Generated: hash, Server: serverHash}
+	}
+
+	return nil
+}
@@ -0,0 +1,207 @@
+package clientv2
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Subscription is a subscription started by Subscribe, receiving its events over the body of a single response.
+type Subscription struct {
+	ctx     context.Context
+	resp    *http.Response
+	events  *bufio.Reader
+	gqlInfo *GQLRequestInfo
+
+	// ws is the WebSocket of the subscriptions of WithWebSocket, stop stops closing it along the context of the subscription.
+	ws   *webSocket
+	stop func() bool
+}
+
+// Subscribe starts a subscription on the graphql endpoint with the given query, over server-sent events
+// in the distinct connections mode of the GraphQL over SSE protocol, https://github.com/enisdenjo/graphql-sse/blob/master/PROTOCOL.md.
+// The interceptors run on the request starting the subscription, their res is nil, and the decoder options
+// and the partial data they set apply to each event. The subscription ends with ctx, or by closing it.
+// WithWebSocket makes it receive the events over a WebSocket instead.
+func (c *Client) Subscribe(ctx context.Context, operationName, query string, vars map[string]interface{}, interceptors ...RequestInterceptor) (*Subscription, error) {
+	r := &Request{
+		Query:         query,
+		Variables:     vars,
+		OperationName: operationName,
+	}
+	gqlInfo := NewGQLRequestInfo(r)
+
+	requestBody, err := c.marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("encode: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("create request struct failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Accept", "text/event-stream")
+
+	sub := &Subscription{ctx: ctx}
+	f := ChainInterceptor(append([]RequestInterceptor{c.RequestInterceptor}, interceptors...)...)
+	if err := f(ctx, req, gqlInfo, nil, func(_ context.Context, req *http.Request, gqlInfo *GQLRequestInfo, _ interface{}) error {
+		return c.subscribe(req, gqlInfo, sub)
+	}); err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// subscribe sends the request starting the subscription sub, failing when the server does not stream its events.
+func (c *Client) subscribe(req *http.Request, gqlInfo *GQLRequestInfo, sub *Subscription) error {
+	if err := rewriteQuery(req, gqlInfo, c.marshal); err != nil {
+		return err
+	}
+	if gqlInfo.webSocket != nil {
+		return c.subscribeWebSocket(req, gqlInfo, sub)
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if resp.StatusCode < 200 || 299 < resp.StatusCode || mediaType != "text/event-stream" {
+		defer resp.Body.Close()
+
+		// a subscription rejected by the server is answered like a query
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read response body: %w", err)
+		}
+		if err := parseResponse(body, resp.StatusCode, ignoredData{}, gqlInfo.decoderOptions...); err != nil {
+			return err
+		}
+
+		return fmt.Errorf("unexpected response content type %q", resp.Header.Get("Content-Type"))
+	}
+
+	sub.resp = resp
+	sub.events = bufio.NewReader(resp.Body)
+	sub.gqlInfo = gqlInfo
+
+	return nil
+}
+
+// Next waits for the next event of the subscription and decodes its payload into res as Post decodes a response,
+// the graphql errors of the event being returned as an *ErrorResponse, see GraphQLErrors.
+// It returns io.EOF when the server completes the subscription, and the error of the context of the subscription once it is done.
+func (s *Subscription) Next(res interface{}) error {
+	if s.ws != nil {
+		return s.nextWebSocket(res)
+	}
+
+	for {
+		event, data, err := s.readEvent()
+		if err != nil {
+			if ctxErr := s.ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if err == io.EOF {
+				// the connection ended without completing the subscription
+				err = io.ErrUnexpectedEOF
+			}
+
+			return fmt.Errorf("failed to read subscription event: %w", err)
+		}
+
+		switch event {
+		case "next", "message":
+			return s.decode(data, res)
+		case "complete":
+			return io.EOF
+		}
+		// the events of other types are ignored
+	}
+}
+
+// decode decodes the payload data of an event into res.
+func (s *Subscription) decode(data []byte, res interface{}) error {
+	data, err := stripComments(data, s.gqlInfo)
+	if err != nil {
+		return fmt.Errorf("failed to read subscription event: %w", err)
+	}
+
+	return timeDecode(data, s.gqlInfo, func() error {
+		if s.gqlInfo.errorPolicy != ErrorPolicyNone {
+			if err := unmarshalPartialData(data, res, s.gqlInfo.decoderOptions...); err != nil {
+				return err
+			}
+		}
+
+		return applyErrorPolicy(parseResponse(data, http.StatusOK, res, s.gqlInfo.decoderOptions...), s.gqlInfo.errorPolicy)
+	})
+}
+
+// readEvent reads the next server-sent event and returns its type, "message" if not set, and its data.
+func (s *Subscription) readEvent() (string, []byte, error) {
+	var event string
+	var data [][]byte
+	for {
+		line, err := s.events.ReadBytes('\n')
+		if err != nil {
+			return "", nil, err
+		}
+		line = bytes.TrimRight(line, "\r\n")
+
+		if len(line) == 0 {
+			// a blank line dispatches the event, those without data are not dispatched but the completion
+			if data == nil && event != "complete" {
+				event = ""
+
+				continue
+			}
+			if event == "" {
+				event = "message"
+			}
+
+			return event, bytes.Join(data, []byte("\n")), nil
+		}
+
+		field, value := string(line), ""
+		if i := strings.IndexByte(field, ':'); i != -1 {
+			field, value = field[:i], strings.TrimPrefix(field[i+1:], " ")
+		}
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data = append(data, []byte(value))
+		}
+		// comments, keeping the connection alive, and the other fields are ignored
+	}
+}
+
+// ignoredData is the data of a response which is not decoded, like the one of a rejected subscription.
+type ignoredData struct{}
+
+func (ignoredData) UnmarshalGraphQLResponse(json.RawMessage) error {
+	return nil
+}
+
+// Close ends the subscription, closing its connection.
+func (s *Subscription) Close() error {
+	if s.ws != nil {
+		return s.closeWebSocket()
+	}
+	if err := s.resp.Body.Close(); err != nil {
+		return fmt.Errorf("close subscription: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package clientv2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ApolloTracing is the tracing response extension of the Apollo tracing format,
+// https://github.com/apollographql/apollo-tracing. The offsets are from StartTime.
+type ApolloTracing struct {
+	// OperationName is the name of the operation of the request, not part of the extension.
+	OperationName string `json:"-"`
+
+	Version    int                 `json:"version"`
+	StartTime  time.Time           `json:"startTime"`
+	EndTime    time.Time           `json:"endTime"`
+	Duration   time.Duration       `json:"duration"`
+	Parsing    ApolloTracingPhase  `json:"parsing"`
+	Validation ApolloTracingPhase  `json:"validation"`
+	Execution  ApolloTracingResult `json:"execution"`
+}
+
+// ApolloTracingPhase is the timing of the parsing or the validation of the request.
+type ApolloTracingPhase struct {
+	StartOffset time.Duration `json:"startOffset"`
+	Duration    time.Duration `json:"duration"`
+}
+
+// ApolloTracingResult is the timing of the execution of the request, by resolver.
+type ApolloTracingResult struct {
+	Resolvers []*ApolloTracingResolver `json:"resolvers"`
+}
+
+// ApolloTracingResolver is the timing of a resolver.
+type ApolloTracingResolver struct {
+	// Path is the response path of the field, its elements are field names and list indexes.
+	Path        []interface{} `json:"path"`
+	ParentType  string        `json:"parentType"`
+	FieldName   string        `json:"fieldName"`
+	ReturnType  string        `json:"returnType"`
+	StartOffset time.Duration `json:"startOffset"`
+	Duration    time.Duration `json:"duration"`
+}
+
+// PathString returns the path of the field, like "hero.friends.1.name".
+func (r *ApolloTracingResolver) PathString() string {
+	var path string
+	for i, element := range r.Path {
+		if i > 0 {
+			path += "."
+		}
+		path += fmt.Sprint(element)
+	}
+
+	return path
+}
+
+// WithTracingCollector returns an interceptor calling collect with the Apollo tracing extension of each response having one.
+func WithTracingCollector(collect func(t ApolloTracing)) RequestInterceptor {
+	return func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		gqlInfo.tracingCollector = collect
+
+		return next(ctx, req, gqlInfo, res)
+	}
+}
+
+// ParseApolloTracing returns the Apollo tracing extension of a response body, nil if it has none.
+func ParseApolloTracing(body []byte) (*ApolloTracing, error) {
+	var resp struct {
+		Extensions struct {
+			Tracing *ApolloTracing `json:"tracing"`
+		} `json:"extensions"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode tracing extension: %w", err)
+	}
+
+	return resp.Extensions.Tracing, nil
+}
+
+// collectTracing calls the tracing collector of the request with the tracing extension of the response body.
+// A response without a valid tracing extension is not collected, the decoding of the response reports an invalid body.
+func collectTracing(body []byte, gqlInfo *GQLRequestInfo) {
+	if gqlInfo.tracingCollector == nil {
+		return
+	}
+
+	tracing, err := ParseApolloTracing(body)
+	if err != nil || tracing == nil {
+		return
+	}
+	tracing.OperationName = gqlInfo.Request.OperationName
+
+	gqlInfo.tracingCollector(*tracing)
+}
@@ -0,0 +1,166 @@
+package clientv2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// FieldTransform transforms the value of a response field before it is decoded.
+// It gets the content of a JSON string value, or the JSON of any other value,
+// and returns the JSON of the value to decode.
+type FieldTransform func(value []byte) ([]byte, error)
+
+// WithFieldTransform returns an interceptor registering fn as the field transform called name.
+//
+// The server lists the fields to transform in the extensions of the response,
+// with the path of each field and the name of its transform:
+//
+//	"extensions": {"fieldTransforms": [{"path": ["user", "avatar"], "name": "gzip"}]}
+func WithFieldTransform(name string, fn func([]byte) ([]byte, error)) RequestInterceptor {
+	return func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		if gqlInfo.fieldTransforms == nil {
+			gqlInfo.fieldTransforms = make(map[string]FieldTransform)
+		}
+		gqlInfo.fieldTransforms[name] = fn
+
+		return next(ctx, req, gqlInfo, res)
+	}
+}
+
+// fieldTransformsExtension is the response extension listing the fields to transform.
+type fieldTransformsExtension struct {
+	FieldTransforms []struct {
+		Path []interface{} `json:"path"`
+		Name string        `json:"name"`
+	} `json:"fieldTransforms"`
+}
+
+// transformFields applies the field transforms listed in the extensions of the response body to its data.
+func transformFields(body []byte, transforms map[string]FieldTransform) ([]byte, error) {
+	if len(transforms) == 0 {
+		return body, nil
+	}
+
+	var resp map[string]json.RawMessage
+	if err := json.Unmarshal(body, &resp); err != nil {
+		// not a GraphQL response, let the response parsing report it
+		return body, nil
+	}
+
+	var extension fieldTransformsExtension
+	if extensions, ok := resp["extensions"]; !ok || json.Unmarshal(extensions, &extension) != nil || len(extension.FieldTransforms) == 0 {
+		return body, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(resp["data"]))
+	decoder.UseNumber()
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode data to transform: %w", err)
+	}
+
+	for _, fieldTransform := range extension.FieldTransforms {
+		transform, ok := transforms[fieldTransform.Name]
+		if !ok {
+			return nil, fmt.Errorf("no field transform %q registered for %v", fieldTransform.Name, fieldTransform.Path)
+		}
+
+		if err := transformField(data, fieldTransform.Path, transform); err != nil {
+			return nil, fmt.Errorf("field transform %q of %v failed: %w", fieldTransform.Name, fieldTransform.Path, err)
+		}
+	}
+
+	transformed, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode transformed data: %w", err)
+	}
+	resp["data"] = transformed
+
+	return json.Marshal(resp)
+}
+
+// transformField replaces the value at path in data by its transform.
+func transformField(data interface{}, path []interface{}, transform FieldTransform) error {
+	if len(path) == 0 {
+		return errors.New("empty path")
+	}
+
+	parent := data
+	for _, element := range path[:len(path)-1] {
+		child, err := pathChild(parent, element)
+		if err != nil {
+			return err
+		}
+		parent = child
+	}
+
+	value, err := pathChild(parent, path[len(path)-1])
+	if err != nil {
+		return err
+	}
+
+	var raw []byte
+	if s, ok := value.(string); ok {
+		raw = []byte(s)
+	} else if raw, err = json.Marshal(value); err != nil {
+		return fmt.Errorf("failed to encode value: %w", err)
+	}
+
+	transformed, err := transform(raw)
+	if err != nil {
+		return err
+	}
+	if !json.Valid(transformed) {
+		return errors.New("transformed value is not valid JSON")
+	}
+
+	switch parent := parent.(type) {
+	case map[string]interface{}:
+		parent[path[len(path)-1].(string)] = json.RawMessage(transformed)
+	case []interface{}:
+		index, _ := pathIndex(path[len(path)-1])
+		parent[index] = json.RawMessage(transformed)
+	}
+
+	return nil
+}
+
+// pathChild returns the value of the object key or array index element of parent.
+func pathChild(parent, element interface{}) (interface{}, error) {
+	switch parent := parent.(type) {
+	case map[string]interface{}:
+		key, ok := element.(string)
+		if !ok {
+			return nil, fmt.Errorf("%v is not an object key", element)
+		}
+		child, ok := parent[key]
+		if !ok {
+			return nil, fmt.Errorf("no field %q", key)
+		}
+
+		return child, nil
+	case []interface{}:
+		index, ok := pathIndex(element)
+		if !ok || index < 0 || index >= len(parent) {
+			return nil, fmt.Errorf("%v is not an index of a list of %d elements", element, len(parent))
+		}
+
+		return parent[index], nil
+	default:
+		return nil, fmt.Errorf("no field %v in a scalar value", element)
+	}
+}
+
+// pathIndex returns the list index of a path element decoded from JSON.
+func pathIndex(element interface{}) (int, bool) {
+	n, ok := element.(float64)
+	if !ok || n != float64(int(n)) {
+		return 0, false
+	}
+
+	return int(n), true
+}
@@ -0,0 +1,61 @@
+package clientv2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// ResponseUnwrapper returns the data and the graphql errors of a response body, nil when absent,
+// wherever the platform serving the response nests them.
+type ResponseUnwrapper func(raw json.RawMessage) (data json.RawMessage, errs json.RawMessage, err error)
+
+// WithResponseUnwrapper returns an interceptor extracting the data and the graphql errors of the responses with unwrap
+// before they are decoded, for gateways wrapping them in their own envelope, like {"result": {"payload": {...}, "problems": [...]}}.
+// Without unwrapper the responses are the standard ones, unwrapped by StandardResponseUnwrapper.
+// The options reading the body before it is decoded, like the envelope extractor, the tracing collector or the field transforms,
+// get it as sent. An unwrap error fails the request but for the responses of an error status, reported as such.
+func WithResponseUnwrapper(unwrap ResponseUnwrapper) RequestInterceptor {
+	return func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		gqlInfo.responseUnwrapper = unwrap
+
+		return next(ctx, req, gqlInfo, res)
+	}
+}
+
+// StandardResponseUnwrapper returns the data and errors of a standard response, {"data": ..., "errors": ...}.
+func StandardResponseUnwrapper(raw json.RawMessage) (json.RawMessage, json.RawMessage, error) {
+	var resp response
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, nil, err
+	}
+
+	return resp.Data, resp.Errors, nil
+}
+
+// unwrapResponse returns the standard response of the data and errors extracted from body by the unwrapper of the request,
+// body as is without unwrapper.
+func unwrapResponse(body []byte, gqlInfo *GQLRequestInfo) ([]byte, error) {
+	if gqlInfo.responseUnwrapper == nil {
+		return body, nil
+	}
+
+	data, errs, err := gqlInfo.responseUnwrapper(body)
+	if err != nil {
+		return body, err
+	}
+	resp := make(map[string]json.RawMessage, 2)
+	if data != nil {
+		resp["data"] = data
+	}
+	if errs != nil {
+		resp["errors"] = errs
+	}
+	unwrapped, err := json.Marshal(resp)
+	if err != nil {
+		return body, fmt.Errorf("invalid unwrapped response: %w", err)
+	}
+
+	return unwrapped, nil
+}
@@ -0,0 +1,482 @@
+package clientv2
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// The subprotocols of GraphQL over WebSocket spoken by the subscriptions of WithWebSocket.
+const (
+	// GraphQLTransportWS is the protocol of graphql-ws, https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md.
+	GraphQLTransportWS = "graphql-transport-ws"
+	// GraphQLWS is the legacy protocol of subscriptions-transport-ws,
+	// https://github.com/apollographql/subscriptions-transport-ws/blob/master/PROTOCOL.md.
+	GraphQLWS = "graphql-ws"
+)
+
+// ErrConnectionRejected is returned by Subscribe when the server rejects the connection_init of a WebSocket subscription,
+// like for a wrong token in its payload.
+var ErrConnectionRejected = errors.New("websocket connection rejected")
+
+// WebSocketCloseError is the error of a WebSocket closed by the server, with the close code and reason it sent, like 4403 Forbidden.
+type WebSocketCloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *WebSocketCloseError) Error() string {
+	return fmt.Sprintf("websocket closed with code %d: %s", e.Code, e.Reason)
+}
+
+// webSocketConfig is the WebSocket transport of the subscriptions set by WithWebSocket.
+type webSocketConfig struct {
+	protocols   []string
+	initPayload map[string]interface{}
+}
+
+// WithWebSocket returns an interceptor making Subscribe receive the events over a WebSocket rather than server-sent events,
+// sending initPayload, like {"authToken": token}, in the connection_init message, for the servers authenticating there rather than by headers.
+// The subprotocols offered are protocols, in order of preference, GraphQLTransportWS then GraphQLWS when none are given,
+// the subscription speaking the one the server selects. The opening handshake is sent to the endpoint of the client,
+// with the headers of the request set by the interceptors. Post ignores it.
+func WithWebSocket(initPayload map[string]interface{}, protocols ...string) RequestInterceptor {
+	config := &webSocketConfig{initPayload: initPayload, protocols: protocols}
+	if len(protocols) == 0 {
+		config.protocols = []string{GraphQLTransportWS, GraphQLWS}
+	}
+
+	return func(ctx context.Context, req *http.Request, gqlInfo *GQLRequestInfo, res interface{}, next RequestInterceptorFunc) error {
+		for _, protocol := range config.protocols {
+			if protocol != GraphQLTransportWS && protocol != GraphQLWS {
+				return fmt.Errorf("unknown websocket subprotocol %q", protocol)
+			}
+		}
+		gqlInfo.webSocket = config
+
+		return next(ctx, req, gqlInfo, res)
+	}
+}
+
+// webSocketGUID is the GUID of the accept key of the opening handshake, see RFC 6455.
+const webSocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// maxWebSocketFrame is the size of the largest frame read, and of the largest message of continuation frames,
+// beyond which the subscription fails.
+const maxWebSocketFrame = 32 << 20
+
+// The opcodes of the WebSocket frames, the continuation frames being read along the frame they continue.
+const (
+	opText  = 0x1
+	opClose = 0x8
+	opPing  = 0x9
+	opPong  = 0xa
+)
+
+// webSocketMessage is a message of the GraphQL over WebSocket protocols.
+type webSocketMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// webSocket is the connection of a WebSocket, speaking the subprotocol protocol.
+type webSocket struct {
+	conn     io.ReadWriteCloser
+	reader   *bufio.Reader
+	protocol string
+	// client masks the frames sent, as the client of a WebSocket must.
+	client bool
+
+	// mu serializes the frames written, by Next and by Close.
+	mu sync.Mutex
+	// done is set once the subscription completed, failed or was closed, Close running along a blocked Next.
+	done atomic.Bool
+}
+
+// subscribeWebSocket opens the WebSocket of the subscription sub requested by req and starts the subscription,
+// once the server acknowledged the connection.
+func (c *Client) subscribeWebSocket(req *http.Request, gqlInfo *GQLRequestInfo, sub *Subscription) error {
+	payload, err := c.marshal(gqlInfo.Request)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+
+	ws, err := c.dialWebSocket(req, gqlInfo)
+	if err != nil {
+		return err
+	}
+	stop := context.AfterFunc(req.Context(), func() {
+		ws.conn.Close()
+	})
+	if err := ws.init(gqlInfo.webSocket.initPayload); err != nil {
+		stop()
+		ws.conn.Close()
+
+		return err
+	}
+	start := "subscribe"
+	if ws.protocol == GraphQLWS {
+		start = "start"
+	}
+	if err := ws.writeMessage(webSocketMessage{ID: "1", Type: start, Payload: payload}); err != nil {
+		stop()
+		ws.conn.Close()
+
+		return err
+	}
+
+	sub.ws = ws
+	sub.stop = stop
+	sub.gqlInfo = gqlInfo
+
+	return nil
+}
+
+// dialWebSocket sends the opening handshake of the WebSocket to the URL of req, with its headers, over the transport of the client.
+func (c *Client) dialWebSocket(req *http.Request, gqlInfo *GQLRequestInfo) (*webSocket, error) {
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, fmt.Errorf("websocket key: %w", err)
+	}
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+
+	upgrade, err := http.NewRequestWithContext(req.Context(), http.MethodGet, req.URL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request struct failed: %w", err)
+	}
+	upgrade.Header = req.Header.Clone()
+	upgrade.Header.Del("Content-Type")
+	upgrade.Header.Del("Accept")
+	upgrade.Header.Set("Connection", "Upgrade")
+	upgrade.Header.Set("Upgrade", "websocket")
+	upgrade.Header.Set("Sec-WebSocket-Version", "13")
+	upgrade.Header.Set("Sec-WebSocket-Key", key)
+	upgrade.Header.Set("Sec-WebSocket-Protocol", strings.Join(gqlInfo.webSocket.protocols, ", "))
+
+	resp, err := c.Client.Do(upgrade)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		defer resp.Body.Close()
+
+		// a handshake rejected by the server is answered like a query
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+		if err := parseResponse(body, resp.StatusCode, ignoredData{}, gqlInfo.decoderOptions...); err != nil {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("websocket handshake: unexpected status %s", resp.Status)
+	}
+
+	conn, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		resp.Body.Close()
+
+		return nil, errors.New("websocket handshake: the transport does not upgrade connections")
+	}
+	if !strings.EqualFold(resp.Header.Get("Upgrade"), "websocket") || resp.Header.Get("Sec-WebSocket-Accept") != webSocketAccept(key) {
+		conn.Close()
+
+		return nil, errors.New("websocket handshake: invalid upgrade response")
+	}
+	protocol := resp.Header.Get("Sec-WebSocket-Protocol")
+	for _, offered := range gqlInfo.webSocket.protocols {
+		if protocol == offered {
+			return &webSocket{conn: conn, reader: bufio.NewReader(conn), protocol: protocol, client: true}, nil
+		}
+	}
+	conn.Close()
+
+	return nil, fmt.Errorf("websocket handshake: the server selected the subprotocol %q, not one of %s", protocol, strings.Join(gqlInfo.webSocket.protocols, ", "))
+}
+
+// webSocketAccept returns the accept key of the opening handshake sent with key.
+func webSocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + webSocketGUID))
+
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// init sends the connection_init message with payload and waits for its connection_ack.
+func (ws *webSocket) init(payload map[string]interface{}) error {
+	init := webSocketMessage{Type: "connection_init"}
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("encode init payload: %w", err)
+		}
+		init.Payload = data
+	}
+	if err := ws.writeMessage(init); err != nil {
+		return err
+	}
+
+	for {
+		message, err := ws.readMessage()
+		if err != nil {
+			var closeErr *WebSocketCloseError
+			if errors.As(err, &closeErr) {
+				return fmt.Errorf("%w: %w", ErrConnectionRejected, err)
+			}
+
+			return fmt.Errorf("failed to read connection_ack: %w", err)
+		}
+
+		switch message.Type {
+		case "connection_ack":
+			return nil
+		case "connection_error":
+			return fmt.Errorf("%w: %s", ErrConnectionRejected, message.Payload)
+		case "ping":
+			if err := ws.writeMessage(webSocketMessage{Type: "pong"}); err != nil {
+				return err
+			}
+		case "ka", "pong":
+		default:
+			return fmt.Errorf("unexpected websocket message %q before connection_ack", message.Type)
+		}
+	}
+}
+
+// nextWebSocket waits for the next event of the subscription over its WebSocket and decodes its payload into res, see Subscription.Next.
+func (s *Subscription) nextWebSocket(res interface{}) error {
+	if s.ws.done.Load() {
+		return io.EOF
+	}
+
+	for {
+		message, err := s.ws.readMessage()
+		if err != nil {
+			if ctxErr := s.ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if err == io.EOF {
+				// the connection ended without completing the subscription
+				err = io.ErrUnexpectedEOF
+			}
+
+			return fmt.Errorf("failed to read subscription event: %w", err)
+		}
+
+		switch message.Type {
+		case "next", "data":
+			return s.decode(message.Payload, res)
+		case "error":
+			// the operation failed, with the graphql errors of the payload, a list but for the legacy protocol
+			s.ws.done.Store(true)
+			errs := message.Payload
+			if !strings.HasPrefix(strings.TrimSpace(string(errs)), "[") {
+				errs = append(append(json.RawMessage("["), errs...), ']')
+			}
+
+			return parseResponse(append(append([]byte(`{"errors":`), errs...), '}'), http.StatusOK, ignoredData{}, s.gqlInfo.decoderOptions...)
+		case "complete":
+			s.ws.done.Store(true)
+
+			return io.EOF
+		case "connection_error":
+			s.ws.done.Store(true)
+
+			return fmt.Errorf("%w: %s", ErrConnectionRejected, message.Payload)
+		case "ping":
+			if err := s.ws.writeMessage(webSocketMessage{Type: "pong"}); err != nil {
+				return fmt.Errorf("failed to read subscription event: %w", err)
+			}
+		}
+		// the keep alive and the messages of other types are ignored
+	}
+}
+
+// closeWebSocket stops the subscription, unless it is done, and closes its WebSocket.
+func (s *Subscription) closeWebSocket() error {
+	defer s.stop()
+
+	if s.ws.done.CompareAndSwap(false, true) {
+		if s.ws.protocol == GraphQLWS {
+			_ = s.ws.writeMessage(webSocketMessage{ID: "1", Type: "stop"})
+			_ = s.ws.writeMessage(webSocketMessage{Type: "connection_terminate"})
+		} else {
+			_ = s.ws.writeMessage(webSocketMessage{ID: "1", Type: "complete"})
+		}
+	}
+	// the close frame of the normal closure, the connection may be gone already
+	_ = s.ws.writeFrame(opClose, binary.BigEndian.AppendUint16(nil, 1000))
+
+	if err := s.ws.conn.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+		return fmt.Errorf("close subscription: %w", err)
+	}
+
+	return nil
+}
+
+// writeMessage sends message in a text frame.
+func (ws *webSocket) writeMessage(message webSocketMessage) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("encode websocket message: %w", err)
+	}
+
+	return ws.writeFrame(opText, data)
+}
+
+// readMessage reads the next message, answering the pings and failing with a *WebSocketCloseError on a close frame.
+func (ws *webSocket) readMessage() (*webSocketMessage, error) {
+	var data []byte
+	for {
+		fin, opcode, payload, err := ws.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case opPing:
+			if err := ws.writeFrame(opPong, payload); err != nil {
+				return nil, err
+			}
+
+			continue
+		case opPong:
+			continue
+		case opClose:
+			closeErr := &WebSocketCloseError{Code: 1005}
+			if len(payload) >= 2 {
+				closeErr.Code = int(binary.BigEndian.Uint16(payload))
+				closeErr.Reason = string(payload[2:])
+				payload = payload[:2]
+			}
+			// the close frame is echoed before the connection is closed
+			_ = ws.writeFrame(opClose, payload)
+
+			return nil, closeErr
+		}
+
+		if len(data)+len(payload) > maxWebSocketFrame {
+			return nil, fmt.Errorf("websocket message of more than %d bytes", maxWebSocketFrame)
+		}
+		data = append(data, payload...)
+		if !fin {
+			continue
+		}
+
+		var message webSocketMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			return nil, fmt.Errorf("decode websocket message: %w", err)
+		}
+
+		return &message, nil
+	}
+}
+
+// readFrame reads the next frame and returns its fin bit, its opcode and its payload, unmasked.
+func (ws *webSocket) readFrame() (bool, byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(ws.reader, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin, opcode := header[0]&0x80 != 0, header[0]&0x0f
+	masked, length := header[1]&0x80 != 0, uint64(header[1]&0x7f)
+
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(ws.reader, extended[:]); err != nil {
+			return false, 0, nil, unexpectedEOF(err)
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(ws.reader, extended[:]); err != nil {
+			return false, 0, nil, unexpectedEOF(err)
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+	if length > maxWebSocketFrame {
+		return false, 0, nil, fmt.Errorf("websocket frame of %d bytes, more than %d", length, maxWebSocketFrame)
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(ws.reader, mask[:]); err != nil {
+			return false, 0, nil, unexpectedEOF(err)
+		}
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(ws.reader, payload); err != nil {
+		return false, 0, nil, unexpectedEOF(err)
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+
+	return fin, opcode, payload, nil
+}
+
+// writeFrame sends a final frame of opcode and payload, masked by a client.
+func (ws *webSocket) writeFrame(opcode byte, payload []byte) error {
+	var maskBit byte
+	if ws.client {
+		maskBit = 0x80
+	}
+
+	frame := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, maskBit|byte(n))
+	case n <= 0xffff:
+		frame = binary.BigEndian.AppendUint16(append(frame, maskBit|126), uint16(n))
+	default:
+		frame = binary.BigEndian.AppendUint64(append(frame, maskBit|127), uint64(n))
+	}
+	start := len(frame)
+	if ws.client {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return fmt.Errorf("websocket mask: %w", err)
+		}
+		frame = append(frame, mask[:]...)
+		start += len(mask)
+		frame = append(frame, payload...)
+		for i := range payload {
+			frame[start+i] ^= mask[i%4]
+		}
+	} else {
+		frame = append(frame, payload...)
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if _, err := ws.conn.Write(frame); err != nil {
+		return fmt.Errorf("write websocket frame: %w", err)
+	}
+
+	return nil
+}
+
+// unexpectedEOF returns io.ErrUnexpectedEOF for an io.EOF in the middle of a frame, err otherwise.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
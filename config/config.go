@@ -9,10 +9,12 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/99designs/gqlgen/codegen/config"
-	"github.com/Yamashou/gqlgenc/client"
-	"github.com/Yamashou/gqlgenc/introspection"
+	"github.com/99designs/gqlgen/codegen/templates"
+	"github.com/pleclech/gqlgenc/client"
+	"github.com/pleclech/gqlgenc/introspection"
 	"github.com/vektah/gqlparser/v2"
 	"github.com/vektah/gqlparser/v2/ast"
 	"github.com/vektah/gqlparser/v2/validator"
@@ -134,6 +136,57 @@ func LoadConfig(filename string) (*Config, error) {
 		return nil, fmt.Errorf("neither 'schema' nor 'endpoint' specified. Use schema to load from a local file, use endpoint to load from a remote server (using introspection)")
 	}
 
+	if cfg.Generate != nil {
+		for name, timeScalar := range cfg.Generate.TimeScalars {
+			if _, err := timeScalar.Duration(); err != nil {
+				return nil, fmt.Errorf("generate.timeScalars.%s: %w", name, err)
+			}
+		}
+
+		for name, validatedScalar := range cfg.Generate.ValidatedScalars {
+			if _, err := validatedScalar.Regexp(); err != nil {
+				return nil, fmt.Errorf("generate.validatedScalars.%s: %w", name, err)
+			}
+			if !cfg.Model.IsDefined() {
+				return nil, fmt.Errorf("generate.validatedScalars.%s: the scalar is generated in the model package, model is required", name)
+			}
+			if cfg.Models.Exists(name) {
+				return nil, fmt.Errorf("generate.validatedScalars.%s: the scalar is mapped in models", name)
+			}
+		}
+
+		if cfg.Generate.InputRawExtras && !cfg.Model.IsDefined() {
+			return nil, fmt.Errorf("generate.inputRawExtras: the extras are encoded by the model package, model is required")
+		}
+		if cfg.Generate.ExhaustiveEnums && !cfg.Model.IsDefined() {
+			return nil, fmt.Errorf("generate.exhaustiveEnums: the enums are generated in the model package, model is required")
+		}
+
+		switch cfg.Generate.FieldNameCollision {
+		case "", FieldNameCollisionSuffix, FieldNameCollisionError:
+		default:
+			return nil, fmt.Errorf("generate.fieldNameCollision: unknown strategy %q, want %s or %s", cfg.Generate.FieldNameCollision, FieldNameCollisionSuffix, FieldNameCollisionError)
+		}
+
+		for name, mutations := range cfg.Generate.MutationBatches {
+			if len(mutations) == 0 {
+				return nil, fmt.Errorf("generate.mutationBatches.%s: no mutations", name)
+			}
+		}
+
+		for name, contextValue := range cfg.Generate.ContextValues {
+			if err := contextValue.Check(); err != nil {
+				return nil, fmt.Errorf("generate.contextValues.%s: %w", name, err)
+			}
+		}
+
+		switch cfg.Generate.DocumentMode {
+		case "", DocumentModeText, DocumentModeHash, DocumentModeID:
+		default:
+			return nil, fmt.Errorf("generate.documentMode: unknown mode %q, want %s, %s or %s", cfg.Generate.DocumentMode, DocumentModeText, DocumentModeHash, DocumentModeID)
+		}
+	}
+
 	// https://github.com/99designs/gqlgen/blob/3a31a752df764738b1f6e99408df3b169d514784/codegen/config/config.go#L120
 	for _, f := range cfg.SchemaFilename {
 		var matches []string
@@ -181,6 +234,16 @@ func LoadConfig(filename string) (*Config, error) {
 	if cfg.Models != nil {
 		models = cfg.Models
 	}
+	if cfg.Generate != nil {
+		for _, name := range cfg.Generate.Int64Scalars {
+			if !models.Exists(name) {
+				models.Add(name, Int64Model)
+			}
+		}
+		for name := range cfg.Generate.ValidatedScalars {
+			models.Add(name, cfg.Model.ImportPath()+"."+templates.ToGo(name))
+		}
+	}
 
 	sources := []*ast.Source{}
 
@@ -209,9 +272,50 @@ func LoadConfig(filename string) (*Config, error) {
 		return nil, fmt.Errorf("config.exec: %w", err)
 	}
 
+	if err := checkModelPackage(cfg.Model, cfg.Client, cfg.Models); err != nil {
+		return nil, fmt.Errorf("config.model: %w", err)
+	}
+
 	return &cfg, nil
 }
 
+// checkModelPackage checks the models can be generated in the model package, shared by clients
+// or separate from the client package importing it.
+// The models of the schema cannot be generated in the file of the client, in another package of its directory,
+// or in another package than the client while some types are models of the client package, as it imports the models.
+func checkModelPackage(model, client config.PackageConfig, models config.TypeMap) error {
+	if !model.IsDefined() {
+		return nil
+	}
+
+	if err := model.Check(); err != nil {
+		return err
+	}
+
+	if model.Filename == client.Filename {
+		return fmt.Errorf("model and client must be different files, got %s", model.Filename)
+	}
+
+	if model.Dir() == client.Dir() {
+		if model.Package != client.Package {
+			return fmt.Errorf("model and client in the same directory must have the same package, got %s and %s", model.Package, client.Package)
+		}
+
+		return nil
+	}
+
+	clientPath := client.ImportPath()
+	for name, typ := range models {
+		for _, m := range typ.Model {
+			if i := strings.LastIndex(m, "."); i != -1 && m[:i] == clientPath {
+				return fmt.Errorf("models.%s: %s is in the client package, which imports the model package", name, m)
+			}
+		}
+	}
+
+	return nil
+}
+
 // LoadSchema load and parses the schema from a local file or a remote server
 func (c *Config) LoadSchema(ctx context.Context) error {
 	var schema *ast.Schema
@@ -282,6 +386,193 @@ type GenerateConfig struct {
 	Client        *bool         `yaml:"client,omitempty"`
 	// if true, used client v2 in generate code
 	ClientV2 bool `yaml:"clientV2,omitempty"`
+	// integer scalars decoded into time.Duration or time.Time by client v2, by scalar name
+	TimeScalars map[string]TimeScalarConfig `yaml:"timeScalars,omitempty"`
+	// 64-bit integer scalars, like BigInt or Long, sent as numbers or strings and decoded into int64 by client v2
+	Int64Scalars []string `yaml:"int64Scalars,omitempty"`
+	// string scalars, like Email or URL, generated in the model package as string types failing to marshal
+	// when they do not match their pattern, so invalid variables are not sent, by scalar name
+	ValidatedScalars map[string]ValidatedScalarConfig `yaml:"validatedScalars,omitempty"`
+	// how client v2 resolves struct fields having the same go name, one of suffix (default) or error
+	FieldNameCollision string `yaml:"fieldNameCollision,omitempty"`
+	// if true, client v2 checks the __typename of the objects of responses is the type of their fields
+	TypenameChecks bool `yaml:"typenameChecks,omitempty"`
+	// if true, client v2 generates for each operation a result type holding its data and the graphql errors of a partial response
+	OperationResults bool `yaml:"operationResults,omitempty"`
+	// if true, client v2 generates for each operation a method returning its undecoded data and the graphql errors of the response
+	RawMethods bool `yaml:"rawMethods,omitempty"`
+	// if true, client v2 generates for each operation having variables a struct of its variables, sent as returned by its Variables method
+	OperationVariables bool `yaml:"operationVariables,omitempty"`
+	// if true, client v2 generates for the fields of the generated types getters returning their zero value on nil structs
+	Getters bool `yaml:"getters,omitempty"`
+	// if true, client v2 generates for the generated types a Clone method returning a deep copy, see clientv2.Clone
+	Clone bool `yaml:"clone,omitempty"`
+	// if true, client v2 generates for the variables having a default value in their operation a constant of the value
+	VariableDefaults bool `yaml:"variableDefaults,omitempty"`
+	// if true, client v2 generates for subscriptions methods returning the channel of their events, received over server-sent events
+	SubscriptionChannels bool `yaml:"subscriptionChannels,omitempty"`
+	// if true, client v2 generates a client delegating the operations to the Execute method of an Executor interface,
+	// implemented by an existing graphql transport, instead of the transport of clientv2
+	Executor bool `yaml:"executor,omitempty"`
+	// the probe query of the generated Ping method of client v2, checking the server is reachable, { __typename } when unset
+	PingQuery string `yaml:"pingQuery,omitempty"`
+	// prefix and suffix of the names of the types generated by client v2, like Gen for GenGetUser,
+	// to not collide with the hand-written types of the client package
+	TypeNamePrefix string `yaml:"typeNamePrefix,omitempty"`
+	TypeNameSuffix string `yaml:"typeNameSuffix,omitempty"`
+	// if true, the generated models get graphql tags along their json tags, and the json tags of the models
+	// and of the types of client v2 have omitempty on the fields nullable in the schema only
+	JSONTags bool `yaml:"jsonTags,omitempty"`
+	// prefix of the environment variables read by the NewClientFromEnv constructor generated by client v2,
+	// like GITHUB for GITHUB_ENDPOINT, the constructor is not generated when unset
+	EnvPrefix string `yaml:"envPrefix,omitempty"`
+	// patterns of path.Match of the names of the operations generated by client v2 as methods of a service of the client,
+	// like users: ["*User*"] for client.Users.GetUser, by service name
+	Services map[string][]string `yaml:"services,omitempty"`
+	// if true, the generated input types implement the GraphQLInput interface generated along the models,
+	// for helpers accepting any input
+	InputInterface bool `yaml:"inputInterface,omitempty"`
+	// if true, the generated input types get a RawExtras field of JSON fields merged into the encoded input,
+	// like the fields the schema added since the generation, a field of the input in RawExtras failing the encoding
+	InputRawExtras bool `yaml:"inputRawExtras,omitempty"`
+	// if true, the generated enums get a Switch method taking a function per value, a value added to the schema failing the build
+	// of the switches until they handle it, its switch marked //exhaustive:enforce for the exhaustive linter
+	ExhaustiveEnums bool `yaml:"exhaustiveEnums,omitempty"`
+	// if true, client v2 generates for the selections of union fields an interface implemented by a struct per member of the union,
+	// the objects being decoded into the struct of their __typename, which the selections must select
+	UnionInterfaces bool `yaml:"unionInterfaces,omitempty"`
+	// lengths of the lists of fixed length, like Point.coordinates: 3, by type and field name, generated by client v2 as go arrays,
+	// like [3]float64 for [Float!]!, the decoding failing for a list of another length
+	FixedLists map[string]int `yaml:"fixedLists,omitempty"`
+	// what the generated client v2 sends for the documents of the operations, one of text (default), the text of the document,
+	// hash, its SHA-256 hash as an automatic persisted query, or id, the id of the document registered in the operation registry
+	// of the server, the trusted documents
+	DocumentMode string `yaml:"documentMode,omitempty"`
+	// ids of the documents registered in the operation registry, by operation name, sha256:<hash of the document> when unset
+	DocumentIDs map[string]string `yaml:"documentIds,omitempty"`
+	// mutations sent by client v2 in one document by a generated method of the batch returning their results, by batch name,
+	// like SaveProfile: [UpdateUser, CreatePost], their variables and root fields being prefixed with the name of their operation
+	MutationBatches map[string][]string `yaml:"mutationBatches,omitempty"`
+	// how the server is asked to run the mutations of a batch in a transaction
+	BatchTransaction *BatchTransactionConfig `yaml:"batchTransaction,omitempty"`
+	// values of the requests carried by their context, by value name, generated by client v2 as typed helpers,
+	// like WithAuthToken and AuthTokenFrom for AuthToken, and sent by the generated client as configured
+	ContextValues map[string]ContextValueConfig `yaml:"contextValues,omitempty"`
+	// header of the files generated with client v2, the client and the models, a text/template of the package name (.Package) and file name (.Filename)
+	// whose lines are comments, like a license, the "Code generated" comment when unset
+	Header string `yaml:"header,omitempty"`
+	// build constraint of the files generated with client v2, the client and the models, like integration && !windows, written in a //go:build line
+	BuildTags string `yaml:"buildTags,omitempty"`
+}
+
+const (
+	// FieldNameCollisionSuffix keeps the first field of a go name and appends 2, 3, ... to the next ones
+	FieldNameCollisionSuffix = "suffix"
+	// FieldNameCollisionError fails the generation when go names collide
+	FieldNameCollisionError = "error"
+)
+
+const (
+	// DocumentModeText sends the text of the documents
+	DocumentModeText = "text"
+	// DocumentModeHash sends the SHA-256 hash of the documents, the hash of the automatic persisted queries
+	DocumentModeHash = "hash"
+	// DocumentModeID sends the ids of the documents registered in the operation registry
+	DocumentModeID = "id"
+)
+
+// Int64Model is the model of the 64-bit integer scalars not mapped in models
+const Int64Model = "github.com/99designs/gqlgen/graphql.Int64"
+
+// TimeScalarConfig describes an integer scalar mapped to time.Duration or time.Time in models
+type TimeScalarConfig struct {
+	// unit of the integer, one of ns, us, ms or s
+	Unit string `yaml:"unit"`
+}
+
+// timeScalarUnits are the durations of the units of the time scalars and their go expressions
+var timeScalarUnits = map[string]struct {
+	duration time.Duration
+	expr     string
+}{
+	"ns": {time.Nanosecond, "time.Nanosecond"},
+	"us": {time.Microsecond, "time.Microsecond"},
+	"ms": {time.Millisecond, "time.Millisecond"},
+	"s":  {time.Second, "time.Second"},
+}
+
+// Duration returns the duration of one unit
+func (c TimeScalarConfig) Duration() (time.Duration, error) {
+	unit, ok := timeScalarUnits[c.Unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q, want one of ns, us, ms or s", c.Unit)
+	}
+
+	return unit.duration, nil
+}
+
+// UnitExpr returns the go expression of the duration of one unit, like time.Second
+func (c TimeScalarConfig) UnitExpr() (string, error) {
+	unit, ok := timeScalarUnits[c.Unit]
+	if !ok {
+		return "", fmt.Errorf("unknown unit %q, want one of ns, us, ms or s", c.Unit)
+	}
+
+	return unit.expr, nil
+}
+
+// BatchTransactionConfig describes the transaction of the mutations of a batch, as exposed by the server
+type BatchTransactionConfig struct {
+	// directive of the document of the batches, like transaction for mutation SaveProfile(...) @transaction
+	Directive string `yaml:"directive,omitempty"`
+	// headers of the requests of the batches, like X-Transaction: atomic
+	Headers map[string]string `yaml:"headers,omitempty"`
+}
+
+// ContextValueConfig describes how the generated client v2 sends a value of the context of the requests,
+// one of header, endpoint or idempotencyKey
+type ContextValueConfig struct {
+	// header carrying the value, like Authorization
+	Header string `yaml:"header,omitempty"`
+	// prefix of the value in the header, like "Bearer "
+	Prefix string `yaml:"prefix,omitempty"`
+	// if true, the value is the endpoint the requests are sent to, instead of the base URL of the client
+	Endpoint bool `yaml:"endpoint,omitempty"`
+	// if true, the value is the idempotency key of the mutations, see clientv2.WithIdempotencyKeys
+	IdempotencyKey bool `yaml:"idempotencyKey,omitempty"`
+}
+
+// Check returns an error unless the value is sent one way, the prefix being the one of a header
+func (c ContextValueConfig) Check() error {
+	uses := 0
+	for _, use := range []bool{c.Header != "", c.Endpoint, c.IdempotencyKey} {
+		if use {
+			uses++
+		}
+	}
+	if uses != 1 {
+		return fmt.Errorf("want one of header, endpoint or idempotencyKey")
+	}
+	if c.Prefix != "" && c.Header == "" {
+		return fmt.Errorf("prefix is the one of a header")
+	}
+
+	return nil
+}
+
+// ValidatedScalarConfig describes a string scalar generated with its validation
+type ValidatedScalarConfig struct {
+	// regular expression of regexp the values must match, like ^[^@\s]+@[^@\s]+$
+	Pattern string `yaml:"pattern"`
+}
+
+// Regexp returns the compiled pattern
+func (c ValidatedScalarConfig) Regexp() (*regexp.Regexp, error) {
+	if c.Pattern == "" {
+		return nil, fmt.Errorf("pattern is required")
+	}
+
+	return regexp.Compile(c.Pattern)
 }
 
 func (c *GenerateConfig) ShouldGenerateClient() bool {
@@ -296,6 +587,36 @@ func (c *GenerateConfig) ShouldGenerateClient() bool {
 	return true
 }
 
+// FieldNameCollisionStrategy returns the strategy resolving go field name collisions, suffix when unset
+func (c *GenerateConfig) FieldNameCollisionStrategy() string {
+	if c == nil || c.FieldNameCollision == "" {
+		return FieldNameCollisionSuffix
+	}
+
+	return c.FieldNameCollision
+}
+
+// DocumentID returns the id of the document of the operation in the operation registry, sha256:<hash> when not configured,
+// hash being the hex SHA-256 hash of the document
+func (c *GenerateConfig) DocumentID(operationName, hash string) string {
+	if c != nil {
+		if id, ok := c.DocumentIDs[operationName]; ok {
+			return id
+		}
+	}
+
+	return "sha256:" + hash
+}
+
+// TypeName returns the go name of a generated type, name with the configured prefix and suffix
+func (c *GenerateConfig) TypeName(name string) string {
+	if c == nil {
+		return name
+	}
+
+	return c.TypeNamePrefix + name + c.TypeNameSuffix
+}
+
 type NamingConfig struct {
 	Query    string `yaml:"query,omitempty"`
 	Mutation string `yaml:"mutation,omitempty"`
@@ -80,6 +80,114 @@ func TestLoadConfig(t *testing.T) {
 		require.Equal(t, c.Generate.Suffix.Query, "Foo")
 		require.Equal(t, c.Generate.Prefix.Mutation, "Hoge")
 		require.Equal(t, c.Generate.Prefix.Query, "Data")
+		require.Equal(t, c.Generate.TimeScalars["DurationSeconds"].Unit, "s")
+		require.Equal(t, c.Generate.TimeScalars["EpochMillis"].Unit, "ms")
+		require.True(t, c.Generate.OperationResults)
+		require.True(t, c.Generate.RawMethods)
+		require.True(t, c.Generate.TypenameChecks)
+		require.True(t, c.Generate.OperationVariables)
+		require.True(t, c.Generate.VariableDefaults)
+		require.True(t, c.Generate.Getters)
+		require.True(t, c.Generate.Clone)
+		require.True(t, c.Generate.SubscriptionChannels)
+		require.True(t, c.Generate.Executor)
+		require.True(t, c.Generate.JSONTags)
+		require.Equal(t, "MYAPI", c.Generate.EnvPrefix)
+		require.Equal(t, map[string][]string{"users": {"*User", "*Users"}}, c.Generate.Services)
+		require.True(t, c.Generate.InputInterface)
+		require.True(t, c.Generate.InputRawExtras)
+		require.True(t, c.Generate.ExhaustiveEnums)
+		require.True(t, c.Generate.UnionInterfaces)
+		require.Equal(t, map[string]int{"Point.coordinates": 3}, c.Generate.FixedLists)
+		require.Equal(t, DocumentModeID, c.Generate.DocumentMode)
+		require.Equal(t, "user-v1", c.Generate.DocumentID("GetUser", "abc"))
+		require.Equal(t, "sha256:abc", c.Generate.DocumentID("ListUsers", "abc"))
+		require.Equal(t, "// Code generated by gqlgenc, DO NOT EDIT.", c.Generate.Header)
+		require.Equal(t, map[string][]string{"SaveProfile": {"UpdateUser", "CreatePost"}}, c.Generate.MutationBatches)
+		require.Equal(t, &BatchTransactionConfig{Directive: "transaction", Headers: map[string]string{"X-Transaction": "atomic"}}, c.Generate.BatchTransaction)
+		require.Equal(t, map[string]ContextValueConfig{
+			"AuthToken": {Header: "Authorization", Prefix: "Bearer "},
+			"Endpoint":  {Endpoint: true},
+		}, c.Generate.ContextValues)
+		require.Equal(t, "integration", c.Generate.BuildTags)
+		require.Equal(t, "{ __typename }", c.Generate.PingQuery)
+		require.Equal(t, "GenGetUserType", c.Generate.TypeName("GetUser"))
+		require.Equal(t, "GetUser", (*GenerateConfig)(nil).TypeName("GetUser"))
+	})
+
+	t.Run("generate int64 scalars", func(t *testing.T) {
+		t.Parallel()
+		c, err := LoadConfig("testdata/cfg/int64_scalars.yml")
+		require.NoError(t, err)
+		require.Equal(t, []string{"BigInt", "Long"}, c.Generate.Int64Scalars)
+		// unmapped scalars are int64, mapped ones keep their model
+		require.Equal(t, config.StringList{Int64Model}, c.GQLConfig.Models["BigInt"].Model)
+		require.Equal(t, config.StringList{"github.com/99designs/gqlgen/graphql.String"}, c.GQLConfig.Models["Long"].Model)
+	})
+
+	t.Run("generate validated scalars", func(t *testing.T) {
+		t.Parallel()
+		c, err := LoadConfig("testdata/cfg/validated_scalars.yml")
+		require.NoError(t, err)
+		require.Equal(t, `^[^@\s]+@[^@\s]+$`, c.Generate.ValidatedScalars["Email"].Pattern)
+		// the scalars are mapped to their type generated in the model package
+		require.Equal(t, config.StringList{"github.com/pleclech/gqlgenc/config/gen.Email"}, c.GQLConfig.Models["Email"].Model)
+	})
+
+	t.Run("generate validated scalar with invalid pattern", func(t *testing.T) {
+		t.Parallel()
+		_, err := LoadConfig("testdata/cfg/validated_scalars_invalid.yml")
+		require.EqualError(t, err, "generate.validatedScalars.Email: error parsing regexp: missing closing ): `^([^@\\s]+@[^@\\s]+$`")
+	})
+
+	t.Run("generate context value sent two ways", func(t *testing.T) {
+		t.Parallel()
+		_, err := LoadConfig("testdata/cfg/context_values_invalid.yml")
+		require.EqualError(t, err, "generate.contextValues.AuthToken: want one of header, endpoint or idempotencyKey")
+	})
+
+	t.Run("generate time scalar with invalid unit", func(t *testing.T) {
+		t.Parallel()
+		_, err := LoadConfig("testdata/cfg/time_scalars_invalid_unit.yml")
+		require.EqualError(t, err, `generate.timeScalars.DurationSeconds: unknown unit "seconds", want one of ns, us, ms or s`)
+	})
+
+	t.Run("generate field name collision with invalid strategy", func(t *testing.T) {
+		t.Parallel()
+		_, err := LoadConfig("testdata/cfg/field_name_collision_invalid.yml")
+		require.EqualError(t, err, `generate.fieldNameCollision: unknown strategy "rename", want suffix or error`)
+	})
+
+	t.Run("generate with invalid document mode", func(t *testing.T) {
+		t.Parallel()
+		_, err := LoadConfig("testdata/cfg/document_mode_invalid.yml")
+		require.EqualError(t, err, `generate.documentMode: unknown mode "query", want text, hash or id`)
+	})
+
+	t.Run("model package", func(t *testing.T) {
+		t.Parallel()
+		c, err := LoadConfig("testdata/cfg/model_package.yml")
+		require.NoError(t, err)
+		require.Equal(t, "client", c.Client.Package)
+	})
+
+	t.Run("model and client in the same file", func(t *testing.T) {
+		t.Parallel()
+		_, err := LoadConfig("testdata/cfg/model_client_same_file.yml")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "config.model: model and client must be different files")
+	})
+
+	t.Run("model and client in the same directory with different packages", func(t *testing.T) {
+		t.Parallel()
+		_, err := LoadConfig("testdata/cfg/model_client_package_mismatch.yml")
+		require.EqualError(t, err, "config.model: model and client in the same directory must have the same package, got model and gen")
+	})
+
+	t.Run("model of the client package", func(t *testing.T) {
+		t.Parallel()
+		_, err := LoadConfig("testdata/cfg/model_in_client_package.yml")
+		require.EqualError(t, err, "config.model: models.User: github.com/pleclech/gqlgenc/config/gen/client.User is in the client package, which imports the model package")
 	})
 
 	t.Run("generate skip client", func(t *testing.T) {
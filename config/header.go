@@ -0,0 +1,57 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"go/build/constraint"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/99designs/gqlgen/codegen/config"
+)
+
+// DefaultHeader is the header of the generated files when none is configured
+const DefaultHeader = "// Code generated by github.com/Yamashou/gqlgenc, DO NOT EDIT."
+
+// NewHeader returns the text written above the package line of a file generated in pkg: the //go:build line of the build tags
+// and the header template executed with the package and file names of pkg, each line of which must be a comment
+func NewHeader(generateConfig *GenerateConfig, pkg config.PackageConfig) (string, error) {
+	header, buildTags := DefaultHeader, ""
+	if generateConfig != nil {
+		if generateConfig.Header != "" {
+			header = generateConfig.Header
+		}
+		buildTags = generateConfig.BuildTags
+	}
+
+	tmpl, err := template.New("header").Parse(header)
+	if err != nil {
+		return "", fmt.Errorf("header: %w", err)
+	}
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, map[string]string{
+		"Package":  pkg.Package,
+		"Filename": filepath.Base(pkg.Filename),
+	}); err != nil {
+		return "", fmt.Errorf("header: %w", err)
+	}
+
+	var doc strings.Builder
+	if buildTags != "" {
+		line := "//go:build " + buildTags
+		if _, err := constraint.Parse(line); err != nil {
+			return "", fmt.Errorf("build tags %q: %w", buildTags, err)
+		}
+		// a blank line separates the constraint from the doc of the package
+		doc.WriteString(line + "\n\n")
+	}
+	for _, line := range strings.Split(strings.TrimRight(rendered.String(), "\n"), "\n") {
+		if line != "" && !strings.HasPrefix(line, "//") {
+			return "", fmt.Errorf("header line %q is not a // comment", line)
+		}
+		doc.WriteString(line + "\n")
+	}
+
+	return doc.String(), nil
+}
@@ -7,7 +7,7 @@ import (
 	"net/http"
 	"time"
 
-	"github.com/Yamashou/gqlgenc/client"
+	"github.com/pleclech/gqlgenc/client"
 )
 
 type Client struct {
@@ -18,6 +18,11 @@ func NewClient(cli *http.Client, baseURL string, options ...client.HTTPRequestOp
 	return &Client{Client: client.NewClient(cli, baseURL, options...)}
 }
 
+// RawExecute runs a query which is not generated and decodes its data into out
+func (c *Client) RawExecute(ctx context.Context, query string, vars map[string]interface{}, out interface{}, httpRequestOptions ...client.HTTPRequestOption) error {
+	return c.Client.Post(ctx, "", query, out, vars, httpRequestOptions...)
+}
+
 type Query struct {
 	Node                Node                    "json:\"node\" graphql:\"node\""
 	Nodes               []Node                  "json:\"nodes\" graphql:\"nodes\""
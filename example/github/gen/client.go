@@ -6,7 +6,7 @@ import (
 	"context"
 	"net/http"
 
-	"github.com/Yamashou/gqlgenc/clientv2"
+	"github.com/pleclech/gqlgenc/clientv2"
 )
 
 type Client struct {
@@ -17,6 +17,11 @@ func NewClient(cli *http.Client, baseURL string, interceptors ...clientv2.Reques
 	return &Client{Client: clientv2.NewClient(cli, baseURL, interceptors...)}
 }
 
+// RawExecute runs a query which is not generated and decodes its data into out
+func (c *Client) RawExecute(ctx context.Context, query string, vars map[string]interface{}, out interface{}, interceptors ...clientv2.RequestInterceptor) error {
+	return c.Client.Post(ctx, "", query, out, vars, interceptors...)
+}
+
 type Query struct {
 	CodeOfConduct                            *CodeOfConduct                     "json:\"codeOfConduct,omitempty\" graphql:\"codeOfConduct\""
 	CodesOfConduct                           []*CodeOfConduct                   "json:\"codesOfConduct,omitempty\" graphql:\"codesOfConduct\""
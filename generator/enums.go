@@ -0,0 +1,96 @@
+package generator
+
+import (
+	"sort"
+	"text/template"
+
+	"github.com/99designs/gqlgen/codegen/templates"
+	"github.com/99designs/gqlgen/plugin/modelgen"
+	"github.com/pleclech/gqlgenc/config"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// EnumsFilename is the name of the file of the model package the switches of the exhaustive enums are generated in.
+const EnumsFilename = "enums_gen.go"
+
+// ExhaustiveEnum is an enum generated with its Switch method.
+type ExhaustiveEnum struct {
+	// Type is the go type of the enum, Name its name in the schema.
+	Type string
+	Name string
+	// Values are the values of the enum, in the order of the schema.
+	Values []*ExhaustiveEnumValue
+}
+
+// ExhaustiveEnumValue is a value of an exhaustive enum.
+type ExhaustiveEnumValue struct {
+	// Const is the go constant of the value, like RoleAdmin, and Param the parameter of its function, like onAdmin.
+	Const string
+	Param string
+}
+
+var enumsTemplate = template.Must(template.New(EnumsFilename).Parse(`package {{ .Package }}
+
+import (
+	"fmt"
+)
+{{ range .Enums }}
+// Switch calls the function of the value of e, failing for a value which is not of {{ .Type }}, like a value the server added since the generation.
+// It takes a function per value, so a value added to the schema fails the build of the switches until they handle it.
+func (e {{ .Type }}) Switch({{ range $i, $value := .Values }}{{ if $i }}, {{ end }}{{ $value.Param }}{{ end }} func() error) error {
+	//exhaustive:enforce
+	switch e {
+	{{- range .Values }}
+	case {{ .Const }}:
+		return {{ .Param }}()
+	{{- end }}
+	}
+
+	return fmt.Errorf("%s is not a valid {{ .Name }}", string(e))
+}
+{{ end }}`))
+
+// addEnumMarkers adds to the doc of the generated enums the marker of their Switch method.
+func addEnumMarkers(b *modelgen.ModelBuild) {
+	for _, enum := range b.Enums {
+		marker := templates.ToGo(enum.Name) + " is exhaustive: its values are the constants of the type, handled all by " + templates.ToGo(enum.Name) + ".Switch."
+		if enum.Description != "" {
+			marker = enum.Description + "\n\n" + marker
+		}
+		enum.Description = marker
+	}
+}
+
+// exhaustiveEnums returns the enums of the schema generated in the model package, sorted by name.
+func exhaustiveEnums(cfg *config.Config) []*ExhaustiveEnum {
+	var enums []*ExhaustiveEnum
+	for name, definition := range cfg.GQLConfig.Schema.Types {
+		if definition.Kind != ast.Enum || cfg.GQLConfig.Models.UserDefined(name) {
+			continue
+		}
+		enum := &ExhaustiveEnum{Type: templates.ToGo(name), Name: name}
+		for _, value := range definition.EnumValues {
+			enum.Values = append(enum.Values, &ExhaustiveEnumValue{
+				Const: enum.Type + templates.ToGo(value.Name),
+				Param: "on" + templates.ToGo(value.Name),
+			})
+		}
+		enums = append(enums, enum)
+	}
+	sort.Slice(enums, func(i, j int) bool {
+		return enums[i].Type < enums[j].Type
+	})
+
+	return enums
+}
+
+// generateEnums writes the switches of the exhaustive enums in the model package, along the models.
+func generateEnums(cfg *config.Config, enums []*ExhaustiveEnum) error {
+	if len(enums) == 0 {
+		return nil
+	}
+
+	return writeModelFile(cfg, "exhaustiveEnums", EnumsFilename, enumsTemplate, map[string]interface{}{
+		"Enums": enums,
+	})
+}
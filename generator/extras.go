@@ -0,0 +1,139 @@
+package generator
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+	"text/template"
+
+	"github.com/99designs/gqlgen/codegen/templates"
+	"github.com/99designs/gqlgen/plugin/modelgen"
+	"github.com/pleclech/gqlgenc/config"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// RawExtrasFilename is the name of the file of the model package the encoding of the raw extras of the inputs is generated in.
+const RawExtrasFilename = "inputs_gen.go"
+
+// RawExtrasField is the name in the schema of the raw extras field of the generated inputs, RawExtras in go.
+const RawExtrasField = "rawExtras"
+
+// RawExtrasInput is an input type generated with its raw extras.
+type RawExtrasInput struct {
+	// Type is the go type of the input.
+	Type string
+	// Fields are the names of the fields of the input in the schema, which the raw extras cannot have.
+	Fields []string
+}
+
+var rawMessageType = types.NewNamed(types.NewTypeName(0, types.NewPackage("encoding/json", "json"), "RawMessage", nil), types.NewSlice(types.Typ[types.Byte]), nil)
+
+var rawExtrasTemplate = template.Must(template.New(RawExtrasFilename).Parse(`package {{ .Package }}
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+{{ range .Inputs }}
+// MarshalJSON encodes the input with the fields of its RawExtras, failing when RawExtras has a field of the input.
+func (v {{ .Type }}) MarshalJSON() ([]byte, error) {
+	type input {{ .Type }}
+	typed, err := json.Marshal(input(v))
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeRawExtras(typed, v.RawExtras, {{ printf "%#v" .Fields }})
+}
+{{ end }}
+// mergeRawExtras appends to the encoded input typed the fields of the JSON object extras, sorted by name,
+// failing when extras has one of the fields of the input.
+func mergeRawExtras(typed []byte, extras json.RawMessage, fields []string) ([]byte, error) {
+	if len(extras) == 0 {
+		return typed, nil
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(extras, &values); err != nil {
+		return nil, fmt.Errorf("raw extras: %w", err)
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		for _, field := range fields {
+			if name == field {
+				return nil, fmt.Errorf("raw extras: %s is a field of the input", name)
+			}
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	buf.Write(typed[:len(typed)-1])
+	for _, name := range names {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, fmt.Errorf("raw extras: %w", err)
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(values[name])
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+`))
+
+// addRawExtras adds the raw extras field to the input types of the models.
+func addRawExtras(schema *ast.Schema, b *modelgen.ModelBuild) {
+	for _, model := range b.Models {
+		if definition := schema.Types[model.Name]; definition != nil && definition.Kind == ast.InputObject {
+			model.Fields = append(model.Fields, &modelgen.Field{
+				Description: "RawExtras are JSON fields merged into the encoded input, like the fields the schema added since the generation.",
+				Name:        RawExtrasField,
+				Type:        rawMessageType,
+				Tag:         `json:"-"`,
+			})
+		}
+	}
+}
+
+// rawExtrasInputs returns the input types of the schema generated in the model package, sorted by name.
+func rawExtrasInputs(cfg *config.Config) ([]*RawExtrasInput, error) {
+	var inputs []*RawExtrasInput
+	for name, definition := range cfg.GQLConfig.Schema.Types {
+		if definition.Kind != ast.InputObject || cfg.GQLConfig.Models.UserDefined(name) {
+			continue
+		}
+		input := &RawExtrasInput{Type: templates.ToGo(name)}
+		for _, field := range definition.Fields {
+			if field.Name == RawExtrasField {
+				return nil, fmt.Errorf("inputRawExtras: the input %s has a field %s, named as the raw extras", name, RawExtrasField)
+			}
+			input.Fields = append(input.Fields, field.Name)
+		}
+		inputs = append(inputs, input)
+	}
+	sort.Slice(inputs, func(i, j int) bool {
+		return inputs[i].Type < inputs[j].Type
+	})
+
+	return inputs, nil
+}
+
+// generateRawExtras writes the encoding of the raw extras of inputs in the model package, along the models.
+func generateRawExtras(cfg *config.Config, inputs []*RawExtrasInput) error {
+	if len(inputs) == 0 {
+		return nil
+	}
+
+	return writeModelFile(cfg, "inputRawExtras", RawExtrasFilename, rawExtrasTemplate, map[string]interface{}{
+		"Inputs": inputs,
+	})
+}
@@ -1,37 +1,164 @@
 package generator
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"text/template"
 
 	"github.com/99designs/gqlgen/api"
 	codegenconfig "github.com/99designs/gqlgen/codegen/config"
 	"github.com/99designs/gqlgen/plugin"
 	"github.com/99designs/gqlgen/plugin/modelgen"
-	"github.com/Yamashou/gqlgenc/config"
+	"github.com/pleclech/gqlgenc/config"
+	"github.com/vektah/gqlparser/v2/ast"
 )
 
-// mutateHook adds the "omitempty" option to nilable fields.
+// mutateHook adds the "omitempty" option to nilable fields, or with jsonTags to the fields nullable in the schema
+// along a graphql tag, so the models encode with the keys and the nulls of the server.
 // For more info see https://github.com/99designs/gqlgen/blob/master/docs/content/recipes/modelgen-hook.md
-func mutateHook(b *modelgen.ModelBuild) *modelgen.ModelBuild {
-	for _, model := range b.Models {
-		for _, field := range model.Fields {
-			field.Tag = `json:"` + field.Name
-			if codegenconfig.IsNilable(field.Type) {
-				field.Tag += ",omitempty"
+func mutateHook(cfg *config.Config) modelgen.BuildMutateHook {
+	return func(b *modelgen.ModelBuild) *modelgen.ModelBuild {
+		jsonTags := cfg.Generate != nil && cfg.Generate.JSONTags
+		for _, model := range b.Models {
+			for _, field := range model.Fields {
+				omitempty := codegenconfig.IsNilable(field.Type)
+				if jsonTags {
+					if definition := schemaField(cfg.GQLConfig.Schema, model.Name, field.Name); definition != nil {
+						omitempty = !definition.Type.NonNull
+					}
+				}
+
+				field.Tag = `json:"` + field.Name
+				if omitempty {
+					field.Tag += ",omitempty"
+				}
+				field.Tag += `"`
+				if jsonTags {
+					field.Tag += ` graphql:"` + field.Name + `"`
+				}
 			}
-			field.Tag += `"`
 		}
+
+		if cfg.Generate != nil && cfg.Generate.InputInterface {
+			addInputInterface(cfg.GQLConfig.Schema, b)
+		}
+		if cfg.Generate != nil && cfg.Generate.InputRawExtras {
+			addRawExtras(cfg.GQLConfig.Schema, b)
+		}
+		if cfg.Generate != nil && cfg.Generate.ExhaustiveEnums {
+			addEnumMarkers(b)
+		}
+
+		return b
+	}
+}
+
+// InputInterface is the interface generated along the models with inputInterface, implemented by the input types
+// with its IsGraphQLInput marker method.
+const InputInterface = "GraphQLInput"
+
+// addInputInterface adds the input interface to the models, and makes the input types of the schema implement it.
+func addInputInterface(schema *ast.Schema, b *modelgen.ModelBuild) {
+	b.Interfaces = append(b.Interfaces, &modelgen.Interface{
+		Description: InputInterface + " is implemented by the input types of the schema.",
+		Name:        InputInterface,
+	})
+	for _, model := range b.Models {
+		if definition := schema.Types[model.Name]; definition != nil && definition.Kind == ast.InputObject {
+			model.Implements = append(model.Implements, InputInterface)
+		}
+	}
+}
+
+// schemaField returns the field of the type of the schema, nil if there is none.
+func schemaField(schema *ast.Schema, typeName, fieldName string) *ast.FieldDefinition {
+	definition := schema.Types[typeName]
+	if definition == nil {
+		return nil
+	}
+
+	return definition.Fields.ForName(fieldName)
+}
+
+// modelsHeader is the header gqlgen writes above the package line of the models.
+const modelsHeader = "// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.\n\n"
+
+// writeModelsHeader replaces the header gqlgen writes above the models by the header and the build tags of the files
+// gqlgenc writes, when either is configured. Models without the header of gqlgen, like ones left from a generation
+// without models, are left as they are.
+func writeModelsHeader(cfg *config.Config) error {
+	if cfg.Generate == nil || cfg.Generate.Header == "" && cfg.Generate.BuildTags == "" {
+		return nil
+	}
+
+	src, err := ioutil.ReadFile(cfg.Model.Filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("header of the models: %w", err)
+	}
+	if !bytes.HasPrefix(src, []byte(modelsHeader)) {
+		return nil
+	}
+	model := cfg.Model
+	if err := model.Check(); err != nil {
+		return fmt.Errorf("header of the models: %w", err)
+	}
+	header, err := config.NewHeader(cfg.Generate, model)
+	if err != nil {
+		return fmt.Errorf("header of the models: %w", err)
+	}
+	if err := ioutil.WriteFile(cfg.Model.Filename, append([]byte(header+"\n"), src[len(modelsHeader):]...), 0o644); err != nil {
+		return fmt.Errorf("header of the models: %w", err)
+	}
+
+	return nil
+}
+
+// writeModelFile writes the file filename of the model package under the header of the generated files,
+// tmpl being executed with data and the name of the package. The errors are prefixed by the option generating the file.
+func writeModelFile(cfg *config.Config, option, filename string, tmpl *template.Template, data map[string]interface{}) error {
+	model := cfg.Model
+	if err := model.Check(); err != nil {
+		return fmt.Errorf("%s: %w", option, err)
+	}
+	header, err := config.NewHeader(cfg.Generate, codegenconfig.PackageConfig{Filename: filepath.Join(model.Dir(), filename), Package: model.Package})
+	if err != nil {
+		return fmt.Errorf("%s: %w", option, err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(header + "\n")
+	data["Package"] = model.Package
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("%s: %w", option, err)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("%s: %w", option, err)
+	}
+
+	if err := os.MkdirAll(model.Dir(), 0o755); err != nil {
+		return fmt.Errorf("%s: %w", option, err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(model.Dir(), filename), src, 0o644); err != nil {
+		return fmt.Errorf("%s: %w", option, err)
 	}
 
-	return b
+	return nil
 }
 
 func Generate(ctx context.Context, cfg *config.Config, option ...api.Option) error {
 	var plugins []plugin.Plugin
 	if cfg.Model.IsDefined() {
 		p := modelgen.Plugin{
-			MutateHook: mutateHook,
+			MutateHook: mutateHook(cfg),
 		}
 		plugins = append(plugins, &p)
 	}
@@ -42,11 +169,31 @@ func Generate(ctx context.Context, cfg *config.Config, option ...api.Option) err
 	if err := cfg.LoadSchema(ctx); err != nil {
 		return fmt.Errorf("failed to load schema: %w", err)
 	}
+	if cfg.Generate != nil && cfg.Generate.InputInterface && cfg.GQLConfig.Schema.Types[InputInterface] != nil {
+		return fmt.Errorf("inputInterface: the schema has a type %s, named as the input interface", InputInterface)
+	}
+
+	if err := generateValidatedScalars(cfg); err != nil {
+		return err
+	}
 
 	if err := cfg.GQLConfig.Init(); err != nil {
 		return fmt.Errorf("generating core failed: %w", err)
 	}
 
+	var rawExtras []*RawExtrasInput
+	if cfg.Generate != nil && cfg.Generate.InputRawExtras {
+		inputs, err := rawExtrasInputs(cfg)
+		if err != nil {
+			return err
+		}
+		rawExtras = inputs
+	}
+	var enums []*ExhaustiveEnum
+	if cfg.Generate != nil && cfg.Generate.ExhaustiveEnums {
+		enums = exhaustiveEnums(cfg)
+	}
+
 	for _, p := range plugins {
 		if mut, ok := p.(plugin.ConfigMutator); ok {
 			err := mut.MutateConfig(cfg.GQLConfig)
@@ -54,7 +201,17 @@ func Generate(ctx context.Context, cfg *config.Config, option ...api.Option) err
 				return fmt.Errorf("%s failed: %w", p.Name(), err)
 			}
 		}
+		// the inputs and the enums get their methods along their models, before the client is bound to them
+		if _, ok := p.(*modelgen.Plugin); ok {
+			if err := generateRawExtras(cfg, rawExtras); err != nil {
+				return err
+			}
+			if err := generateEnums(cfg, enums); err != nil {
+				return err
+			}
+		}
 	}
 
-	return nil
+	// the build constraint of the models would hide them from the client bound to them, it is written last
+	return writeModelsHeader(cfg)
 }
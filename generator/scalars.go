@@ -0,0 +1,79 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"text/template"
+
+	"github.com/99designs/gqlgen/codegen/templates"
+	"github.com/pleclech/gqlgenc/config"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// ValidatedScalarsFilename is the name of the file of the model package the validated scalars are generated in.
+const ValidatedScalarsFilename = "scalars_gen.go"
+
+// ValidatedScalar is a string scalar generated with its validation.
+type ValidatedScalar struct {
+	// Name is the name of the scalar in the schema.
+	Name string
+	// Type is the go type of the scalar.
+	Type string
+	// Pattern is the regular expression the values must match.
+	Pattern string
+}
+
+var validatedScalarsTemplate = template.Must(template.New(ValidatedScalarsFilename).Parse(`package {{ .Package }}
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+{{ range .Scalars }}
+// {{ .Type }} is the {{ .Name }} scalar, whose values must match {{ .Pattern }}, checked when it is marshaled.
+type {{ .Type }} string
+
+var pattern{{ .Type }} = regexp.MustCompile({{ printf "%q" .Pattern }})
+
+// Validate returns an error if the value does not match the pattern of the scalar.
+func (v {{ .Type }}) Validate() error {
+	if !pattern{{ .Type }}.MatchString(string(v)) {
+		return fmt.Errorf("invalid {{ .Name }} %q: does not match %s", string(v), pattern{{ .Type }})
+	}
+
+	return nil
+}
+
+// MarshalJSON encodes the value as a JSON string, failing if it is not valid so that it is not sent.
+func (v {{ .Type }}) MarshalJSON() ([]byte, error) {
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(string(v))
+}
+{{ end }}`))
+
+// generateValidatedScalars writes the types of the validated scalars in the model package, before the models
+// and the client are bound to them.
+func generateValidatedScalars(cfg *config.Config) error {
+	if cfg.Generate == nil || len(cfg.Generate.ValidatedScalars) == 0 {
+		return nil
+	}
+
+	scalars := make([]*ValidatedScalar, 0, len(cfg.Generate.ValidatedScalars))
+	for name, validatedScalar := range cfg.Generate.ValidatedScalars {
+		if definition := cfg.GQLConfig.Schema.Types[name]; definition == nil || definition.Kind != ast.Scalar {
+			return fmt.Errorf("validatedScalars.%s: not a scalar of the schema", name)
+		}
+		scalars = append(scalars, &ValidatedScalar{Name: name, Type: templates.ToGo(name), Pattern: validatedScalar.Pattern})
+	}
+	sort.Slice(scalars, func(i, j int) bool {
+		return scalars[i].Name < scalars[j].Name
+	})
+
+	return writeModelFile(cfg, "validatedScalars", ValidatedScalarsFilename, validatedScalarsTemplate, map[string]interface{}{
+		"Scalars": scalars,
+	})
+}
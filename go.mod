@@ -1,17 +1,23 @@
 module github.com/pleclech/gqlgenc
 
-go 1.14
+go 1.25.0
 
 require (
 	github.com/99designs/gqlgen v0.13.0
-	github.com/agnivade/levenshtein v1.1.0 // indirect
-	github.com/google/go-cmp v0.5.4
-	github.com/pkg/errors v0.9.1
+	github.com/google/go-cmp v0.6.0
 	github.com/stretchr/testify v1.4.0
 	github.com/vektah/gqlparser/v2 v2.1.0
-	golang.org/x/tools v0.0.0-20200827163409-021d7c6f1ec3 // indirect
-	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
+	golang.org/x/tools v0.44.0
 	gopkg.in/yaml.v2 v2.3.0
 )
 
+require (
+	github.com/agnivade/levenshtein v1.1.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/mod v0.35.0 // indirect
+	golang.org/x/sync v0.20.0 // indirect
+)
+
 replace github.com/Yamashou/gqlgenc v0.0.2 => github.com/pleclech/gqlgenc v0.0.4
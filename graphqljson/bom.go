@@ -0,0 +1,46 @@
+package graphqljson
+
+import (
+	"bytes"
+	"io"
+)
+
+// utf8BOM is the byte order mark some proxies and gateways write before the responses, which JSON does not allow.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// TrimBOM returns data without its leading UTF-8 byte order mark, data itself when it has none.
+// UnmarshalData, UnmarshalBytes and NewDecoder trim it, the whitespace before the value being valid JSON already.
+func TrimBOM(data []byte) []byte {
+	return bytes.TrimPrefix(data, utf8BOM)
+}
+
+// bomReader reads r without its leading UTF-8 byte order mark, see TrimBOM.
+type bomReader struct {
+	r io.Reader
+	// head are the first bytes of r when they are not a byte order mark, read before the rest of r.
+	head    []byte
+	checked bool
+}
+
+func (b *bomReader) Read(p []byte) (int, error) {
+	if !b.checked {
+		b.checked = true
+		head := make([]byte, len(utf8BOM))
+		n, err := io.ReadFull(b.r, head)
+		if !bytes.Equal(head[:n], utf8BOM) {
+			b.head = head[:n]
+		}
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return 0, err
+		}
+	}
+
+	if len(b.head) > 0 {
+		n := copy(p, b.head)
+		b.head = b.head[n:]
+
+		return n, nil
+	}
+
+	return b.r.Read(p)
+}
@@ -0,0 +1,65 @@
+package graphqljson
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// RegisterBoolMapping makes Decode accept for bool fields the JSON strings trueVals as true and falseVals as false,
+// like RegisterBoolMapping([]string{"enabled"}, []string{"disabled"}) for APIs sending strings where a bool is modeled.
+// Once a mapping is registered, the other strings fail for the bool fields but "true" and "false" with tolerant bools,
+// the mappings registered along adding their strings, the last one of a string winning.
+// Without mapping the strings fail as usual. The bool types decoding themselves are left to their UnmarshalJSON.
+func (d *Decoder) RegisterBoolMapping(trueVals, falseVals []string) {
+	if d.boolMapping == nil {
+		d.boolMapping = make(map[string]bool)
+	}
+	for _, s := range trueVals {
+		d.boolMapping[s] = true
+	}
+	for _, s := range falseVals {
+		d.boolMapping[s] = false
+	}
+}
+
+// WithBoolMapping makes UnmarshalData decode the strings trueVals and falseVals into bool fields, see Decoder.RegisterBoolMapping.
+func WithBoolMapping(trueVals, falseVals []string) Option {
+	return func(d *Decoder) {
+		d.RegisterBoolMapping(trueVals, falseVals)
+	}
+}
+
+// decodeMappedBool decodes the JSON string value into the bool, or pointer to bool, t when bool mappings are registered.
+// It reports whether value was such a string, the error being the one of a string not mapped.
+func (d *Decoder) decodeMappedBool(value json.Token, t target) (bool, error) {
+	s, ok := value.(string)
+	if !ok || d.boolMapping == nil || decodesItself(t.value.Type()) {
+		return false, nil
+	}
+	typ := t.value.Type()
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Bool {
+		return false, nil
+	}
+
+	b, ok := d.boolMapping[s]
+	if !ok && d.tolerantBools {
+		b, ok = tolerantBool(value, t.value)
+	}
+	if !ok {
+		return true, fmt.Errorf("%v at %q: unknown bool string %q", t.value.Type(), d.currentPath(), s)
+	}
+	v := t.value
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(d.newValue(v.Type().Elem())) // v = new(T).
+		}
+		v = v.Elem()
+	}
+	v.SetBool(b)
+
+	return true, nil
+}
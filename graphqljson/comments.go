@@ -0,0 +1,123 @@
+package graphqljson
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// commentState is where a comment reader is in its input.
+type commentState int
+
+const (
+	commentNone commentState = iota
+	commentString
+	commentStringEscape
+	commentLine
+	commentBlockStart
+	commentBlock
+	commentBlockStar
+)
+
+// commentReader replaces the line and block comments of JSON input by spaces, see NewCommentReader.
+type commentReader struct {
+	r     *bufio.Reader
+	state commentState
+}
+
+// NewCommentReader returns a reader of the JSON input of r without its // line and /* block */ comments,
+// for the fixtures and the servers writing comments in their responses, which standard JSON forbids.
+// The comments are replaced by spaces, their line breaks being kept, so the offsets of the input are unchanged.
+// The input ending in a block comment fails with io.ErrUnexpectedEOF.
+func NewCommentReader(r io.Reader) io.Reader {
+	return &commentReader{r: bufio.NewReader(r)}
+}
+
+func (c *commentReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		b, err := c.r.ReadByte()
+		if err != nil {
+			if err == io.EOF && (c.state == commentBlockStart || c.state == commentBlock || c.state == commentBlockStar) {
+				err = io.ErrUnexpectedEOF
+			}
+
+			return n, err
+		}
+
+		switch c.state {
+		case commentNone:
+			switch b {
+			case '"':
+				c.state = commentString
+			case '/':
+				next, err := c.r.Peek(1)
+				if err == nil && next[0] == '/' {
+					c.state = commentLine
+					b = ' '
+				} else if err == nil && next[0] == '*' {
+					c.state = commentBlockStart
+					b = ' '
+				}
+			}
+		case commentString:
+			switch b {
+			case '\\':
+				c.state = commentStringEscape
+			case '"':
+				c.state = commentNone
+			}
+		case commentStringEscape:
+			c.state = commentString
+		case commentLine:
+			if b == '\n' {
+				c.state = commentNone
+			} else {
+				b = ' '
+			}
+		case commentBlockStart:
+			// the star opening the comment does not close it
+			c.state = commentBlock
+			b = ' '
+		case commentBlock, commentBlockStar:
+			switch {
+			case b == '/' && c.state == commentBlockStar:
+				c.state = commentNone
+				b = ' '
+			case b == '*':
+				c.state = commentBlockStar
+				b = ' '
+			case b == '\n':
+				c.state = commentBlock
+			default:
+				c.state = commentBlock
+				b = ' '
+			}
+		}
+
+		p[n] = b
+		n++
+	}
+
+	return n, nil
+}
+
+// StripComments returns data without its line and block comments, see NewCommentReader.
+func StripComments(data []byte) ([]byte, error) {
+	stripped, err := ioutil.ReadAll(NewCommentReader(bytes.NewReader(data)))
+	if err != nil {
+		return nil, fmt.Errorf("strip comments: %w", err)
+	}
+
+	return stripped, nil
+}
+
+// WithComments makes UnmarshalData and UnmarshalBytes accept the line and block comments of data,
+// stripping them before decoding it, see NewCommentReader. A Decoder reads the input of a NewCommentReader instead.
+func WithComments() Option {
+	return func(d *Decoder) {
+		d.comments = true
+	}
+}
@@ -0,0 +1,101 @@
+package graphqljson
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultField is a struct field tagged with a default option, like `graphql:"status,default=ACTIVE"`.
+type defaultField struct {
+	index int
+	// key is the key of the field in the response.
+	key     string
+	value   string
+	options tagOptions
+}
+
+// defaultFields caches the fields having a default of the struct types decoded, by type.
+var defaultFields sync.Map
+
+// defaultFieldsOf returns the fields of the struct type typ having a default, the fragments and embedded structs excluded.
+func defaultFieldsOf(typ reflect.Type) []defaultField {
+	if fields, ok := defaultFields.Load(typ); ok {
+		return fields.([]defaultField)
+	}
+
+	var fields []defaultField
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.PkgPath != "" || f.Anonymous || isGraphQLFragment(f) {
+			continue
+		}
+		name, options := splitGraphQLTag(f.Tag.Get("graphql"))
+		value, ok := options["default"]
+		if !ok {
+			continue
+		}
+		if j := strings.IndexAny(name, "(:"); j != -1 {
+			name = name[:j]
+		}
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		fields = append(fields, defaultField{index: i, key: name, value: value, options: options})
+	}
+	defaultFields.Store(typ, fields)
+
+	return fields
+}
+
+// setDefaults sets the fields of the current object tagged with a default option whose key the object does not have
+// to their default, converted to the type of the field as the JSON value of the default would be.
+func (d *Decoder) setDefaults() error {
+	for _, dv := range d.vs {
+		v := followPtr(dv[len(dv)-1].value)
+		if v.Kind() != reflect.Struct {
+			continue
+		}
+
+		for _, f := range defaultFieldsOf(v.Type()) {
+			if d.hasKey(f.key) {
+				continue
+			}
+
+			field := v.Field(f.index)
+			if err := d.unmarshalValue(defaultToken(f.value, field.Type()), target{value: field, options: f.options}); err != nil {
+				return fmt.Errorf("default %q of %s at %q: %w", f.value, f.key, d.currentPath(), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// defaultToken returns the JSON token of the default value for a field of type typ:
+// a string for the string fields, else a number, a boolean or null when the value is one, and a string otherwise.
+func defaultToken(value string, typ reflect.Type) json.Token {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() == reflect.String {
+		return value
+	}
+
+	switch value {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return json.Number(value)
+	}
+
+	return value
+}
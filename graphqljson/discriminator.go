@@ -0,0 +1,230 @@
+package graphqljson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// typenameField is the discriminator of the objects of GraphQL responses.
+const typenameField = "__typename"
+
+// SetDiscriminator makes Decode tell the type of an object by its field named fieldName rather than __typename,
+// like "kind" or "type" for APIs not exposing __typename. The discriminator selects the fragments decoded
+// with their type conditions, and the types registered with RegisterType for interface fields.
+func (d *Decoder) SetDiscriminator(fieldName string) {
+	d.discriminator = fieldName
+}
+
+// RegisterType makes Decode decode the objects whose discriminator is value into a new value of the type of v,
+// like RegisterType("Circle", &Circle{}), for the interface fields and the slices of interfaces it implements.
+// Several types can be registered for a value, each object being decoded into the first one implementing its field,
+// like the structs of the selections of a union in several queries. Without registered types the interface fields are decoded by encoding/json.
+func (d *Decoder) RegisterType(value string, v interface{}) {
+	if d.types == nil {
+		d.types = make(map[string][]reflect.Type)
+	}
+	typ := reflect.TypeOf(v)
+	for _, registered := range d.types[value] {
+		if registered == typ {
+			return
+		}
+	}
+	d.types[value] = append(d.types[value], typ)
+}
+
+// WithDiscriminator makes UnmarshalData tell the type of an object by its field named fieldName, see Decoder.SetDiscriminator.
+func WithDiscriminator(fieldName string) Option {
+	return func(d *Decoder) {
+		d.SetDiscriminator(fieldName)
+	}
+}
+
+// WithType makes UnmarshalData decode the objects whose discriminator is value into the type of v, see Decoder.RegisterType.
+func WithType(value string, v interface{}) Option {
+	return func(d *Decoder) {
+		d.RegisterType(value, v)
+	}
+}
+
+// OnTypename makes Decode call fn with the typename of each object decoded into a struct, the value of its discriminator,
+// and the struct, nested objects and the elements of lists included, in the order of their typename in the input.
+// fn is called when the typename is read, before the fields after it are decoded into the struct,
+// and the objects decoded at once into maps and interface{} fields do not call it.
+func (d *Decoder) OnTypename(fn func(typename string, v reflect.Value)) {
+	d.onTypename = fn
+}
+
+// WithTypenameHook makes UnmarshalData call fn with the typename of each object decoded into a struct, see Decoder.OnTypename.
+func WithTypenameHook(fn func(typename string, v reflect.Value)) Option {
+	return func(d *Decoder) {
+		d.OnTypename(fn)
+	}
+}
+
+// callOnTypename calls the typename hook with typename and the struct of the current object,
+// which is below the field of the typename in the main stack.
+func (d *Decoder) callOnTypename(typename string) {
+	if d.onTypename == nil || len(d.vs) == 0 || len(d.vs[0]) < 2 {
+		return
+	}
+	if v := followPtr(d.vs[0][len(d.vs[0])-2].value); v.Kind() == reflect.Struct {
+		d.onTypename(typename, v)
+	}
+}
+
+// discriminatorField returns the name of the field telling the type of an object.
+func (d *Decoder) discriminatorField() string {
+	if d.discriminator == "" {
+		return typenameField
+	}
+
+	return d.discriminator
+}
+
+// isDispatched reports whether the values of the field v are decoded into the registered types.
+func (d *Decoder) isDispatched(v reflect.Value) bool {
+	if len(d.types) == 0 {
+		return false
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Slice:
+		return v.Type().Elem().Kind() == reflect.Interface
+	}
+
+	return false
+}
+
+// decodeDispatched decodes the next JSON value, read once, into the fields, interfaces or slices of interfaces.
+func (d *Decoder) decodeDispatched(fields []reflect.Value) error {
+	var raw json.RawMessage
+	if err := d.jsonDecoder.Decode(&raw); err != nil {
+		return d.readError(err)
+	}
+	d.recordPresence(isNull(raw))
+
+	for _, v := range fields {
+		if err := d.dispatchField(raw, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dispatchField decodes raw into the interface field v, or into the elements of the slice field v.
+func (d *Decoder) dispatchField(raw json.RawMessage, v reflect.Value) error {
+	if v.Kind() == reflect.Interface {
+		return d.dispatch(raw, v, d.currentPath())
+	}
+
+	if isNull(raw) {
+		v.Set(reflect.Zero(v.Type()))
+
+		return nil
+	}
+	var elements []json.RawMessage
+	if err := json.Unmarshal(raw, &elements); err != nil {
+		return fmt.Errorf("%v at %q: %w", v.Type(), d.currentPath(), err)
+	}
+	v.Set(reflect.MakeSlice(v.Type(), len(elements), len(elements)))
+	for i, element := range elements {
+		if err := d.dispatch(element, v.Index(i), fmt.Sprintf("%s[%d]", d.currentPath(), i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dispatch decodes the JSON value raw at path into the interface v, an object into the type registered for its discriminator.
+func (d *Decoder) dispatch(raw json.RawMessage, v reflect.Value, path string) error {
+	if isNull(raw) {
+		v.Set(reflect.Zero(v.Type()))
+
+		return nil
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &object); err != nil {
+		// not an object, like a scalar in an interface{}
+		if err := unmarshalNumbers(raw, v.Addr().Interface()); err != nil {
+			return fmt.Errorf("%v at %q: %w", v.Type(), path, err)
+		}
+
+		return nil
+	}
+
+	var value string
+	if err := json.Unmarshal(object[d.discriminatorField()], &value); err != nil {
+		return fmt.Errorf("missing %s of %v at %q", d.discriminatorField(), v.Type(), path)
+	}
+	types, ok := d.types[value]
+	if !ok {
+		return fmt.Errorf("unknown %s %q of %v at %q", d.discriminatorField(), value, v.Type(), path)
+	}
+	typ := types[0]
+	for _, candidate := range types {
+		if candidate.AssignableTo(v.Type()) {
+			typ = candidate
+
+			break
+		}
+	}
+	if !typ.AssignableTo(v.Type()) {
+		return fmt.Errorf("%v of %s %q does not implement %v at %q", typ, d.discriminatorField(), value, v.Type(), path)
+	}
+
+	// the object is decoded by a decoder of the same settings, into a new value of the registered type,
+	// the errors of its lenient lists being the ones of this decoder
+	concrete := d.newValue(typ)
+	sub := d.subDecoder(raw, path)
+	err := sub.Decode(concrete.Interface())
+	var elementErrors ElementErrors
+	if errors.As(err, &elementErrors) {
+		d.elementErrors = append(d.elementErrors, elementErrors...)
+	} else if err != nil {
+		return fmt.Errorf("%s at %q%w", value, path, err)
+	}
+	v.Set(concrete.Elem())
+
+	return nil
+}
+
+// subDecoder returns a decoder of the settings of d decoding raw, the value at path in the data of d.
+func (d *Decoder) subDecoder(raw json.RawMessage, path string) *Decoder {
+	sub := &Decoder{decoderOptions: d.decoderOptions}
+	if sub.maxDepth > 0 {
+		sub.maxDepth -= len(d.parseState)
+	}
+	sub.pathPrefix = joinPath(d.pathPrefix, path)
+	sub.data.Reset(raw)
+	sub.jsonDecoder = json.NewDecoder(&sub.data)
+	sub.jsonDecoder.UseNumber()
+
+	return sub
+}
+
+// joinPath returns the path of the value at path in the value at prefix.
+func joinPath(prefix, path string) string {
+	switch {
+	case prefix == "":
+		return path
+	case path == "":
+		return prefix
+	case path[0] == '[':
+		return prefix + path
+	default:
+		return prefix + "." + path
+	}
+}
+
+// isNull reports whether raw is the JSON null.
+func isNull(raw json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+}
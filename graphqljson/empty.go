@@ -0,0 +1,42 @@
+package graphqljson
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// RegisterEmptyAsNull makes Decode decode the empty JSON strings of the fields of the type of v, or pointing to it, as null,
+// like RegisterEmptyAsNull(time.Time{}) for the backends sending "" for a date they do not have: a *time.Time field is set to nil
+// and a time.Time field to its zero value, rather than failing to parse "". The null check of the non-null fields applies to them.
+// The fields of the other types decode "" as usual, it is off by default.
+func (d *Decoder) RegisterEmptyAsNull(v interface{}) {
+	if d.emptyAsNull == nil {
+		d.emptyAsNull = make(map[reflect.Type]bool)
+	}
+	typ := reflect.TypeOf(v)
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	d.emptyAsNull[typ] = true
+}
+
+// WithEmptyAsNull makes UnmarshalData decode the empty strings of the fields of the types of values as null, see Decoder.RegisterEmptyAsNull.
+func WithEmptyAsNull(values ...interface{}) Option {
+	return func(d *Decoder) {
+		for _, v := range values {
+			d.RegisterEmptyAsNull(v)
+		}
+	}
+}
+
+// isEmptyAsNull reports whether value is an empty string decoded as null into a value of type typ.
+func (d *Decoder) isEmptyAsNull(value json.Token, typ reflect.Type) bool {
+	if s, ok := value.(string); !ok || s != "" || d.emptyAsNull == nil {
+		return false
+	}
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	return d.emptyAsNull[typ]
+}
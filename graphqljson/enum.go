@@ -0,0 +1,49 @@
+package graphqljson
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Enum is implemented by the integer types of enums checking their values, like type Level int for schemas sending
+// their enums as integers. The numbers decoded into these types fail unless IsValid reports they are values of the enum.
+type Enum interface {
+	IsValid() bool
+}
+
+var enumType = reflect.TypeOf((*Enum)(nil)).Elem()
+
+// checkEnum returns an error if v, or the value pointed by v, is an integer enum decoded from a number which IsValid rejects.
+// The other types, and the types decoding themselves, are not checked.
+func (d *Decoder) checkEnum(v reflect.Value) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		return nil
+	}
+	if decodesItself(v.Type()) {
+		return nil
+	}
+
+	var enum Enum
+	switch {
+	case v.Type().Implements(enumType):
+		enum = v.Interface().(Enum)
+	case v.CanAddr() && reflect.PtrTo(v.Type()).Implements(enumType):
+		enum = v.Addr().Interface().(Enum)
+	default:
+		return nil
+	}
+	if !enum.IsValid() {
+		return fmt.Errorf("%v at %q: %v is not a value of the enum", v.Type(), d.currentPath(), v.Interface())
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package graphqljson
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// fieldIndex indexes the exported fields of a struct type by the names they match, see fieldByGraphQLName.
+// A name matched by several fields is the one of the first, as for a scan of the fields in order.
+type fieldIndex struct {
+	// names are the names matched whether exact or not, by the graphql tags, or by the json and protobuf tags
+	// and __typename of the fields without graphql tag, mapped to the index of the field.
+	names map[string]int
+	// goNames are the names of the fields without graphql tag, matched when exact, and folded their folded names,
+	// matched when not exact.
+	goNames map[string]int
+	folded  map[string]int
+	// options are the options of the graphql tags of the fields, by index.
+	options []tagOptions
+}
+
+// fieldIndexes caches the field indexes of the struct types decoded, by type.
+var fieldIndexes sync.Map
+
+// fieldIndexOf returns the field index of the struct type typ, built once for all the objects of the type.
+func fieldIndexOf(typ reflect.Type) *fieldIndex {
+	if index, ok := fieldIndexes.Load(typ); ok {
+		return index.(*fieldIndex)
+	}
+
+	index := &fieldIndex{
+		names:   make(map[string]int),
+		goNames: make(map[string]int),
+		folded:  make(map[string]int),
+		options: make([]tagOptions, typ.NumField()),
+	}
+	add := func(names map[string]int, name string, i int) {
+		if _, ok := names[name]; !ok {
+			names[name] = i
+		}
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.PkgPath != "" {
+			// Skip unexported field.
+			continue
+		}
+
+		value, ok := f.Tag.Lookup("graphql")
+		if ok {
+			if name, ok := graphQLName(value); ok {
+				add(index.names, name, i)
+			}
+			_, index.options[i] = splitGraphQLTag(value)
+
+			continue
+		}
+
+		jsonName := f.Tag.Get("json")
+		if jsonName == "-" {
+			// never decoded, as by encoding/json
+			continue
+		}
+		if j := strings.Index(jsonName, ","); j != -1 {
+			jsonName = jsonName[:j]
+		}
+		if jsonName != "" && jsonName != "-" {
+			add(index.names, jsonName, i)
+		}
+		for _, option := range strings.Split(f.Tag.Get("protobuf"), ",") {
+			if strings.HasPrefix(option, "json=") || strings.HasPrefix(option, "name=") {
+				add(index.names, option[strings.Index(option, "=")+1:], i)
+			}
+		}
+		// the name of the field cannot have the underscores of __typename
+		if f.Name == "Typename" || f.Name == "TypeName" {
+			add(index.names, typenameField, i)
+		}
+		add(index.goNames, f.Name, i)
+		add(index.folded, foldName(f.Name), i)
+	}
+	actual, _ := fieldIndexes.LoadOrStore(typ, index)
+
+	return actual.(*fieldIndex)
+}
+
+// lookup returns the index of the first field matching name, false if none does. See fieldByGraphQLName for exact.
+func (index *fieldIndex) lookup(name string, exact bool) (int, bool) {
+	i, ok := index.names[name]
+	var j int
+	var goName bool
+	if exact {
+		j, goName = index.goNames[name]
+	} else if len(index.folded) > 0 {
+		j, goName = index.lookupFolded(name)
+	}
+	if goName && (!ok || j < i) {
+		return j, true
+	}
+
+	return i, ok
+}
+
+// lookupFolded returns the index of the first field without graphql tag whose name is name under case folding.
+// The ASCII names, the names of the keys of most responses, are folded without allocating.
+func (index *fieldIndex) lookupFolded(name string) (int, bool) {
+	var buf [64]byte
+	if len(name) > len(buf) || !isASCII(name) {
+		i, ok := index.folded[foldName(name)]
+
+		return i, ok
+	}
+
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		buf[i] = c
+	}
+	i, ok := index.folded[string(buf[:len(name)])]
+
+	return i, ok
+}
+
+// isASCII reports whether s has ASCII characters only.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+
+	return true
+}
+
+// graphQLName returns the name of the field of the graphql tag value, like user for `graphql:"user(id: $id),nonnull"`,
+// false for a fragment, which has no name.
+func graphQLName(value string) (string, bool) {
+	value, _ = splitGraphQLTag(value)
+	value = strings.TrimSpace(value) // TODO: Parse better.
+	if strings.HasPrefix(value, "...") {
+		// GraphQL fragment. It doesn't have a name.
+		return "", false
+	}
+	if i := strings.Index(value, "("); i != -1 {
+		value = value[:i]
+	}
+	if i := strings.Index(value, ":"); i != -1 {
+		value = value[:i]
+	}
+
+	return strings.TrimSpace(value), true
+}
+
+// foldName returns the name folded as strings.EqualFold compares the names, the names equal under case folding
+// having the same folded name, the ASCII names being lower cased.
+func foldName(name string) string {
+	return strings.ToLower(strings.ToUpper(name))
+}
@@ -28,20 +28,21 @@ package graphqljson
 
 import (
 	"bytes"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"reflect"
 	"strings"
+	"time"
 )
 
 var (
-	objectBeginToken   = json.Delim('{')
-	objectEndToken     = json.Delim('}')
-	arrayBeginToken    = json.Delim('[')
-	arrayEndToken      = json.Delim(']')
-	mapStringInterface = reflect.TypeOf(map[string]interface{}{})
+	objectBeginToken = json.Delim('{')
+	objectEndToken   = json.Delim('}')
+	arrayBeginToken  = json.Delim('[')
+	arrayEndToken    = json.Delim(']')
 )
 
 // Reference: https://blog.gopheracademy.com/advent-2017/custom-json-unmarshaler-for-graphql-client/
@@ -50,10 +51,66 @@ var (
 // the result in the GraphQL query data structure pointed to by v.
 //
 // The implementation is created on top of the JSON tokenizer available
-// in "encoding/json".Decoder.
-func UnmarshalData(data json.RawMessage, v interface{}) error {
-	d := newDecoder(bytes.NewBuffer(data))
-	if err := d.Decode(v); err != nil {
+// in "encoding/json".Decoder. A v implementing ResponseUnmarshaler decodes the data itself.
+// A leading UTF-8 byte order mark, written by some gateways, is trimmed, see TrimBOM.
+func UnmarshalData(data json.RawMessage, v interface{}, options ...Option) error {
+	data = TrimBOM(data)
+
+	return newDecoder(bytes.NewBuffer(data)).unmarshalData(data, v, options)
+}
+
+// Unmarshal is UnmarshalData returning the value decoded from data rather than storing it in a pointer,
+// like Unmarshal[GetUser](data) for a struct, Unmarshal[[]*User](data) for a list or Unmarshal[*GetUser](data) for a pointer.
+// The value is returned along with the error, holding what was decoded before it, like the elements decoded
+// along the ElementErrors of lenient lists.
+func Unmarshal[T any](data json.RawMessage, options ...Option) (T, error) {
+	var v T
+	err := UnmarshalData(data, &v, options...)
+
+	return v, err
+}
+
+// UnmarshalBytes is UnmarshalData for the callers holding the data in a []byte, with the same options and checks.
+// The reader of the data is allocated along with the decoder rather than on its own,
+// which saves an allocation by call on hot paths.
+func UnmarshalBytes(data []byte, v interface{}, options ...Option) error {
+	data = TrimBOM(data)
+	d := &Decoder{}
+	d.data.Reset(data)
+	d.jsonDecoder = json.NewDecoder(&d.data)
+	d.jsonDecoder.UseNumber()
+
+	return d.unmarshalData(data, v, options)
+}
+
+// unmarshalData decodes data, the input of d, into v, checking nothing follows it.
+func (d *Decoder) unmarshalData(data []byte, v interface{}, options []Option) error {
+	for _, option := range options {
+		option(d)
+	}
+
+	if d.comments {
+		stripped, err := StripComments(data)
+		if err != nil {
+			return fmt.Errorf(": %w", err)
+		}
+		data = stripped
+		d.data.Reset(data)
+		d.jsonDecoder = json.NewDecoder(&d.data)
+		d.jsonDecoder.UseNumber()
+	}
+
+	if u, ok := v.(ResponseUnmarshaler); ok {
+		if err := u.UnmarshalGraphQLResponse(data); err != nil {
+			return fmt.Errorf(": %w", err)
+		}
+
+		// skip the value decoded by u to check the tokens after it
+		var value json.RawMessage
+		if err := d.jsonDecoder.Decode(&value); err != nil {
+			return fmt.Errorf(": %w", err)
+		}
+	} else if err := d.Decode(v); err != nil && !errors.As(err, new(ElementErrors)) {
 		return fmt.Errorf(": %w", err)
 	}
 
@@ -63,6 +120,10 @@ func UnmarshalData(data json.RawMessage, v interface{}) error {
 	case io.EOF:
 		// Expect to get io.EOF. There shouldn't be any more
 		// tokens left after we've decoded v successfully.
+		if len(d.elementErrors) > 0 {
+			return d.elementErrors
+		}
+
 		return nil
 	case nil:
 		return fmt.Errorf("invalid token '%v' after top-level value", tok)
@@ -71,23 +132,171 @@ func UnmarshalData(data json.RawMessage, v interface{}) error {
 	return fmt.Errorf("invalid token '%v' after top-level value", tok)
 }
 
+// ResponseUnmarshaler is implemented by the types decoding the response data themselves,
+// like types mapping the data to a structure unlike the query or decoding it with a faster decoder.
+// UnmarshalData passes them the data as is, bypassing the decoding of the fields of the query and the options.
+// Prefer field by field decoding otherwise, it handles aliases, fragments and scalars.
+type ResponseUnmarshaler interface {
+	UnmarshalGraphQLResponse(data json.RawMessage) error
+}
+
+// Option configures the Decoder used by UnmarshalData.
+type Option func(d *Decoder)
+
 // Decoder is a JSON Decoder that performs custom unmarshaling behavior
 // for GraphQL query data structures. It's implemented on top of a JSON tokenizer.
 type Decoder struct {
 	jsonDecoder *json.Decoder
 
+	// Reader of the data decoded by UnmarshalBytes.
+	data bytes.Reader
+
 	// Stack of what part of input JSON we're in the middle of - objects, arrays.
 	parseState []json.Delim
 
+	// First token of the value decoded, read by Decode to check it before decoding the value.
+	first        json.Token
+	pendingFirst bool
+
+	// Position inside each entry of parseState, used to report where an error happened.
+	path []pathElement
+
+	// Keys already seen in the objects of path, each object having the ones from the keys of its path element.
+	// Scanning them is cheaper than a set for the objects of a few keys of most responses.
+	keys []string
+
+	decoderOptions
+
+	// Whether the fields of the data are present with a value rather than null, by path, nil unless presence is recorded.
+	present map[string]bool
+
+	// Bytes of the last string token of a field streamed to a StreamUnmarshaler, reused by the next one.
+	streamed json.RawMessage
+
 	// Stacks of values where to unmarshal.
 	// The top of each stack is the reflect.Value where to unmarshal next JSON value.
 	//
 	// The reason there's more than one stack is because we might be unmarshaling
 	// a single JSON value into multiple GraphQL fragments or embedded structs, so
 	// we keep track of them all.
-	vs [][]reflect.Value
+	vs [][]target
+
+	// Fragments dropped because their type condition does not match the typename of their object,
+	// the keys they have are skipped rather than reported as missing.
+	dropped []target
+
+	// Errors of the values which did not fit the fields of fragments of their object decoded into other fragments,
+	// before the typename of the object told which fragment they belong to.
+	mismatches []fragmentMismatch
+
+	// The array elements being decoded with lenient lists and the errors of the skipped ones.
+	elements      []element
+	elementErrors ElementErrors
 }
 
+// decoderOptions are the settings of a decoder, shared by the decoders of the objects of registered types.
+type decoderOptions struct {
+	// Maximum nesting of objects and arrays, 0 means no limit.
+	maxDepth int
+
+	// Whether the elements of a JSON array longer than the go array it is decoded into are dropped,
+	// instead of failing.
+	truncateArrays bool
+
+	// Whether bool fields accept JSON numbers and the strings "true" and "false".
+	tolerantBools bool
+
+	// Bools of the JSON strings accepted for bool fields, nil if none are.
+	boolMapping map[string]bool
+
+	// Types whose fields decode the empty JSON strings as null.
+	emptyAsNull map[reflect.Type]bool
+
+	// Whether a JSON null fails for the fields tagged nonnull.
+	strictNonNull bool
+
+	// Whether the values of the fields of kinds JSON does not decode into are skipped, instead of failing.
+	skipUnsupported bool
+
+	// Whether the line and block comments of the data decoded by UnmarshalData are stripped.
+	comments bool
+
+	// Whether the fields without graphql, json or protobuf name match the keys of their exact name only.
+	exactMatch bool
+
+	// Whether the typename of an object fails when it is not the one of the typename option of its field.
+	typenameChecks bool
+
+	// Field telling the type of an object, __typename if empty, and the types of the objects
+	// decoded into interface fields, by value of the field.
+	discriminator string
+	types         map[string][]reflect.Type
+
+	// Hook called with the typename of each object decoded into a struct and the struct, nil if not set.
+	onTypename func(typename string, v reflect.Value)
+
+	// Counters of the work of the decoder, nil unless they are collected.
+	stats *DecodeStats
+
+	// Converters for the JSON numbers of fields tagged with a scalar option, by scalar name.
+	scalars map[string]NumberConverter
+
+	// Scalars of the 64-bit integers sent as JSON numbers or strings, by name.
+	int64Scalars map[string]bool
+
+	// Transforms of the scalar values decoded at a path.
+	transforms []transform
+
+	// Pool of the strings decoded into string fields, nil unless strings are interned.
+	strings map[string]string
+
+	// Allocator of the values of nil pointers, reflect.New if nil.
+	allocator func(t reflect.Type) reflect.Value
+
+	// Whether the array elements failing to decode are skipped.
+	lenientLists bool
+
+	// Path of the value decoded in the data of the decoder of which this one decodes an object, empty at the top level.
+	// It prefixes the paths of the transforms and of the errors of the elements of lenient lists.
+	pathPrefix string
+}
+
+// target is a place to unmarshal the next JSON value into.
+type target struct {
+	value reflect.Value
+	// options of the graphql tag of the struct field value belongs to,
+	// the elements of a list field share the options of the field.
+	options tagOptions
+	// typeCondition is the type of a GraphQL fragment, like TypeA for `graphql:"... on TypeA"`,
+	// and depth the parse state depth of the object holding the fragment.
+	typeCondition string
+	depth         int
+	// nonNull is set for a struct field tagged nonnull, like `graphql:"name,nonnull"`,
+	// the elements of a list field do not share it.
+	nonNull bool
+}
+
+// fragmentMismatch is the error of a value not fitting the field of a fragment of the object at depth.
+type fragmentMismatch struct {
+	typeCondition string
+	depth         int
+	err           error
+}
+
+// pathElement is the current key of an object or the current index of an array.
+type pathElement struct {
+	key   string
+	index int
+	// start of the keys of the object in the keys of the decoder
+	keys int
+}
+
+// NewDecoder returns a new decoder that reads from r, skipping its leading UTF-8 byte order mark, see TrimBOM.
+func NewDecoder(r io.Reader) *Decoder {
+	return newDecoder(&bomReader{r: r})
+}
+
+// newDecoder returns a new decoder that reads from r as is.
 func newDecoder(r io.Reader) *Decoder {
 	jsonDecoder := json.NewDecoder(r)
 	jsonDecoder.UseNumber()
@@ -97,6 +306,99 @@ func newDecoder(r io.Reader) *Decoder {
 	}
 }
 
+// SetMaxDepth makes Decode fail when objects and arrays are nested deeper than n.
+// A value of 0 or less disables the limit, which is the default.
+func (d *Decoder) SetMaxDepth(n int) {
+	d.maxDepth = n
+}
+
+// SetTruncateArrays makes Decode drop the elements of JSON arrays which do not fit
+// the fixed-size go arrays they are decoded into. By default it fails.
+func (d *Decoder) SetTruncateArrays(truncate bool) {
+	d.truncateArrays = truncate
+}
+
+// SetTolerantBools makes Decode accept for bool fields the JSON numbers, 0 being false and any other number true,
+// and the JSON strings "true" and "false", as returned by some non-conformant servers.
+// It is off by default as it hides servers returning wrong values. The bool types decoding themselves are left to their UnmarshalJSON.
+func (d *Decoder) SetTolerantBools(tolerant bool) {
+	d.tolerantBools = tolerant
+}
+
+// SetStrictNonNull makes Decode fail on a JSON null for the struct fields tagged with the nonnull option,
+// like `graphql:"name,nonnull"` generated for the fields the schema declares non-null,
+// to catch servers breaking their schema. It is off by default.
+func (d *Decoder) SetStrictNonNull(strict bool) {
+	d.strictNonNull = strict
+}
+
+// SetAllocator makes Decode allocate the values of the nil pointers it sets with allocate instead of reflect.New,
+// like from a pool or an arena, to reduce the allocations of decoding pointer-heavy responses.
+// allocate must return a pointer to a zero value of t, it is not called for the values decoded by encoding/json,
+// like maps and interface{}, nor by sql.Scanner. A nil allocate restores reflect.New, which is the default.
+func (d *Decoder) SetAllocator(allocate func(t reflect.Type) reflect.Value) {
+	d.allocator = allocate
+}
+
+// WithAllocator makes UnmarshalData allocate the values of nil pointers with allocate, see Decoder.SetAllocator.
+func WithAllocator(allocate func(t reflect.Type) reflect.Value) Option {
+	return func(d *Decoder) {
+		d.SetAllocator(allocate)
+	}
+}
+
+// SetExactMatch makes Decode match the struct fields without graphql, json or protobuf tag naming them
+// to the keys of their exact name only, like Url to "Url" but not to "url" or "uRL",
+// so that keys differing only in case are not decoded into the same field. By default they match in any case.
+func (d *Decoder) SetExactMatch(exact bool) {
+	d.exactMatch = exact
+}
+
+// WithExactMatch makes UnmarshalData match the untagged struct fields to the keys of their exact name,
+// see Decoder.SetExactMatch.
+func WithExactMatch() Option {
+	return func(d *Decoder) {
+		d.SetExactMatch(true)
+	}
+}
+
+// SetTypenameChecks makes Decode fail when the __typename of an object is not the type given by the typename option
+// of its field, like `graphql:"user,typename=User"` generated for the fields of object types, to catch servers
+// returning the wrong type for a field. The objects which do not select __typename are not checked. It is off by default.
+func (d *Decoder) SetTypenameChecks(check bool) {
+	d.typenameChecks = check
+}
+
+// WithTypenameChecks makes UnmarshalData check the __typename of the objects of the fields tagged typename,
+// see Decoder.SetTypenameChecks.
+func WithTypenameChecks() Option {
+	return func(d *Decoder) {
+		d.SetTypenameChecks(true)
+	}
+}
+
+// WithStrictNonNull makes UnmarshalData fail on null for the fields tagged nonnull, see Decoder.SetStrictNonNull.
+func WithStrictNonNull() Option {
+	return func(d *Decoder) {
+		d.SetStrictNonNull(true)
+	}
+}
+
+// WithTolerantBools makes UnmarshalData accept numbers and strings for bool fields, see Decoder.SetTolerantBools.
+func WithTolerantBools() Option {
+	return func(d *Decoder) {
+		d.SetTolerantBools(true)
+	}
+}
+
+// WithTruncateArrays makes UnmarshalData drop the elements of JSON arrays which do not fit
+// the fixed-size go arrays they are decoded into, see Decoder.SetTruncateArrays.
+func WithTruncateArrays() Option {
+	return func(d *Decoder) {
+		d.SetTruncateArrays(true)
+	}
+}
+
 func followPtr(v reflect.Value) reflect.Value {
 	if v.Kind() == reflect.Ptr {
 		v = v.Elem()
@@ -119,28 +421,55 @@ func (d *Decoder) Decode(v interface{}) error {
 		return fmt.Errorf("cannot decode into non-pointer %T", v)
 	}
 
-	d.vs = [][]reflect.Value{{rv.Elem()}}
+	// a map or interface{} holding the whole value is decoded at once, as a field of these types
+	if decoded, err := d.decodeDynamic(v); decoded {
+		if err != nil {
+			return fmt.Errorf(": %w", err)
+		}
+
+		return nil
+	}
+
+	if err := d.readFirst(rv.Elem().Type()); err != nil {
+		return fmt.Errorf(": %w", err)
+	}
+
+	d.vs = [][]target{{{value: rv.Elem()}}}
+	d.elements, d.elementErrors = nil, nil
 	if err := d.decode(); err != nil {
 		return fmt.Errorf(": %w", err)
 	}
+	if len(d.elementErrors) > 0 {
+		return d.elementErrors
+	}
 
 	return nil
 }
 
-// decode decodes a single JSON value from d.tokenizer into d.vs.
+// decode decodes a single JSON value from d.tokenizer into d.vs, going on after the malformed elements of lenient lists.
 func (d *Decoder) decode() error {
+	for {
+		err := d.decodeTokens()
+		if err == nil {
+			return nil
+		}
+		if err := d.recoverElement(err); err != nil {
+			return err
+		}
+	}
+}
+
+// decodeTokens decodes the tokens of the JSON value from d.tokenizer into d.vs, from where it is.
+func (d *Decoder) decodeTokens() error {
 	// The loop invariant is that the top of each d.vs stack
 	// is where we try to unmarshal the next JSON value we see.
 	// var customField reflect.Value
 	// var unmarshalJSON reflect.Value
 loop:
 	for len(d.vs) > 0 {
-		tok, err := d.jsonDecoder.Token()
-
-		if err == io.EOF {
-			return errors.New("unexpected end of JSON input")
-		} else if err != nil {
-			return fmt.Errorf(": %w", err)
+		tok, err := d.token()
+		if err != nil {
+			return d.readError(err)
 		}
 
 		switch {
@@ -150,98 +479,220 @@ loop:
 			if !ok {
 				return errors.New("unexpected non-key in JSON input")
 			}
-			someFieldExist := false
-			continueLoop := false
+			d.path[len(d.path)-1].key = key
+			// A duplicated key replaces the value of the previous one, last wins.
+			duplicated := d.seeKey(key)
+			someFieldExist, unsupported := false, false
+			// the fields decoded at once, every place matching the key getting the value
+			var dynamicFields, dispatchedFields, streamedFields []reflect.Value
+			fields := make([]target, len(d.vs))
 			for i, dv := range d.vs {
-				v := followPtr(dv[len(dv)-1])
-				var f reflect.Value
-				switch v.Kind() {
-				case reflect.Struct:
-					f = fieldByGraphQLName(v, key)
-					if f.IsValid() {
-						someFieldExist = true
-
-						switch f.Kind() {
-						case reflect.Map:
-							f.Set(reflect.MakeMap(mapStringInterface))
-							if err := d.jsonDecoder.Decode(f.Addr().Interface()); err != nil {
-								return fmt.Errorf(": %w", err)
-							}
-							continueLoop = true
-						default:
-							d.vs[i] = append(dv, f)
-						}
+				v := followPtr(dv[len(dv)-1].value)
+				if v.Kind() != reflect.Struct {
+					continue
+				}
+				f, options := fieldByGraphQLName(v, key, d.exactMatch)
+				if !f.IsValid() {
+					continue
+				}
+				if kind, ok := unsupportedKind(f.Type()); ok {
+					if !d.skipUnsupported {
+						return fmt.Errorf("%v at %q: cannot decode into a field of kind %v", f.Type(), d.currentPath(), kind)
 					}
+					unsupported = true
+
+					continue
+				}
+				someFieldExist = true
+				if d.stats != nil {
+					d.stats.Fields++
+				}
+				if d.isDispatched(f) {
+					dispatchedFields = append(dispatchedFields, f)
+				} else if isStreamed(f.Type()) {
+					streamedFields = append(streamedFields, f)
+				} else if isDynamic(f.Type()) {
+					dynamicFields = append(dynamicFields, f)
 				}
+				_, nonNull := options["nonnull"]
+				fields[i] = target{value: f, options: options, nonNull: nonNull}
+			}
+
+			// The value of a skipped field, or of a field of a dropped fragment, is skipped.
+			if !someFieldExist && (unsupported || d.droppedFragmentHas(key)) {
+				var skipped json.RawMessage
+				if err := d.jsonDecoder.Decode(&skipped); err != nil {
+					return d.readError(err)
+				}
+				d.countToken()
+				d.recordPresence(isNull(skipped))
+
+				continue loop
 			}
 
 			if !someFieldExist {
 				return fmt.Errorf("struct field for %q doesn't exist in any of %v places to unmarshal", key, len(d.vs))
 			}
 
-			if continueLoop {
+			// An interface field is decoded at once into the type registered for the discriminator of the object.
+			if len(dispatchedFields) > 0 {
+				if err := d.decodeDispatched(dispatchedFields); err != nil {
+					return err
+				}
+
+				continue loop
+			}
+
+			// A StreamUnmarshaler field receives its string as a stream, from the bytes of the token.
+			if len(streamedFields) > 0 {
+				if err := d.decodeStreamed(streamedFields); err != nil {
+					return err
+				}
+
 				continue loop
 			}
 
+			// A map or interface{} field receives the whole JSON value at once, its numbers as json.Number.
+			if len(dynamicFields) > 0 {
+				if err := d.decodeDynamicFields(dynamicFields); err != nil {
+					return err
+				}
+
+				continue loop
+			}
+
+			for i, f := range fields {
+				if duplicated && f.value.IsValid() {
+					f.value.Set(reflect.Zero(f.value.Type()))
+				}
+				d.vs[i] = append(d.vs[i], f)
+			}
+
 			// We've just consumed the current token, which was the key.
 			// Read the next token, which should be the value, and let the rest of code process it.
 			tok, err = d.jsonDecoder.Token()
+			if err != nil {
+				return d.readError(err)
+			}
+			d.countToken()
+			d.recordPresence(tok == nil)
 
-			if err == io.EOF {
-				return errors.New("unexpected end of JSON input")
-			} else if err != nil {
-				return fmt.Errorf(": %w", err)
+			if typename, ok := tok.(string); ok && key == d.discriminatorField() {
+				if err := d.checkTypename(typename); err != nil {
+					return err
+				}
+				if err := d.checkMismatches(typename); err != nil {
+					return err
+				}
+				d.callOnTypename(typename)
+				d.dropFragments(typename)
 			}
 		// Are we inside an array and seeing next value (rather than end of array)?
 		case d.insideArray(tok):
+			index := d.path[len(d.path)-1].index + 1
+			d.path[len(d.path)-1].index = index
 			someSliceExist := false
+			truncated := false
+			var vs [][]target
+			if d.lenientLists {
+				// the previous element is done, the stacks are back to the array
+				d.endElements(len(d.parseState))
+				vs = append(vs, d.vs...)
+			}
 			for i, dv := range d.vs {
-				v := followPtr(dv[len(dv)-1])
-				var f reflect.Value
-				if v.Kind() == reflect.Slice {
+				top := dv[len(dv)-1]
+				v := followPtr(top.value)
+				var f target
+				switch v.Kind() {
+				case reflect.Slice:
 					v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem()))) // v = append(v, T).
-					f = v.Index(v.Len() - 1)
+					f = target{value: v.Index(v.Len() - 1), options: top.options}
+					someSliceExist = true
+				case reflect.Array:
+					if index >= v.Len() {
+						if !d.truncateArrays {
+							return d.skipElement(tok, fmt.Errorf("JSON array longer than %v at %q", v.Type(), d.currentPath()))
+						}
+						truncated = true
+
+						break
+					}
+					f = target{value: v.Index(index), options: top.options}
 					someSliceExist = true
 				}
 				d.vs[i] = append(dv, f)
 			}
+			if !someSliceExist && truncated {
+				// no place left for the element, skip it
+				if err := d.skipValue(tok); err != nil {
+					return err
+				}
+				d.popAllVs()
+
+				continue loop
+			}
 			if !someSliceExist {
-				return fmt.Errorf("slice doesn't exist in any of %v places to unmarshal", len(d.vs))
+				return d.skipElement(tok, fmt.Errorf("slice doesn't exist in any of %v places to unmarshal", len(d.vs)))
+			}
+			if d.lenientLists {
+				d.beginElement(vs)
 			}
 		}
 
 		switch tok := tok.(type) {
 		case string, json.Number, bool, nil:
 			// Value.
+			// A value not fitting the field of a fragment, like a field of the same name and another type
+			// in the fragment on another member of a union, drops the fragment when it fits another one.
+			var mismatches []fragmentMismatch
+			decoded := false
 			for _, dv := range d.vs {
-				v := dv[len(dv)-1]
-				if !v.IsValid() {
+				top := dv[len(dv)-1]
+				if !top.value.IsValid() {
+					continue
+				}
+				if err := d.unmarshalValue(tok, top); err != nil {
+					if !d.isObjectFragment(dv) {
+						return fmt.Errorf(": %w", err)
+					}
+					mismatches = append(mismatches, fragmentMismatch{typeCondition: dv[0].typeCondition, depth: dv[0].depth, err: err})
+
 					continue
 				}
-				if err := unmarshalValue(tok, v); err != nil {
-					return fmt.Errorf(": %w", err)
+				decoded = true
+				if tok != nil {
+					d.transformValue(top.value)
 				}
 			}
+			if len(mismatches) > 0 {
+				if !decoded {
+					return fmt.Errorf(": %w", mismatches[0].err)
+				}
+				d.dropMismatches(mismatches)
+			}
 			d.popAllVs()
 
 		case json.Delim:
 			switch tok {
 			case objectBeginToken:
 				// Start of object.
-				d.pushState(tok)
-				frontier := make([]reflect.Value, len(d.vs)) // Places to look for GraphQL fragments/embedded structs.
+				if err := d.pushState(tok); err != nil {
+					return err
+				}
+				frontier := make([]target, len(d.vs)) // Places to look for GraphQL fragments/embedded structs.
 				for i, dv := range d.vs {
-					v := dv[len(dv)-1]
-					frontier[i] = v
-					// TODO: Do this recursively or not? Add a test case if needed.
+					v := dv[len(dv)-1].value
+					frontier[i] = target{value: v}
+					// a nil pointer is allocated, and so are the embedded pointers found below by the frontier
 					if v.Kind() == reflect.Ptr && v.IsNil() {
-						v.Set(reflect.New(v.Type().Elem())) // v = new(T).
+						v.Set(d.newValue(v.Type().Elem())) // v = new(T).
 					}
 				}
 				// Find GraphQL fragments/embedded structs recursively, adding to frontier
 				// as new ones are discovered and exploring them further.
 				for len(frontier) > 0 {
-					v := followPtr(frontier[0])
+					parent := frontier[0]
+					v := followPtr(parent.value)
 					frontier = frontier[1:]
 					if v.Kind() != reflect.Struct {
 						continue
@@ -249,33 +700,54 @@ loop:
 					for i := 0; i < v.NumField(); i++ {
 						tf := v.Type().Field(i)
 						if isGraphQLFragment(tf) || tf.Anonymous {
-							f := v.Field(i)
-							// Add GraphQL fragment or embedded struct.
-							d.vs = append(d.vs, []reflect.Value{f})
+							// Add GraphQL fragment or embedded struct,
+							// an embedded struct of a fragment belongs to the fragment type.
+							f := target{value: v.Field(i), typeCondition: parent.typeCondition, depth: len(d.parseState)}
+							if condition := fragmentTypeCondition(tf); condition != "" {
+								f.typeCondition = condition
+							}
+							// an embedded pointer, like *AuditableFragment, is allocated to explore its fields,
+							// and reset to nil with the fragment when the fragment is dropped
+							if f.value.Kind() == reflect.Ptr && f.value.IsNil() && f.value.Type().Elem().Kind() == reflect.Struct && f.value.CanSet() {
+								f.value.Set(d.newValue(f.value.Type().Elem())) // v = new(T).
+							}
+							d.vs = append(d.vs, []target{f})
 							frontier = append(frontier, f)
 						}
 					}
 				}
 			case arrayBeginToken:
 				// Start of array.
-
-				d.pushState(tok)
+				if err := d.pushState(tok); err != nil {
+					return err
+				}
 
 				for _, dv := range d.vs {
-					v := followPtr(dv[len(dv)-1])
-					// TODO: Confirm this is needed, write a test case.
-					// if v.Kind() == reflect.Ptr && v.IsNil() {
-					//	v.Set(reflect.New(v.Type().Elem())) // v = new(T).
-					//}
-
-					// Reset slice to empty (in case it had non-zero initial value).
-					if v.Kind() != reflect.Slice {
-						continue
+					// a pointer to an array, like *[3]float64 for a nullable list of fixed length, is allocated
+					v := dv[len(dv)-1].value
+					if v.Kind() == reflect.Ptr && v.IsNil() && v.Type().Elem().Kind() == reflect.Array {
+						v.Set(d.newValue(v.Type().Elem())) // v = new(T).
+					}
+					v = followPtr(v)
+
+					// Reset slice to empty (in case it had non-zero initial value),
+					// and array to zero values for the elements missing from a shorter JSON array.
+					switch v.Kind() {
+					case reflect.Slice:
+						v.Set(reflect.MakeSlice(v.Type(), 0, 0)) // v = make(T, 0, 0).
+					case reflect.Array:
+						v.Set(reflect.Zero(v.Type()))
 					}
-					v.Set(reflect.MakeSlice(v.Type(), 0, 0)) // v = make(T, 0, 0).
 				}
 			case objectEndToken, arrayEndToken:
 				// End of object or array.
+				if tok == objectEndToken {
+					if err := d.setDefaults(); err != nil {
+						return err
+					}
+				} else if err := d.checkFixedLength(); err != nil {
+					return err
+				}
 				d.popAllVs()
 				d.popState()
 			default:
@@ -289,15 +761,233 @@ loop:
 	return nil
 }
 
+// checkTypename checks typename, the value of the discriminator of the current object,
+// is the type of the typename option of the field of the object when typenames are checked.
+func (d *Decoder) checkTypename(typename string) error {
+	if !d.typenameChecks {
+		return nil
+	}
+
+	// the field of the object is below the field of the typename
+	for _, dv := range d.vs {
+		if len(dv) < 2 {
+			continue
+		}
+		if want := dv[len(dv)-2].options["typename"]; want != "" && want != typename {
+			return fmt.Errorf("unexpected %s %q at %q, want %s", d.discriminatorField(), typename, d.currentPath(), want)
+		}
+	}
+
+	return nil
+}
+
+// checkFixedLength checks the current array, which ends, has the length of the go arrays it is decoded into
+// whose field is tagged with the fixed option, like `graphql:"coordinates,fixed"`.
+func (d *Decoder) checkFixedLength() error {
+	length := d.path[len(d.path)-1].index + 1
+	for _, dv := range d.vs {
+		top := dv[len(dv)-1]
+		if _, fixed := top.options["fixed"]; !fixed {
+			continue
+		}
+		if v := followPtr(top.value); v.Kind() == reflect.Array && v.Len() != length {
+			return fmt.Errorf("JSON array of %d elements for %v at %q", length, v.Type(), d.currentPath())
+		}
+	}
+
+	return nil
+}
+
+// dropFragments stops decoding into the fragments of the current object
+// whose type condition does not match its typename, and resets them.
+// The fragments are kept when none of them is on the typename,
+// as the type conditions may be interfaces the decoder knows nothing about.
+func (d *Decoder) dropFragments(typename string) {
+	matched := false
+	for _, dv := range d.vs {
+		if d.isObjectFragment(dv) && dv[0].typeCondition == typename {
+			matched = true
+		}
+	}
+	if !matched {
+		return
+	}
+
+	d.drop(func(typeCondition string) bool {
+		return typeCondition != typename
+	})
+}
+
+// isObjectFragment reports whether the stack dv is a fragment of the current object with a type condition.
+// The fragments of the current object are at the bottom of their stacks, below the value of the current key.
+func (d *Decoder) isObjectFragment(dv []target) bool {
+	return len(dv) == 2 && dv[0].typeCondition != "" && dv[0].depth == len(d.parseState)
+}
+
+// drop stops decoding into the fragments of the current object whose type condition is dropped, and resets them.
+func (d *Decoder) drop(dropped func(typeCondition string) bool) {
+	vs := d.vs[:0]
+	for _, dv := range d.vs {
+		if d.isObjectFragment(dv) && dropped(dv[0].typeCondition) {
+			dv[0].value.Set(reflect.Zero(dv[0].value.Type()))
+			d.dropped = append(d.dropped, dv[0])
+
+			continue
+		}
+		vs = append(vs, dv)
+	}
+	d.vs = vs
+}
+
+// dropMismatches drops the fragments of the current object a value did not fit, along with their embedded structs.
+func (d *Decoder) dropMismatches(mismatches []fragmentMismatch) {
+	d.mismatches = append(d.mismatches, mismatches...)
+	d.drop(func(typeCondition string) bool {
+		for _, m := range mismatches {
+			if m.typeCondition == typeCondition {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// checkMismatches returns the error of a value which did not fit the fragment of the current object on typename,
+// the fragment being dropped while the value was decoded into another one.
+func (d *Decoder) checkMismatches(typename string) error {
+	for _, m := range d.mismatches {
+		if m.depth == len(d.parseState) && m.typeCondition == typename {
+			return fmt.Errorf(": %w", m.err)
+		}
+	}
+
+	return nil
+}
+
+// seeKey records key in the current object and reports whether it was already in it.
+func (d *Decoder) seeKey(key string) bool {
+	if d.hasKey(key) {
+		return true
+	}
+	d.keys = append(d.keys, key)
+
+	return false
+}
+
+// hasKey reports whether the current object has key.
+func (d *Decoder) hasKey(key string) bool {
+	for _, k := range d.keys[d.path[len(d.path)-1].keys:] {
+		if k == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// droppedFragmentHas reports whether a fragment dropped from the current object has a field for key.
+func (d *Decoder) droppedFragmentHas(key string) bool {
+	for _, f := range d.dropped {
+		if f.depth != len(d.parseState) {
+			continue
+		}
+		v := followPtr(f.value)
+		if v.Kind() != reflect.Struct {
+			continue
+		}
+		if field, _ := fieldByGraphQLName(v, key, d.exactMatch); field.IsValid() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// skipValue consumes the rest of the JSON value starting with tok.
+func (d *Decoder) skipValue(tok json.Token) error {
+	if tok != objectBeginToken && tok != arrayBeginToken {
+		return nil
+	}
+
+	for depth := 1; depth > 0; {
+		tok, err := d.jsonDecoder.Token()
+		if err != nil {
+			return d.readError(err)
+		}
+		d.countToken()
+
+		switch tok {
+		case objectBeginToken, arrayBeginToken:
+			depth++
+		case objectEndToken, arrayEndToken:
+			depth--
+		}
+	}
+
+	return nil
+}
+
 // pushState pushes a new parse state s onto the stack.
-func (d *Decoder) pushState(s json.Delim) {
+// It fails when the maximum depth would be exceeded.
+func (d *Decoder) pushState(s json.Delim) error {
+	if d.maxDepth > 0 && len(d.parseState) >= d.maxDepth {
+		return &inputError{err: fmt.Errorf("maximum depth %d exceeded at %q", d.maxDepth, d.currentPath())}
+	}
+
 	d.parseState = append(d.parseState, s)
+	d.path = append(d.path, pathElement{index: -1, keys: len(d.keys)})
+
+	return nil
 }
 
 // popState pops a parse state (already obtained) off the stack.
 // The stack must be non-empty.
 func (d *Decoder) popState() {
 	d.parseState = d.parseState[:len(d.parseState)-1]
+	d.keys = d.keys[:d.path[len(d.path)-1].keys]
+	d.path = d.path[:len(d.path)-1]
+
+	dropped := d.dropped[:0]
+	for _, f := range d.dropped {
+		if f.depth <= len(d.parseState) {
+			dropped = append(dropped, f)
+		}
+	}
+	d.dropped = dropped
+
+	mismatches := d.mismatches[:0]
+	for _, m := range d.mismatches {
+		if m.depth <= len(d.parseState) {
+			mismatches = append(mismatches, m)
+		}
+	}
+	d.mismatches = mismatches
+	d.endElements(len(d.parseState) + 1)
+}
+
+// currentPath returns the path of the value being decoded, like "user.friends[2].name".
+// The path of an object or array before its first key or element is its own.
+func (d *Decoder) currentPath() string {
+	var b strings.Builder
+	for i, e := range d.path {
+		if d.parseState[i] == arrayBeginToken {
+			if e.index >= 0 {
+				fmt.Fprintf(&b, "[%d]", e.index)
+			}
+
+			continue
+		}
+		if e.key == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(e.key)
+	}
+
+	return b.String()
 }
 
 // state reports the parse state on top of stack, or 0 if empty.
@@ -311,7 +1001,7 @@ func (d *Decoder) state() json.Delim {
 
 // popAllVs pops from all d.vs stacks, keeping only non-empty ones.
 func (d *Decoder) popAllVs() {
-	var nonEmpty [][]reflect.Value
+	var nonEmpty [][]target
 	for _, dv := range d.vs {
 		dv = dv[:len(dv)-1]
 		if len(dv) > 0 {
@@ -322,60 +1012,304 @@ func (d *Decoder) popAllVs() {
 }
 
 // fieldByGraphQLName returns an exported struct field of struct v
-// that matches GraphQL name, or invalid reflect.Value if none found.
-func fieldByGraphQLName(v reflect.Value, name string) reflect.Value {
-	for i := 0; i < v.NumField(); i++ {
-		f := v.Type().Field(i)
-		if f.PkgPath != "" {
-			// Skip unexported field.
+// that matches GraphQL name along with its tag options,
+// or invalid reflect.Value if none found, the first one when several fields match.
+// A field without graphql tag is matched by the name of its json tag, then by the json name
+// and the name of its protobuf tag as in protobuf generated structs, then by its own name,
+// in any case unless exact. A field named Typename or TypeName also matches __typename.
+// The fields are looked up in the field index of the type of v, built once.
+//
+// The oneof fields of protobuf generated structs are not supported, they are interfaces
+// whose implementations the decoder cannot find.
+func fieldByGraphQLName(v reflect.Value, name string, exact bool) (reflect.Value, tagOptions) {
+	index := fieldIndexOf(v.Type())
+	i, ok := index.lookup(name, exact)
+	if !ok {
+		return reflect.Value{}, nil
+	}
+
+	return v.Field(i), index.options[i]
+}
+
+// tagOptions are the options following the name in a graphql tag,
+// like `graphql:"createdAt,scalar=EpochMillis"`. Options without a value map to "".
+type tagOptions map[string]string
+
+// splitGraphQLTag splits a graphql tag value into the field part, name and arguments,
+// and the options which follow it.
+func splitGraphQLTag(value string) (string, tagOptions) {
+	// arguments may contain commas, options start after them
+	end := strings.LastIndex(value, ")") + 1
+	i := strings.Index(value[end:], ",")
+	if i == -1 {
+		return value, nil
+	}
+
+	options := make(tagOptions)
+	for _, option := range strings.Split(value[end+i+1:], ",") {
+		option = strings.TrimSpace(option)
+		if option == "" {
 			continue
 		}
-		if hasGraphQLName(f, name) {
-			return v.Field(i)
+		key, val := option, ""
+		if j := strings.Index(option, "="); j != -1 {
+			key, val = option[:j], option[j+1:]
 		}
+		options[key] = val
 	}
 
-	return reflect.Value{}
+	return value[:end+i], options
 }
 
-// hasGraphQLName reports whether struct field f has GraphQL name.
-func hasGraphQLName(f reflect.StructField, name string) bool {
+// isGraphQLFragment reports whether struct field f is a GraphQL fragment.
+func isGraphQLFragment(f reflect.StructField) bool {
 	value, ok := f.Tag.Lookup("graphql")
 	if !ok {
-		// TODO: caseconv package is relatively slow. Optimize it, then consider using it here.
-		// return caseconv.MixedCapsToLowerCamelCase(f.Name) == name
-		return strings.EqualFold(f.Name, name)
+		return false
 	}
 	value = strings.TrimSpace(value) // TODO: Parse better.
-	if strings.HasPrefix(value, "...") {
-		// GraphQL fragment. It doesn't have a name.
-		return false
+
+	return strings.HasPrefix(value, "...")
+}
+
+// fragmentTypeCondition returns the type condition of GraphQL fragment field f,
+// like TypeA for `graphql:"... on TypeA"`, or "" if f is not an inline fragment.
+func fragmentTypeCondition(f reflect.StructField) string {
+	value := strings.TrimSpace(f.Tag.Get("graphql"))
+	if !strings.HasPrefix(value, "...") {
+		return ""
+	}
+	fields := strings.Fields(strings.TrimPrefix(value, "..."))
+	if len(fields) < 2 || fields[0] != "on" {
+		return ""
+	}
+
+	return fields[1]
+}
+
+// unmarshalValue unmarshals JSON value into t, JSON numbers of fields having a scalar
+// option go through the converter registered for this scalar, and the values of 64-bit integer scalars
+// are parsed as such. The types decoding themselves get their UnmarshalJSON called, a nil pointer being allocated.
+func (d *Decoder) unmarshalValue(value json.Token, t target) error {
+	emptyAsNull := d.isEmptyAsNull(value, t.value.Type())
+	if (value == nil || emptyAsNull) && t.nonNull && d.strictNonNull {
+		return fmt.Errorf("null for non-null field at %q", d.currentPath())
+	}
+	if emptyAsNull {
+		t.value.Set(reflect.Zero(t.value.Type()))
+
+		return nil
 	}
-	if i := strings.Index(value, "("); i != -1 {
-		value = value[:i]
+
+	if name := t.options["scalar"]; value != nil && d.int64Scalars[name] && !decodesItself(t.value.Type()) {
+		if err := d.unmarshalInt64(value, t.value); err != nil {
+			return fmt.Errorf("scalar %s at %q: %w", name, d.currentPath(), err)
+		}
+
+		return nil
 	}
-	if i := strings.Index(value, ":"); i != -1 {
-		value = value[:i]
+
+	if n, ok := value.(json.Number); ok {
+		if convert, ok := d.scalars[t.options["scalar"]]; ok {
+			v := t.value
+			if v.Kind() == reflect.Ptr {
+				if v.IsNil() {
+					v.Set(d.newValue(v.Type().Elem())) // v = new(T).
+				}
+				v = v.Elem()
+			}
+
+			if err := convert(n, v); err != nil {
+				return fmt.Errorf("scalar %s: %w", t.options["scalar"], err)
+			}
+
+			return nil
+		}
+	}
+
+	if ok, err := d.decodeMappedBool(value, t); ok {
+		return err
 	}
 
-	return strings.TrimSpace(value) == name
+	if d.tolerantBools && !decodesItself(t.value.Type()) {
+		if b, ok := tolerantBool(value, t.value); ok {
+			v := t.value
+			if v.Kind() == reflect.Ptr {
+				if v.IsNil() {
+					v.Set(d.newValue(v.Type().Elem())) // v = new(T).
+				}
+				v = v.Elem()
+			}
+			v.SetBool(b)
+
+			return nil
+		}
+	}
+
+	if s, ok := scanner(t.value, value == nil); ok {
+		if err := scanValue(s, value); err != nil {
+			return fmt.Errorf("%v at %q: %w", t.value.Type(), d.currentPath(), err)
+		}
+
+		return nil
+	}
+
+	if n, ok := value.(json.Number); ok {
+		if ok, err := d.unmarshalWholeNumber(n, t.value); ok {
+			if err != nil {
+				return fmt.Errorf("%v at %q: %w", t.value.Type(), d.currentPath(), err)
+			}
+
+			return d.checkEnum(t.value)
+		}
+	}
+
+	if s, ok := value.(string); ok && d.strings != nil && d.internString(s, t.value) {
+		return nil
+	}
+
+	if value != nil && d.allocator != nil && t.value.Kind() == reflect.Ptr && t.value.IsNil() {
+		// encoding/json decodes into the value pointed, rather than into a new one
+		t.value.Set(d.newValue(t.value.Type().Elem())) // v = new(T).
+	}
+
+	if err := unmarshalValue(value, t.value); err != nil {
+		return err
+	}
+	if _, ok := value.(json.Number); ok {
+		return d.checkEnum(t.value)
+	}
+
+	return nil
 }
 
-// isGraphQLFragment reports whether struct field f is a GraphQL fragment.
-func isGraphQLFragment(f reflect.StructField) bool {
-	value, ok := f.Tag.Lookup("graphql")
-	if !ok {
-		return false
+// newValue returns a pointer to a new zero value of typ, from the allocator if set.
+func (d *Decoder) newValue(typ reflect.Type) reflect.Value {
+	if d.stats != nil {
+		d.stats.Allocations++
+	}
+	if d.allocator != nil {
+		return d.allocator(typ)
 	}
-	value = strings.TrimSpace(value) // TODO: Parse better.
 
-	return strings.HasPrefix(value, "...")
+	return reflect.New(typ)
+}
+
+var (
+	scannerType       = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	jsonUnmarshalType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// decodesItself reports whether the values of typ, or the ones pointed by typ, decode themselves
+// as json.Unmarshaler or encoding.TextUnmarshaler, which unmarshalValue calls on a nil pointer allocated by encoding/json.
+func decodesItself(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	ptr := reflect.PtrTo(typ)
+
+	return ptr.Implements(jsonUnmarshalType) || ptr.Implements(textUnmarshalerType)
+}
+
+// scanner returns the sql.Scanner of v for the types decoded by scanning the JSON value,
+// like sql.NullString, which are not json.Unmarshaler. A nil pointer v is allocated unless the value is null,
+// a null pointer being left to unmarshalValue to set nil.
+func scanner(v reflect.Value, null bool) (sql.Scanner, bool) {
+	typ := v.Type()
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	ptr := reflect.PtrTo(typ)
+	if !ptr.Implements(scannerType) || ptr.Implements(jsonUnmarshalType) {
+		return nil, false
+	}
+
+	if v.Kind() == reflect.Ptr {
+		if null {
+			return nil, false
+		}
+		if v.IsNil() {
+			// a Scanner is allocated with reflect.New rather than by the allocator, see SetAllocator
+			v.Set(reflect.New(typ)) // v = new(T).
+		}
+
+		return v.Interface().(sql.Scanner), true
+	}
+
+	return v.Addr().Interface().(sql.Scanner), true
+}
+
+// scanValue scans JSON value into s, a JSON number as an int64 if it is an integer or a float64,
+// and a JSON string as a string or else as an RFC 3339 time like sql.NullTime expects.
+func scanValue(s sql.Scanner, value json.Token) error {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return s.Scan(n)
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return fmt.Errorf(": %w", err)
+		}
+
+		return s.Scan(f)
+	case string:
+		err := s.Scan(v)
+		if err == nil {
+			return nil
+		}
+		if t, perr := time.Parse(time.RFC3339Nano, v); perr == nil {
+			if s.Scan(t) == nil {
+				return nil
+			}
+		}
+
+		return err
+	default:
+		return s.Scan(value)
+	}
+}
+
+// tolerantBool converts a JSON number or a "true" or "false" string into a bool for a bool field v.
+func tolerantBool(value json.Token, v reflect.Value) (bool, bool) {
+	typ := v.Type()
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Bool {
+		return false, false
+	}
+
+	switch value := value.(type) {
+	case json.Number:
+		f, err := value.Float64()
+		if err != nil {
+			return false, false
+		}
+
+		return f != 0, true
+	case string:
+		switch value {
+		case "true":
+			return true, true
+		case "false":
+			return false, true
+		}
+	}
+
+	return false, false
 }
 
-// unmarshalValue unmarshals JSON value into v.
+// unmarshalValue unmarshals JSON value into v, a number into an interface{} as a json.Number.
 // v must be addressable and not obtained by the use of unexported
 // struct fields, otherwise unmarshalValue will panic.
 func unmarshalValue(value json.Token, v reflect.Value) error {
+	if n, ok := value.(json.Number); ok && isEmptyInterface(v.Type()) {
+		v.Set(reflect.ValueOf(n))
+
+		return nil
+	}
+
 	b, err := json.Marshal(value) // TODO: Short-circuit (if profiling says it's worth it).
 	if err != nil {
 		return fmt.Errorf(": %w", err)
@@ -388,3 +1322,75 @@ func unmarshalValue(value json.Token, v reflect.Value) error {
 
 	return nil
 }
+
+// decodeDynamicFields decodes the next JSON value into the map or interface{} fields, read once for all of them.
+func (d *Decoder) decodeDynamicFields(fields []reflect.Value) error {
+	var raw json.RawMessage
+	if err := d.jsonDecoder.Decode(&raw); err != nil {
+		return d.readError(err)
+	}
+	d.countToken()
+	d.recordPresence(isNull(raw))
+	if err := d.checkRawDepth(raw); err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		if f.Kind() == reflect.Map {
+			f.Set(reflect.MakeMap(f.Type()))
+		} else {
+			f.Set(reflect.Zero(f.Type()))
+		}
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		if err := decoder.Decode(f.Addr().Interface()); err != nil {
+			return fmt.Errorf("%v at %q: %w", f.Type(), d.currentPath(), err)
+		}
+	}
+
+	return nil
+}
+
+// checkRawDepth fails when raw, the value of the current key or element read at once, nests objects or arrays
+// deeper than the maximum depth, as they would fail when walked by the decoder.
+func (d *Decoder) checkRawDepth(raw json.RawMessage) error {
+	if d.maxDepth <= 0 {
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return fmt.Errorf("%q: %w", d.currentPath(), err)
+	}
+	if path, ok := nestedPath(value, len(d.parseState), d.maxDepth, d.currentPath()); ok {
+		return &inputError{err: fmt.Errorf("maximum depth %d exceeded at %q", d.maxDepth, path)}
+	}
+
+	return nil
+}
+
+// isDynamic reports whether the values of typ are decoded by encoding/json rather than by the GraphQL fields of structs:
+// the maps, the interface{} and the slices and arrays of them.
+func isDynamic(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Map:
+		return true
+	case reflect.Slice, reflect.Array:
+		return isDynamic(typ.Elem())
+	}
+
+	return isEmptyInterface(typ)
+}
+
+// isEmptyInterface reports whether typ is interface{}, holding any JSON value.
+func isEmptyInterface(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Interface && typ.NumMethod() == 0
+}
+
+// unmarshalNumbers unmarshals the JSON value raw into v like json.Unmarshal, the numbers held by interface{} as json.Number.
+func unmarshalNumbers(raw []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	return dec.Decode(v)
+}
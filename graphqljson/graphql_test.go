@@ -1,11 +1,26 @@
 package graphqljson_test
 
 import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"testing/iotest"
 	"time"
+	"unsafe"
 
-	"github.com/Yamashou/gqlgenc/graphqljson"
 	"github.com/google/go-cmp/cmp"
+	"github.com/pleclech/gqlgenc/graphqljson"
 )
 
 func TestUnmarshalGraphQL(t *testing.T) {
@@ -62,6 +77,71 @@ func TestUnmarshalGraphQL_graphqlTag(t *testing.T) {
 	}
 }
 
+func TestUnmarshal(t *testing.T) {
+	t.Parallel()
+	type user struct {
+		Name string  `graphql:"name"`
+		Bio  *string `graphql:"bio"`
+	}
+	type query struct {
+		User user `graphql:"user"`
+	}
+
+	t.Run("struct", func(t *testing.T) {
+		t.Parallel()
+		got, err := graphqljson.Unmarshal[query]([]byte(`{"user": {"name": "Gopher", "bio": null}}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(query{User: user{Name: "Gopher"}}, got); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("slice", func(t *testing.T) {
+		t.Parallel()
+		got, err := graphqljson.Unmarshal[[]*user]([]byte(`[{"name": "Gopher"}, null]`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]*user{{Name: "Gopher"}, nil}, got); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("pointer", func(t *testing.T) {
+		t.Parallel()
+		got, err := graphqljson.Unmarshal[*query]([]byte(`{"user": {"name": "Gopher"}}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(&query{User: user{Name: "Gopher"}}, got); diff != "" {
+			t.Error(diff)
+		}
+
+		got, err = graphqljson.Unmarshal[*query]([]byte(`null`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != nil {
+			t.Errorf("got %v, want nil for null", got)
+		}
+	})
+
+	t.Run("options", func(t *testing.T) {
+		t.Parallel()
+		got, err := graphqljson.Unmarshal[[]int]([]byte(`[1, "x", 3]`), graphqljson.WithLenientLists())
+		var elementErrors graphqljson.ElementErrors
+		if !errors.As(err, &elementErrors) || len(elementErrors) != 1 {
+			t.Fatalf("got error %v, want the error of the element skipped", err)
+		}
+		// the malformed element is left at its zero value
+		if diff := cmp.Diff([]int{1, 0, 3}, got); diff != "" {
+			t.Error(diff)
+		}
+	})
+}
+
 func TestUnmarshalGraphQL_jsonTag(t *testing.T) {
 	t.Parallel()
 	type query struct {
@@ -126,6 +206,63 @@ func TestUnmarshalGraphQL_arrayReset(t *testing.T) {
 	}
 }
 
+func TestUnmarshalGraphQL_scalarArrays(t *testing.T) {
+	t.Parallel()
+	type tags []string
+	type id string
+	type query struct {
+		Counts  []int     `graphql:"counts"`
+		Names   []string  `graphql:"names"`
+		Tags    tags      `graphql:"tags"`
+		IDs     []id      `graphql:"ids"`
+		Ratios  []float64 `graphql:"ratios"`
+		Flags   []bool    `graphql:"flags"`
+		Matrix  [][]int   `graphql:"matrix"`
+		Fixed   [3]int    `graphql:"fixed"`
+		Pointed []*int    `graphql:"pointed"`
+	}
+	// the initial elements are replaced, not appended to
+	got := query{Counts: []int{9}, Names: []string{"initial"}, Tags: tags{"initial"}}
+	err := graphqljson.UnmarshalData([]byte(`{
+		"counts": [1, 2, 3],
+		"names": ["a", "", "c"],
+		"tags": ["go", "graphql"],
+		"ids": ["1", "2"],
+		"ratios": [0.5, 1],
+		"flags": [true, false],
+		"matrix": [[1, 2], [], [3]],
+		"fixed": [4, 5, 6],
+		"pointed": [7, null]
+	}`), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seven := 7
+	want := query{
+		Counts:  []int{1, 2, 3},
+		Names:   []string{"a", "", "c"},
+		Tags:    tags{"go", "graphql"},
+		IDs:     []id{"1", "2"},
+		Ratios:  []float64{0.5, 1},
+		Flags:   []bool{true, false},
+		Matrix:  [][]int{{1, 2}, {}, {3}},
+		Fixed:   [3]int{4, 5, 6},
+		Pointed: []*int{&seven, nil},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+
+	// a top-level named slice
+	var top tags
+	if err := graphqljson.UnmarshalData([]byte(`["x", "y"]`), &top); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(tags{"x", "y"}, top); diff != "" {
+		t.Error(diff)
+	}
+}
+
 func TestUnmarshalGraphQL_objectArray(t *testing.T) {
 	t.Parallel()
 	type query struct {
@@ -209,6 +346,72 @@ func TestUnmarshalGraphQL_objectPointerArray(t *testing.T) {
 	}
 }
 
+// The null elements of a list of pointers are nil, the others point to their own values.
+func TestUnmarshalGraphQL_scalarPointerArray(t *testing.T) {
+	t.Parallel()
+	type query struct {
+		Names   []*string   `graphql:"names"`
+		Counts  []*int      `graphql:"counts"`
+		Matrix  [][]*string `graphql:"matrix"`
+		Missing []*bool     `graphql:"missing"`
+	}
+	data := []byte(`{
+		"names": ["a", null, "b"],
+		"counts": [null, 1, null],
+		"matrix": [["a", null], null, [null]],
+		"missing": [null, null]
+	}`)
+
+	for _, tt := range []struct {
+		name    string
+		options []graphqljson.Option
+	}{
+		{"default", nil},
+		{"string interning", []graphqljson.Option{graphqljson.WithStringInterning()}},
+		{"allocator", []graphqljson.Option{graphqljson.WithAllocator(newArena().allocate)}},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// the previous elements are replaced, not written through
+			previous := "previous"
+			got := query{Names: []*string{&previous, &previous, &previous, &previous}}
+			if err := graphqljson.UnmarshalData(data, &got, tt.options...); err != nil {
+				t.Fatal(err)
+			}
+
+			var names []string
+			for _, name := range got.Names {
+				if name == nil {
+					names = append(names, "<nil>")
+				} else {
+					names = append(names, *name)
+				}
+			}
+			if diff := cmp.Diff([]string{"a", "<nil>", "b"}, names); diff != "" {
+				t.Errorf("names: %s", diff)
+			}
+			if previous != "previous" {
+				t.Errorf("got previous element %q, want it left as is", previous)
+			}
+			a, b, one := "a", "b", 1
+			want := query{
+				Names:   []*string{&a, nil, &b},
+				Counts:  []*int{nil, &one, nil},
+				Matrix:  [][]*string{{&a, nil}, nil, {nil}},
+				Missing: []*bool{nil, nil},
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Error(diff)
+			}
+			if got.Matrix[1] != nil {
+				t.Errorf("got matrix[1] %v, want the null list nil", got.Matrix[1])
+			}
+		})
+	}
+}
+
 func TestUnmarshalGraphQL_pointerWithInlineFragment(t *testing.T) {
 	t.Parallel()
 	type actor struct {
@@ -331,18 +534,185 @@ func TestUnmarshalGraphQL_union(t *testing.T) {
 			},
 			UpdatedAt: time.Unix(1498709521, 0).UTC(),
 		},
-		ReopenedEvent: reopenedEvent{
-			Actor: actor{
-				Login: "shurcooL-test",
-			},
-			CreatedAt: time.Unix(1498709521, 0).UTC(),
-		},
 	}
 	if diff := cmp.Diff(got, want); diff != "" {
 		t.Error(diff)
 	}
 }
 
+func TestUnmarshalGraphQL_unionTypename(t *testing.T) {
+	t.Parallel()
+	/*
+		search {
+			... on User {
+				name
+				login
+			}
+			... on Repository {
+				name
+				stars
+			}
+			__typename
+		}
+	*/
+	type user struct {
+		Name  string
+		Login string
+	}
+	type repository struct {
+		Name  string
+		Stars int
+	}
+	type searchResult struct {
+		User       user       `graphql:"... on User"`
+		Repository repository `graphql:"... on Repository"`
+		Typename   string     `graphql:"__typename"`
+	}
+	type query struct {
+		Search []searchResult
+	}
+	tests := []struct {
+		name string
+		data string
+		want query
+	}{
+		{
+			name: "first member",
+			data: `{"search": [{"name": "gopher", "login": "golang", "__typename": "User"}]}`,
+			want: query{Search: []searchResult{{User: user{Name: "gopher", Login: "golang"}, Typename: "User"}}},
+		},
+		{
+			name: "second member",
+			data: `{"search": [{"__typename": "Repository", "name": "go", "stars": 42}]}`,
+			want: query{Search: []searchResult{{Repository: repository{Name: "go", Stars: 42}, Typename: "Repository"}}},
+		},
+		{
+			name: "each member in turn",
+			data: `{"search": [
+				{"__typename": "User", "name": "gopher", "login": "golang"},
+				{"__typename": "Repository", "name": "go", "stars": 42}
+			]}`,
+			want: query{Search: []searchResult{
+				{User: user{Name: "gopher", Login: "golang"}, Typename: "User"},
+				{Repository: repository{Name: "go", Stars: 42}, Typename: "Repository"},
+			}},
+		},
+		{
+			// the type conditions may be interfaces, so all fragments are decoded
+			name: "no member matches",
+			data: `{"search": [{"__typename": "Organization", "name": "gophers"}]}`,
+			want: query{Search: []searchResult{{User: user{Name: "gophers"}, Repository: repository{Name: "gophers"}, Typename: "Organization"}}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var got query
+			if err := graphqljson.UnmarshalData([]byte(tt.data), &got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestUnmarshalGraphQL_unionSameFieldName(t *testing.T) {
+	t.Parallel()
+	/*
+		query {
+			metrics {
+				... on Counter {
+					value
+				}
+				... on Gauge {
+					value
+				}
+				__typename
+			}
+		}
+	*/
+	type counter struct {
+		Value int `graphql:"value"`
+	}
+	type gauge struct {
+		Value string `graphql:"value"`
+	}
+	type metric struct {
+		Counter  counter `graphql:"... on Counter"`
+		Gauge    gauge   `graphql:"... on Gauge"`
+		Typename string  `graphql:"__typename"`
+	}
+	type query struct {
+		Metrics []metric `graphql:"metrics"`
+	}
+	tests := []struct {
+		name string
+		data string
+		want query
+	}{
+		{
+			name: "typename first",
+			data: `{"metrics": [{"__typename": "Counter", "value": 42}, {"__typename": "Gauge", "value": "high"}]}`,
+			want: query{Metrics: []metric{
+				{Counter: counter{Value: 42}, Typename: "Counter"},
+				{Gauge: gauge{Value: "high"}, Typename: "Gauge"},
+			}},
+		},
+		{
+			name: "typename last",
+			data: `{"metrics": [{"value": 42, "__typename": "Counter"}, {"value": "high", "__typename": "Gauge"}]}`,
+			want: query{Metrics: []metric{
+				{Counter: counter{Value: 42}, Typename: "Counter"},
+				{Gauge: gauge{Value: "high"}, Typename: "Gauge"},
+			}},
+		},
+		{
+			name: "no typename",
+			data: `{"metrics": [{"value": "high"}]}`,
+			want: query{Metrics: []metric{{Gauge: gauge{Value: "high"}}}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var got query
+			if err := graphqljson.UnmarshalData([]byte(tt.data), &got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			data string
+			want string
+		}{
+			// the value fits the fragment of another type than the typename
+			{`{"metrics": [{"value": "high", "__typename": "Counter"}]}`, ": : : : json: cannot unmarshal string into Go value of type int"},
+			// the value fits no fragment
+			{`{"metrics": [{"value": true, "__typename": "Counter"}]}`, ": : : : json: cannot unmarshal bool into Go value of type int"},
+		}
+		for _, tt := range tests {
+			var got query
+			err := graphqljson.UnmarshalData([]byte(tt.data), &got)
+			if err == nil {
+				t.Fatalf("%s: got no error", tt.data)
+			}
+			if got := err.Error(); got != tt.want {
+				t.Errorf("%s: got error: %q, want %q", tt.data, got, tt.want)
+			}
+		}
+	})
+}
+
 func TestUnmarshalGraphQL_union2(t *testing.T) {
 	t.Parallel()
 	type SubscriptionItemFragment struct {
@@ -459,3 +829,3065 @@ func TestUnmarshalGraphQL_arrayInsideInlineFragment(t *testing.T) {
 		t.Error(diff)
 	}
 }
+
+func TestDecoder_maxDepth(t *testing.T) {
+	t.Parallel()
+	type query struct {
+		User struct {
+			Friends []struct {
+				Name string
+			}
+		}
+	}
+	const data = `{
+		"user": {
+			"friends": [
+				{"name": "foo"},
+				{"name": "bar"}
+			]
+		}
+	}`
+
+	tests := []struct {
+		name     string
+		maxDepth int
+		wantErr  string
+	}{
+		{"no limit", 0, ""},
+		{"limit not reached", 4, ""},
+		{"limit exceeded on array element", 3, `maximum depth 3 exceeded at "user.friends[0]"`},
+		{"limit exceeded on object", 1, `maximum depth 1 exceeded at "user"`},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var got query
+			d := graphqljson.NewDecoder(strings.NewReader(data))
+			d.SetMaxDepth(tt.maxDepth)
+			err := d.Decode(&got)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(got.User.Friends) != 2 {
+					t.Errorf("got %d friends, want 2", len(got.User.Friends))
+				}
+
+				return
+			}
+			if err == nil {
+				t.Fatal("got error: nil, want: non-nil")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error: %v, want: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecoder_maxDepthDynamic(t *testing.T) {
+	t.Parallel()
+	type query struct {
+		Meta map[string]interface{}
+		Any  interface{}
+	}
+
+	tests := []struct {
+		name     string
+		data     string
+		maxDepth int
+		wantErr  string
+	}{
+		{"limit not reached", `{"meta": {"a": 1}, "any": [1, 2]}`, 2, ""},
+		{"map field", `{"meta": {"a": {"b": {"c": 1}}}}`, 2, `maximum depth 2 exceeded at "meta.a"`},
+		{"interface field", `{"any": [[[1]]]}`, 2, `maximum depth 2 exceeded at "any[0]"`},
+		{"field on the limit", `{"any": [1]}`, 1, `maximum depth 1 exceeded at "any"`},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			d := graphqljson.NewDecoder(strings.NewReader(tt.data))
+			d.SetMaxDepth(tt.maxDepth)
+			err := d.Decode(new(query))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				return
+			}
+			if err == nil {
+				t.Fatal("got error: nil, want: non-nil")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error: %v, want: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUnmarshalGraphQL_timeScalar(t *testing.T) {
+	t.Parallel()
+	type query struct {
+		Timeout   time.Duration   `graphql:"timeout,scalar=DurationSeconds"`
+		Delay     *time.Duration  `graphql:"delay,scalar=DurationNanos"`
+		CreatedAt time.Time       `graphql:"createdAt,scalar=EpochMillis"`
+		Steps     []time.Duration `graphql:"steps,scalar=DurationSeconds"`
+		Count     int64           `graphql:"count"`
+	}
+	var got query
+	err := graphqljson.UnmarshalData([]byte(`{
+		"timeout": 30,
+		"delay": 1500,
+		"createdAt": 1498709521000,
+		"steps": [1, 2],
+		"count": 3
+	}`), &got,
+		graphqljson.WithScalar("DurationSeconds", graphqljson.TimeConverter(time.Second)),
+		graphqljson.WithScalar("DurationNanos", graphqljson.TimeConverter(time.Nanosecond)),
+		graphqljson.WithScalar("EpochMillis", graphqljson.TimeConverter(time.Millisecond)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	delay := 1500 * time.Nanosecond
+	want := query{
+		Timeout:   30 * time.Second,
+		Delay:     &delay,
+		CreatedAt: time.Unix(1498709521, 0).UTC(),
+		Steps:     []time.Duration{time.Second, 2 * time.Second},
+		Count:     3,
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestUnmarshalGraphQL_timeScalarInvalidTarget(t *testing.T) {
+	t.Parallel()
+	type query struct {
+		Timeout string `graphql:"timeout,scalar=DurationSeconds"`
+	}
+	err := graphqljson.UnmarshalData([]byte(`{"timeout": 30}`), new(query),
+		graphqljson.WithScalar("DurationSeconds", graphqljson.TimeConverter(time.Second)),
+	)
+	if err == nil {
+		t.Fatal("got error: nil, want: non-nil")
+	}
+	want := ": : : scalar DurationSeconds: cannot convert 30 into string, want time.Duration or time.Time"
+	if got := err.Error(); got != want {
+		t.Errorf("got error: %v, want: %v", got, want)
+	}
+}
+
+func TestUnmarshalGraphQL_timeScalarOverflow(t *testing.T) {
+	t.Parallel()
+	type query struct {
+		Timeout time.Duration `graphql:"timeout,scalar=DurationSeconds"`
+	}
+	tests := []struct {
+		data    string
+		want    time.Duration
+		wantErr string
+	}{
+		{data: `{"timeout": 9223372036}`, want: 9223372036 * time.Second},
+		{data: `{"timeout": -9223372036}`, want: -9223372036 * time.Second},
+		{data: `{"timeout": 9223372037}`, wantErr: "9223372037 of 1s overflows a duration"},
+		{data: `{"timeout": -9223372037}`, wantErr: "-9223372037 of 1s overflows a duration"},
+	}
+	for _, tt := range tests {
+		var got query
+		err := graphqljson.UnmarshalData([]byte(tt.data), &got,
+			graphqljson.WithScalar("DurationSeconds", graphqljson.TimeConverter(time.Second)),
+		)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: got error: %v, want: %v", tt.data, err, tt.wantErr)
+			}
+
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Timeout != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.data, got.Timeout, tt.want)
+		}
+	}
+}
+
+func TestUnmarshalGraphQL_timeScalarFarFuture(t *testing.T) {
+	t.Parallel()
+	type query struct {
+		Seconds time.Time `graphql:"seconds,scalar=EpochSeconds"`
+		Millis  time.Time `graphql:"millis,scalar=EpochMillis"`
+		Before  time.Time `graphql:"before,scalar=EpochMillis"`
+	}
+
+	// the times past 2262, when the nanoseconds since the epoch overflow an int64, are decoded
+	var got query
+	err := graphqljson.UnmarshalData([]byte(`{"seconds": 10000000000, "millis": 10000000000123, "before": -1}`), &got,
+		graphqljson.WithScalar("EpochSeconds", graphqljson.TimeConverter(time.Second)),
+		graphqljson.WithScalar("EpochMillis", graphqljson.TimeConverter(time.Millisecond)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := query{
+		Seconds: time.Unix(10000000000, 0).UTC(),
+		Millis:  time.Unix(10000000000, 123000000).UTC(),
+		Before:  time.Unix(-1, 999000000).UTC(),
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+	if got.Seconds.Year() != 2286 {
+		t.Errorf("got year %d, want 2286", got.Seconds.Year())
+	}
+
+	err = graphqljson.UnmarshalData([]byte(`{"seconds": 9223372036854775807}`), new(query),
+		graphqljson.WithScalar("EpochSeconds", graphqljson.TimeConverter(time.Hour)),
+	)
+	if err == nil || !strings.Contains(err.Error(), "9223372036854775807 of 1h0m0s overflows a time") {
+		t.Errorf("got error: %v, want the overflow of the time", err)
+	}
+}
+
+func TestUnmarshalGraphQL_int64Scalar(t *testing.T) {
+	t.Parallel()
+	type query struct {
+		Min     int64   `graphql:"min,scalar=BigInt"`
+		Max     int64   `graphql:"max,scalar=BigInt"`
+		Quoted  *int64  `graphql:"quoted,scalar=BigInt"`
+		Null    *int64  `graphql:"null,scalar=BigInt"`
+		Counter uint64  `graphql:"counter,scalar=Long"`
+		IDs     []int64 `graphql:"ids,scalar=BigInt"`
+		ID      string  `graphql:"id,scalar=BigInt"`
+	}
+
+	t.Run("boundaries", func(t *testing.T) {
+		t.Parallel()
+		var got query
+		err := graphqljson.UnmarshalData([]byte(`{
+			"min": -9223372036854775808,
+			"max": 9223372036854775807,
+			"quoted": "9223372036854775807",
+			"null": null,
+			"counter": "18446744073709551615",
+			"ids": [9007199254740993, "-9007199254740993"],
+			"id": "123456789012345678901234567890"
+		}`), &got, graphqljson.WithInt64Scalar("BigInt"), graphqljson.WithInt64Scalar("Long"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		quoted := int64(math.MaxInt64)
+		want := query{
+			Min:     math.MinInt64,
+			Max:     math.MaxInt64,
+			Quoted:  &quoted,
+			Counter: math.MaxUint64,
+			IDs:     []int64{9007199254740993, -9007199254740993},
+			ID:      "123456789012345678901234567890",
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			data string
+			want string
+		}{
+			{`{"max": 9223372036854775808}`, `scalar BigInt at "max": 9223372036854775808 overflows int64`},
+			{`{"min": "-9223372036854775809"}`, `scalar BigInt at "min": -9223372036854775809 overflows int64`},
+			{`{"counter": -1}`, `scalar Long at "counter": -1 overflows uint64`},
+			{`{"ids": [1, 1.5]}`, `scalar BigInt at "ids[1]": invalid integer "1.5"`},
+			{`{"quoted": "one"}`, `scalar BigInt at "quoted": invalid integer "one"`},
+			{`{"id": true}`, `scalar BigInt at "id": cannot decode true into string, want a number or a string`},
+		}
+		for _, tt := range tests {
+			var got query
+			err := graphqljson.UnmarshalData([]byte(tt.data), &got, graphqljson.WithInt64Scalar("BigInt"), graphqljson.WithInt64Scalar("Long"))
+			if err == nil || !strings.HasSuffix(err.Error(), tt.want) {
+				t.Errorf("%s: got error: %v, want: %s", tt.data, err, tt.want)
+			}
+		}
+	})
+}
+
+func TestUnmarshalGraphQL_fixedSizeArray(t *testing.T) {
+	t.Parallel()
+	type color struct {
+		Name string
+		RGB  [3]int
+	}
+	type query struct {
+		Colors []color
+		Pair   [2]struct {
+			ID string
+		}
+	}
+	tests := []struct {
+		name     string
+		data     string
+		truncate bool
+		want     query
+		wantErr  string
+	}{
+		{
+			name: "exact length",
+			data: `{"colors": [{"name": "teal", "rgb": [0, 128, 128]}], "pair": [{"id": "1"}, {"id": "2"}]}`,
+			want: query{
+				Colors: []color{{Name: "teal", RGB: [3]int{0, 128, 128}}},
+				Pair:   [2]struct{ ID string }{{ID: "1"}, {ID: "2"}},
+			},
+		},
+		{
+			name: "shorter",
+			data: `{"colors": [{"name": "black", "rgb": [1]}], "pair": [{"id": "1"}]}`,
+			want: query{
+				Colors: []color{{Name: "black", RGB: [3]int{1}}},
+				Pair:   [2]struct{ ID string }{{ID: "1"}},
+			},
+		},
+		{
+			name:    "longer",
+			data:    `{"colors": [{"name": "teal", "rgb": [0, 128, 128, 255]}]}`,
+			wantErr: `: : JSON array longer than [3]int at "colors[0].rgb[3]"`,
+		},
+		{
+			name:     "longer truncated",
+			data:     `{"colors": [{"name": "teal", "rgb": [0, 128, 128, 255]}], "pair": [{"id": "1"}, {"id": "2"}, {"id": "3", "extra": [{}]}]}`,
+			truncate: true,
+			want: query{
+				Colors: []color{{Name: "teal", RGB: [3]int{0, 128, 128}}},
+				Pair:   [2]struct{ ID string }{{ID: "1"}, {ID: "2"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var options []graphqljson.Option
+			if tt.truncate {
+				options = append(options, graphqljson.WithTruncateArrays())
+			}
+			var got query
+			err := graphqljson.UnmarshalData([]byte(tt.data), &got, options...)
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatal("got error: nil, want: non-nil")
+				}
+				if got := err.Error(); got != tt.wantErr {
+					t.Errorf("got error: %v, want: %v", got, tt.wantErr)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestUnmarshalGraphQL_fixedLength(t *testing.T) {
+	t.Parallel()
+	type point struct {
+		Coordinates [3]float64  `graphql:"coordinates,nonnull,fixed"`
+		Corners     *[2]float64 `graphql:"corners,fixed"`
+		Loose       [2]int      `graphql:"loose"`
+	}
+	type query struct {
+		Points []point `graphql:"points"`
+	}
+
+	var got query
+	err := graphqljson.UnmarshalData([]byte(`{"points": [{"coordinates": [1, 2.5, 3], "corners": [4, 5], "loose": [1]}, {"coordinates": [0, 0, 0], "corners": null}]}`), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := query{Points: []point{
+		{Coordinates: [3]float64{1, 2.5, 3}, Corners: &[2]float64{4, 5}, Loose: [2]int{1}},
+		{Coordinates: [3]float64{}},
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+
+	// the arrays of the fields tagged fixed fail unless their length is the one of the go array, even truncated
+	tests := []struct {
+		data    string
+		options []graphqljson.Option
+		want    string
+	}{
+		{`{"points": [{"coordinates": [1, 2]}]}`, nil, `JSON array of 2 elements for [3]float64 at "points[0].coordinates`},
+		{`{"points": [{"coordinates": [1, 2, 3], "corners": []}]}`, nil, `JSON array of 0 elements for [2]float64 at "points[0].corners`},
+		{`{"points": [{"coordinates": [1, 2, 3, 4]}]}`, []graphqljson.Option{graphqljson.WithTruncateArrays()}, `JSON array of 4 elements for [3]float64`},
+	}
+	for _, tt := range tests {
+		var got query
+		err := graphqljson.UnmarshalData([]byte(tt.data), &got, tt.options...)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got error: %v, want: %s", tt.data, err, tt.want)
+		}
+	}
+}
+func TestUnmarshalGraphQL_exponentIntegers(t *testing.T) {
+	t.Parallel()
+	type query struct {
+		Count    int
+		Small    int8
+		Optional *int64
+		Unsigned uint
+		Ratio    float64
+		Counts   []int
+	}
+
+	t.Run("whole numbers", func(t *testing.T) {
+		t.Parallel()
+		var got query
+		data := []byte(`{"count": 1e3, "small": 1.27E2, "optional": 9.223372036854775807e18, "unsigned": 25000e-1, "ratio": 1.5e0, "counts": [1e0, 2.0, 3]}`)
+		if err := graphqljson.UnmarshalData(data, &got); err != nil {
+			t.Fatal(err)
+		}
+		optional := int64(9223372036854775807)
+		want := query{Count: 1000, Small: 127, Optional: &optional, Unsigned: 2500, Ratio: 1.5, Counts: []int{1, 2, 3}}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		for data, want := range map[string]string{
+			`{"count": 1.5e0}`:         `: : : int at "count": number 1.5e0 is not an integer`,
+			`{"count": 1e-1000000000}`: `: : : int at "count": number 1e-1000000000 is not an integer`,
+			`{"small": 1.28e2}`:        `: : : int8 at "small": 1.28e2 overflows int8`,
+			`{"count": 1e1000000000}`:  `: : : int at "count": 1e1000000000 overflows int`,
+			`{"unsigned": -1e0}`:       `: : : uint at "unsigned": -1e0 overflows uint`,
+			`{"optional": 1.0000000000000000000000000000000000000000000000000000000000000000000000000000001}`: `: : : *int64 at "optional": number 1.0000000000000000000000000000000000000000000000000000000000000000000000000000001 is not an integer`,
+		} {
+			var got query
+			err := graphqljson.UnmarshalData([]byte(data), &got)
+			if err == nil {
+				t.Fatalf("%s: got error: nil, want: non-nil", data)
+			}
+			if got := err.Error(); got != want {
+				t.Errorf("%s: got error: %v, want: %v", data, got, want)
+			}
+		}
+	})
+}
+
+func TestUnmarshalGraphQL_exactMatch(t *testing.T) {
+	t.Parallel()
+	type query struct {
+		Url    string
+		Avatar string `graphql:"avatarURL"`
+		Name   string `json:"name"`
+	}
+
+	t.Run("lenient", func(t *testing.T) {
+		t.Parallel()
+		var got query
+		if err := graphqljson.UnmarshalData([]byte(`{"uRL": "a", "avatarURL": "b", "name": "c"}`), &got); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(got, query{Url: "a", Avatar: "b", Name: "c"}); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("exact", func(t *testing.T) {
+		t.Parallel()
+		var got query
+		if err := graphqljson.UnmarshalData([]byte(`{"Url": "a", "avatarURL": "b", "name": "c"}`), &got, graphqljson.WithExactMatch()); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(got, query{Url: "a", Avatar: "b", Name: "c"}); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("exact case mismatch", func(t *testing.T) {
+		t.Parallel()
+		var got query
+		err := graphqljson.UnmarshalData([]byte(`{"uRL": "a"}`), &got, graphqljson.WithExactMatch())
+		if err == nil {
+			t.Fatal("got error: nil, want: non-nil")
+		}
+		if got, want := err.Error(), `: : struct field for "uRL" doesn't exist in any of 1 places to unmarshal`; got != want {
+			t.Errorf("got error: %v, want: %v", got, want)
+		}
+	})
+}
+
+func TestUnmarshalGraphQL_tolerantBools(t *testing.T) {
+	t.Parallel()
+	type query struct {
+		Zero     bool
+		One      bool
+		Two      bool
+		True     bool
+		False    *bool
+		Native   bool
+		Optional *bool
+		Flags    []bool
+	}
+	data := []byte(`{
+		"zero": 0,
+		"one": 1,
+		"two": 2.5,
+		"true": "true",
+		"false": "false",
+		"native": true,
+		"optional": null,
+		"flags": [1, 0, "true"]
+	}`)
+
+	t.Run("tolerant", func(t *testing.T) {
+		t.Parallel()
+		var got query
+		if err := graphqljson.UnmarshalData(data, &got, graphqljson.WithTolerantBools()); err != nil {
+			t.Fatal(err)
+		}
+		f := false
+		want := query{
+			Zero:   false,
+			One:    true,
+			Two:    true,
+			True:   true,
+			False:  &f,
+			Native: true,
+			Flags:  []bool{true, false, true},
+		}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("tolerant invalid string", func(t *testing.T) {
+		t.Parallel()
+		var got query
+		err := graphqljson.UnmarshalData([]byte(`{"one": "yes"}`), &got, graphqljson.WithTolerantBools())
+		if err == nil {
+			t.Fatal("got error: nil, want: non-nil")
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		t.Parallel()
+		for _, data := range []string{`{"one": 1}`, `{"true": "true"}`} {
+			var got query
+			err := graphqljson.UnmarshalData([]byte(data), &got)
+			if err == nil {
+				t.Fatalf("%s: got error: nil, want: non-nil", data)
+			}
+		}
+	})
+}
+
+func TestUnmarshalGraphQL_boolMapping(t *testing.T) {
+	t.Parallel()
+	type status bool
+	type query struct {
+		Enabled  bool    `graphql:"enabled"`
+		Disabled *bool   `graphql:"disabled"`
+		Active   status  `graphql:"active"`
+		Native   bool    `graphql:"native"`
+		Flags    []bool  `graphql:"flags"`
+		Name     string  `graphql:"name"`
+		Optional *bool   `graphql:"optional"`
+		Missing  *bool   `graphql:"missing"`
+		Label    *string `graphql:"label"`
+	}
+	options := []graphqljson.Option{
+		graphqljson.WithBoolMapping([]string{"enabled", "on"}, []string{"disabled"}),
+		graphqljson.WithBoolMapping(nil, []string{"off"}),
+	}
+
+	t.Run("mapped", func(t *testing.T) {
+		t.Parallel()
+		var got query
+		err := graphqljson.UnmarshalData([]byte(`{
+			"enabled": "enabled",
+			"disabled": "disabled",
+			"active": "on",
+			"native": true,
+			"flags": ["on", "off", false],
+			"name": "enabled",
+			"optional": null,
+			"label": "off"
+		}`), &got, options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := false
+		label := "off"
+		want := query{
+			Enabled:  true,
+			Disabled: &f,
+			Active:   true,
+			Native:   true,
+			Flags:    []bool{true, false, false},
+			Name:     "enabled",
+			Label:    &label,
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("unknown string", func(t *testing.T) {
+		t.Parallel()
+		for _, tt := range []struct {
+			data string
+			want string
+		}{
+			{`{"enabled": "yes"}`, `bool at "enabled": unknown bool string "yes"`},
+			{`{"disabled": "true"}`, `*bool at "disabled": unknown bool string "true"`},
+			{`{"flags": ["on", "maybe"]}`, `bool at "flags[1]": unknown bool string "maybe"`},
+		} {
+			var got query
+			err := graphqljson.UnmarshalData([]byte(tt.data), &got, options...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("%s: got error: %v, want: %s", tt.data, err, tt.want)
+			}
+			if got.Disabled != nil {
+				t.Errorf("%s: got disabled %v, want nil", tt.data, *got.Disabled)
+			}
+		}
+	})
+
+	t.Run("tolerant", func(t *testing.T) {
+		t.Parallel()
+		var got query
+		err := graphqljson.UnmarshalData([]byte(`{"enabled": "true", "native": "disabled"}`), &got, append(options, graphqljson.WithTolerantBools())...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Enabled || got.Native {
+			t.Errorf("got %+v, want enabled and not native", got)
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		t.Parallel()
+		var got query
+		if err := graphqljson.UnmarshalData([]byte(`{"enabled": "enabled"}`), &got); err == nil {
+			t.Fatal("got error: nil, want: non-nil")
+		}
+	})
+}
+
+func TestUnmarshalGraphQL_emptyAsNull(t *testing.T) {
+	t.Parallel()
+	type query struct {
+		Deleted *time.Time   `graphql:"deleted"`
+		Created *time.Time   `graphql:"created"`
+		Updated time.Time    `graphql:"updated"`
+		Dates   []*time.Time `graphql:"dates"`
+		Name    string       `graphql:"name"`
+	}
+
+	t.Run("coerced", func(t *testing.T) {
+		t.Parallel()
+		got := query{Updated: time.Now()}
+		err := graphqljson.UnmarshalData([]byte(`{
+			"deleted": "",
+			"created": "2021-02-03T04:05:06Z",
+			"updated": "",
+			"dates": ["", "2021-02-03T04:05:06Z"],
+			"name": ""
+		}`), &got, graphqljson.WithEmptyAsNull(time.Time{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		created := time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC)
+		want := query{
+			Created: &created,
+			Dates:   []*time.Time{nil, &created},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("strict by default", func(t *testing.T) {
+		t.Parallel()
+		var got query
+		err := graphqljson.UnmarshalData([]byte(`{"deleted": ""}`), &got)
+		if err == nil {
+			t.Fatal("want an error for an empty date")
+		}
+	})
+
+	t.Run("non-null", func(t *testing.T) {
+		t.Parallel()
+		var got struct {
+			Created time.Time `graphql:"created,nonnull"`
+		}
+		err := graphqljson.UnmarshalData([]byte(`{"created": ""}`), &got,
+			graphqljson.WithEmptyAsNull(&time.Time{}), graphqljson.WithStrictNonNull())
+		if err == nil || !strings.Contains(err.Error(), `null for non-null field at "created"`) {
+			t.Fatalf("got %v, want the null check of created", err)
+		}
+	})
+}
+
+func TestUnmarshalGraphQL_skipUnsupported(t *testing.T) {
+	t.Parallel()
+	type query struct {
+		Name     string
+		OnChange func(string) `graphql:"onChange"`
+		Events   chan string  `graphql:"events"`
+		Hooks    []func()     `graphql:"hooks"`
+		Ratio    *complex128  `graphql:"ratio"`
+		Friends  []struct {
+			Name    string
+			OnClick func() `graphql:"onClick"`
+		} `graphql:"friends"`
+	}
+	const data = `{
+		"name": "Gopher",
+		"onChange": "handler",
+		"events": {"kind": "stream"},
+		"hooks": [1, 2],
+		"ratio": null,
+		"friends": [{"name": "Gophie", "onClick": true}]
+	}`
+
+	t.Run("skipped", func(t *testing.T) {
+		t.Parallel()
+		var got query
+		if err := graphqljson.UnmarshalData([]byte(data), &got, graphqljson.WithSkipUnsupported()); err != nil {
+			t.Fatal(err)
+		}
+		if got.Name != "Gopher" || len(got.Friends) != 1 || got.Friends[0].Name != "Gophie" {
+			t.Errorf("got %+v, want the supported fields decoded", got)
+		}
+		if got.OnChange != nil || got.Events != nil || got.Hooks != nil || got.Ratio != nil || got.Friends[0].OnClick != nil {
+			t.Errorf("got %+v, want the unsupported fields left unset", got)
+		}
+	})
+
+	t.Run("fails by default", func(t *testing.T) {
+		t.Parallel()
+		var got query
+		err := graphqljson.UnmarshalData([]byte(data), &got)
+		if err == nil || !strings.Contains(err.Error(), `func(string) at "onChange": cannot decode into a field of kind func`) {
+			t.Fatalf("got %v, want the error of onChange", err)
+		}
+	})
+}
+
+func TestUnmarshalGraphQL_presentFields(t *testing.T) {
+	t.Parallel()
+	type patch struct {
+		Name    *string
+		Email   *string
+		Age     int
+		Admin   bool
+		Tags    []string
+		Address *struct {
+			City string
+		}
+		Metadata map[string]interface{}
+	}
+	type query struct {
+		Patches []patch
+	}
+	const data = `{"patches": [
+		{"name": "Gopher", "email": null, "age": 0, "admin": false, "tags": [], "address": {"city": ""}},
+		{"email": "gopher@golang.org", "address": null, "metadata": null}
+	]}`
+
+	t.Run("with option", func(t *testing.T) {
+		t.Parallel()
+		present := make(map[string]bool)
+		var got query
+		if err := graphqljson.UnmarshalData([]byte(data), &got, graphqljson.WithPresentFields(present)); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]bool{
+			"patches":                 true,
+			"patches[0].name":         true,
+			"patches[0].email":        false,
+			"patches[0].age":          true,
+			"patches[0].admin":        true,
+			"patches[0].tags":         true,
+			"patches[0].address":      true,
+			"patches[0].address.city": true,
+			"patches[1].email":        true,
+			"patches[1].address":      false,
+			"patches[1].metadata":     false,
+		}
+		if diff := cmp.Diff(want, present); diff != "" {
+			t.Errorf("present fields mismatch (-want +got):\n%s", diff)
+		}
+		// absent fields have no entry, unlike the null and zero ones
+		if _, ok := present["patches[1].age"]; ok {
+			t.Errorf("got patches[1].age present, want it absent")
+		}
+	})
+
+	t.Run("decoder", func(t *testing.T) {
+		t.Parallel()
+		d := graphqljson.NewDecoder(strings.NewReader(data))
+		if d.PresentFields() != nil {
+			t.Fatalf("got %v, want no present fields before recording", d.PresentFields())
+		}
+		d.SetRecordPresence(true)
+		var got query
+		if err := d.Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if present, ok := d.PresentFields()["patches[0].email"]; !ok || present {
+			t.Errorf("got patches[0].email %v %v, want it present and null", present, ok)
+		}
+		if present := d.PresentFields()["patches[0].age"]; !present || got.Patches[0].Age != 0 {
+			t.Errorf("got patches[0].age %v, want it present with its zero value", present)
+		}
+	})
+}
+
+// level is an enum of the schema sent as an integer.
+type level int
+
+const (
+	levelLow level = iota + 1
+	levelMedium
+	levelHigh
+)
+
+func (l level) IsValid() bool {
+	return l >= levelLow && l <= levelHigh
+}
+
+// priority is an unchecked integer type.
+type priority uint8
+
+func TestUnmarshalGraphQL_intEnum(t *testing.T) {
+	t.Parallel()
+	type query struct {
+		Level    level
+		Optional *level
+		Levels   []level
+		Priority priority
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		t.Parallel()
+		var got query
+		if err := graphqljson.UnmarshalData([]byte(`{"level": 2, "optional": 3e0, "levels": [1, 3], "priority": 200}`), &got); err != nil {
+			t.Fatal(err)
+		}
+		high := levelHigh
+		want := query{Level: levelMedium, Optional: &high, Levels: []level{levelLow, levelHigh}, Priority: 200}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("not equal (-want +got):\n%s", diff)
+		}
+	})
+
+	for _, tt := range []struct {
+		name string
+		data string
+		want string
+	}{
+		{name: "out of range", data: `{"level": 7}`, want: `graphqljson_test.level at "level": 7 is not a value of the enum`},
+		{name: "pointer", data: `{"optional": 0}`, want: `graphqljson_test.level at "optional": 0 is not a value of the enum`},
+		{name: "exponent", data: `{"optional": 4e0}`, want: `graphqljson_test.level at "optional": 4 is not a value of the enum`},
+		{name: "element", data: `{"levels": [1, -1]}`, want: `graphqljson_test.level at "levels[1]": -1 is not a value of the enum`},
+		{name: "overflow", data: `{"priority": 300}`, want: `cannot unmarshal number 300 into Go value of type graphqljson_test.priority`},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var got query
+			err := graphqljson.UnmarshalData([]byte(tt.data), &got)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("got %v, want %s", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("null", func(t *testing.T) {
+		t.Parallel()
+		var got query
+		if err := graphqljson.UnmarshalData([]byte(`{"optional": null}`), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Optional != nil {
+			t.Errorf("got %v, want nil", *got.Optional)
+		}
+	})
+}
+
+func TestUnmarshalGraphQL_duplicatedKeys(t *testing.T) {
+	t.Parallel()
+	type user struct {
+		Name  string
+		Email *string
+		Tags  []string
+	}
+	type query struct {
+		Count int
+		User  *user
+		Other user
+		After string
+	}
+	var got query
+	err := graphqljson.UnmarshalData([]byte(`{
+		"count": 1,
+		"user": {"name": "first", "email": "first@example.com", "tags": ["a", "b"]},
+		"other": {"name": "other", "name": "other2"},
+		"count": 2,
+		"user": {"name": "second", "tags": ["c"]},
+		"after": "balanced"
+	}`), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := query{
+		Count: 2,
+		User:  &user{Name: "second", Tags: []string{"c"}},
+		Other: user{Name: "other2"},
+		After: "balanced",
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestUnmarshalGraphQL_jsonTags(t *testing.T) {
+	t.Parallel()
+	type query struct {
+		ID       string `graphql:"id"`
+		FullName string `json:"name"`
+		Email    string `json:"email_address,omitempty"`
+		Age      int    `json:",omitempty"`
+		Nickname string
+		Both     string `graphql:"both" json:"other"`
+	}
+	var got query
+	err := graphqljson.UnmarshalData([]byte(`{
+		"id": "1",
+		"name": "Gopher",
+		"email_address": "gopher@example.com",
+		"age": 11,
+		"nickname": "gg",
+		"both": "graphql wins"
+	}`), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := query{
+		ID:       "1",
+		FullName: "Gopher",
+		Email:    "gopher@example.com",
+		Age:      11,
+		Nickname: "gg",
+		Both:     "graphql wins",
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+
+	err = graphqljson.UnmarshalData([]byte(`{"other": "x"}`), new(query))
+	if err == nil {
+		t.Fatal("got error: nil, want: non-nil")
+	}
+
+	// the fields tagged json:"-" are never decoded, not even by their go name
+	var skipped struct {
+		Name   string `json:"name"`
+		Secret string `json:"-"`
+	}
+	err = graphqljson.UnmarshalData([]byte(`{"name": "Gopher", "secret": "s3cr3t"}`), &skipped)
+	if err == nil || !strings.Contains(err.Error(), `struct field for "secret" doesn't exist`) {
+		t.Errorf("got error: %v, want the error of the unknown field", err)
+	}
+	if skipped.Secret != "" {
+		t.Errorf("got secret %q, want none", skipped.Secret)
+	}
+}
+
+// rawResponse keeps the response data it is given.
+type rawResponse struct {
+	data json.RawMessage
+	err  error
+}
+
+func (r *rawResponse) UnmarshalGraphQLResponse(data json.RawMessage) error {
+	r.data = data
+
+	return r.err
+}
+
+func TestUnmarshalGraphQL_responseUnmarshaler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("data", func(t *testing.T) {
+		t.Parallel()
+		data := []byte(`{ "user": {"name": "Gopher", "friends": [1, 2]} }`)
+		var got rawResponse
+		if err := graphqljson.UnmarshalData(data, &got); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(string(data), string(got.data)); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+		got := rawResponse{err: errors.New("unexpected user")}
+		err := graphqljson.UnmarshalData([]byte(`{"user": null}`), &got)
+		if err == nil || !strings.Contains(err.Error(), "unexpected user") {
+			t.Fatalf("got error: %v, want: unexpected user", err)
+		}
+	})
+
+	t.Run("trailing token", func(t *testing.T) {
+		t.Parallel()
+		var got rawResponse
+		err := graphqljson.UnmarshalData([]byte(`{"user": null} {}`), &got)
+		if err == nil || !strings.Contains(err.Error(), "after top-level value") {
+			t.Fatalf("got error: %v, want: invalid token after top-level value", err)
+		}
+	})
+}
+
+func TestUnmarshalGraphQL_strictNonNull(t *testing.T) {
+	t.Parallel()
+	type user struct {
+		Name     string  `graphql:"name,nonnull"`
+		Nickname *string `graphql:"nickname"`
+	}
+	type query struct {
+		User    *user     `graphql:"user,nonnull"`
+		Friends []*user   `graphql:"friends,nonnull"`
+		Tags    []*string `graphql:"tags,nonnull"`
+	}
+
+	t.Run("nullable fields", func(t *testing.T) {
+		t.Parallel()
+		var got query
+		err := graphqljson.UnmarshalData([]byte(`{
+			"user": {"name": "Gopher", "nickname": null},
+			"friends": [null, {"name": "Gophie", "nickname": null}],
+			"tags": [null]
+		}`), &got, graphqljson.WithStrictNonNull())
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := query{
+			User:    &user{Name: "Gopher"},
+			Friends: []*user{nil, {Name: "Gophie"}},
+			Tags:    []*string{nil},
+		}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("non-null fields", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			data string
+			want string
+		}{
+			{`{"user": null}`, `null for non-null field at "user"`},
+			{`{"user": {"name": null}}`, `null for non-null field at "user.name"`},
+			{`{"friends": [{"name": "Gophie"}, {"name": null}]}`, `null for non-null field at "friends[1].name"`},
+		}
+		for _, tt := range tests {
+			var got query
+			err := graphqljson.UnmarshalData([]byte(tt.data), &got, graphqljson.WithStrictNonNull())
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("%s: got error: %v, want: %s", tt.data, err, tt.want)
+			}
+		}
+	})
+
+	t.Run("off by default", func(t *testing.T) {
+		t.Parallel()
+		var got query
+		if err := graphqljson.UnmarshalData([]byte(`{"user": {"name": null}}`), &got); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(query{User: &user{}}, got); diff != "" {
+			t.Error(diff)
+		}
+	})
+}
+
+func TestUnmarshalGraphQL_conditionalFields(t *testing.T) {
+	t.Parallel()
+	// fields of a query like user { email @include(if: $showEmail) }, absent when not included
+	type user struct {
+		Name  *string `graphql:"name"`
+		Email *string `graphql:"email,nonnull"`
+	}
+	name, email := "Gopher", "gopher@example.com"
+	tests := []struct {
+		data string
+		want user
+	}{
+		{`{"name": "Gopher"}`, user{Name: &name}},
+		{`{"name": "Gopher", "email": "gopher@example.com"}`, user{Name: &name, Email: &email}},
+	}
+	for _, tt := range tests {
+		var got user
+		if err := graphqljson.UnmarshalData([]byte(tt.data), &got, graphqljson.WithStrictNonNull()); err != nil {
+			t.Fatalf("%s: %v", tt.data, err)
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%s: %s", tt.data, diff)
+		}
+	}
+}
+
+func TestUnmarshalGraphQL_typenameChecks(t *testing.T) {
+	t.Parallel()
+	type user struct {
+		Typename string `graphql:"__typename"`
+		Name     string `graphql:"name"`
+	}
+	type query struct {
+		User    *user  `graphql:"user,typename=User"`
+		Friends []user `graphql:"friends,typename=User"`
+		Owner   *struct {
+			Name string `graphql:"name"`
+		} `graphql:"owner,typename=User"`
+	}
+
+	t.Run("match", func(t *testing.T) {
+		t.Parallel()
+		var got query
+		err := graphqljson.UnmarshalData([]byte(`{
+			"user": {"__typename": "User", "name": "Gopher"},
+			"friends": [{"__typename": "User", "name": "Gophie"}],
+			"owner": {"name": "Gopherine"}
+		}`), &got, graphqljson.WithTypenameChecks())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.User.Name != "Gopher" || got.Friends[0].Name != "Gophie" || got.Owner.Name != "Gopherine" {
+			t.Errorf("got %+v", got)
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			data string
+			want string
+		}{
+			{`{"user": {"__typename": "Admin", "name": "Gopher"}}`, `unexpected __typename "Admin" at "user.__typename", want User`},
+			{`{"friends": [{"__typename": "User"}, {"__typename": "Bot"}]}`, `unexpected __typename "Bot" at "friends[1].__typename", want User`},
+		}
+		for _, tt := range tests {
+			var got query
+			err := graphqljson.UnmarshalData([]byte(tt.data), &got, graphqljson.WithTypenameChecks())
+			if err == nil || !strings.HasSuffix(err.Error(), tt.want) {
+				t.Errorf("%s: got error: %v, want: %s", tt.data, err, tt.want)
+			}
+
+			if err := graphqljson.UnmarshalData([]byte(tt.data), &got); err != nil {
+				t.Errorf("%s: got error without checks: %v", tt.data, err)
+			}
+		}
+	})
+}
+
+func TestUnmarshalGraphQL_typenameChecksDispatched(t *testing.T) {
+	t.Parallel()
+	type owner struct {
+		Typename string `graphql:"__typename"`
+	}
+	type circle struct {
+		Typename string `graphql:"__typename"`
+		Owner    owner  `graphql:"owner,typename=User"`
+	}
+	type query struct {
+		Shape interface{} `graphql:"shape"`
+	}
+
+	// the objects of the registered types are checked as the other ones
+	var got query
+	err := graphqljson.UnmarshalData([]byte(`{"shape": {"__typename": "Circle", "owner": {"__typename": "Robot"}}}`), &got,
+		graphqljson.WithType("Circle", circle{}),
+		graphqljson.WithTypenameChecks(),
+	)
+	want := `unexpected __typename "Robot" at "owner.__typename", want User`
+	if err == nil || !strings.HasSuffix(err.Error(), want) {
+		t.Errorf("got error: %v, want: %s", err, want)
+	}
+}
+
+func TestUnmarshalGraphQL_typenameField(t *testing.T) {
+	t.Parallel()
+	type query struct {
+		Tagged struct {
+			Kind string `graphql:"__typename"`
+		} `graphql:"tagged"`
+		Typename string `graphql:"__typename"`
+		User     struct {
+			Typename string
+			Name     string
+		} `graphql:"user"`
+		Node struct {
+			TypeName string
+			Fragment struct {
+				ID string
+			} `graphql:"... on User"`
+		} `graphql:"node"`
+	}
+	data := []byte(`{
+		"__typename": "Query",
+		"tagged": {"__typename": "Tagged"},
+		"user": {"__typename": "User", "name": "Gopher"},
+		"node": {"__typename": "User", "id": "1"}
+	}`)
+	var got query
+	if err := graphqljson.UnmarshalData(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Typename != "Query" || got.Tagged.Kind != "Tagged" || got.User.Typename != "User" || got.Node.TypeName != "User" {
+		t.Errorf("got typenames %+v", got)
+	}
+	if got.User.Name != "Gopher" || got.Node.Fragment.ID != "1" {
+		t.Errorf("got %+v", got)
+	}
+
+	// the names of the fields match __typename with exact matching too
+	var exact query
+	if err := graphqljson.UnmarshalData([]byte(`{"user": {"__typename": "User"}, "node": {"__typename": "Bot"}}`), &exact, graphqljson.WithExactMatch()); err != nil {
+		t.Fatal(err)
+	}
+	if exact.User.Typename != "User" || exact.Node.TypeName != "Bot" {
+		t.Errorf("got typenames %+v", exact)
+	}
+}
+
+func TestUnmarshalGraphQL_defaults(t *testing.T) {
+	t.Parallel()
+	type status string
+	type user struct {
+		Status   status  `graphql:"status,default=ACTIVE"`
+		Name     string  `graphql:"name,default=anonymous"`
+		Age      int     `graphql:"age,default=18"`
+		Score    float64 `graphql:"score,default=0.5"`
+		Admin    bool    `graphql:"admin,default=true"`
+		Nickname *string `graphql:"nickname,default=gopher"`
+		Code     string  `graphql:"code,default=42"`
+		Plain    string  `graphql:"plain"`
+		Bot      struct {
+			Model string `graphql:"model,default=v1"`
+		} `graphql:"... on Bot"`
+	}
+	type query struct {
+		Users []user `graphql:"users"`
+		Me    user   `graphql:"me: user"`
+	}
+
+	var got query
+	err := graphqljson.UnmarshalData([]byte(`{
+		"users": [
+			{},
+			{"status": "BANNED", "name": "", "age": 0, "score": 1, "admin": false, "nickname": null, "code": "7", "model": "v2"}
+		],
+		"me": {"age": 30}
+	}`), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nickname := "gopher"
+	defaults := user{Status: "ACTIVE", Name: "anonymous", Age: 18, Score: 0.5, Admin: true, Nickname: &nickname, Code: "42"}
+	defaults.Bot.Model = "v1"
+	present := user{Status: "BANNED", Score: 1, Code: "7"}
+	present.Bot.Model = "v2"
+	me := defaults
+	me.Age = 30
+	want := query{Users: []user{defaults, present}, Me: me}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+
+	// a default not fitting its field fails
+	var bad struct {
+		User struct {
+			Age int `graphql:"age,default=old"`
+		} `graphql:"user"`
+	}
+	err = graphqljson.UnmarshalData([]byte(`{"user": {}}`), &bad)
+	if err == nil || !strings.Contains(err.Error(), `default "old" of age at "user"`) {
+		t.Errorf("got error %v", err)
+	}
+}
+
+func TestUnmarshalGraphQL_onTypename(t *testing.T) {
+	t.Parallel()
+	type user struct {
+		Typename string `graphql:"__typename"`
+		Name     string `graphql:"name"`
+		Friends  []struct {
+			Typename string `graphql:"__typename"`
+			Name     string `graphql:"name"`
+		} `graphql:"friends"`
+	}
+	type query struct {
+		Typename string `graphql:"__typename"`
+		User     *user  `graphql:"user"`
+		Node     struct {
+			Typename string `graphql:"__typename"`
+			User     struct {
+				Name string `graphql:"name"`
+			} `graphql:"... on User"`
+		} `graphql:"node"`
+		Extra map[string]interface{} `graphql:"extra"`
+	}
+
+	var typenames, types []string
+	var got query
+	err := graphqljson.UnmarshalData([]byte(`{
+		"__typename": "Query",
+		"user": {"name": "Gopher", "__typename": "User", "friends": [
+			{"__typename": "User", "name": "Gophie"},
+			{"__typename": "Bot", "name": "Robot"}
+		]},
+		"node": {"__typename": "User", "name": "Gopherine"},
+		"extra": {"__typename": "Extra"}
+	}`), &got, graphqljson.WithTypenameHook(func(typename string, v reflect.Value) {
+		typenames = append(typenames, typename)
+		types = append(types, v.Type().String())
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"Query", "User", "User", "Bot", "User"}, typenames); diff != "" {
+		t.Error(diff)
+	}
+	if types[0] != "graphqljson_test.query" || types[1] != "graphqljson_test.user" || !strings.HasPrefix(types[4], "struct {") {
+		t.Errorf("got types %v", types)
+	}
+	if got.User.Name != "Gopher" || got.User.Friends[1].Name != "Robot" || got.Node.User.Name != "Gopherine" {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestUnmarshalGraphQL_sqlNull(t *testing.T) {
+	t.Parallel()
+	type query struct {
+		String  sql.NullString   `graphql:"string"`
+		Int64   sql.NullInt64    `graphql:"int64"`
+		Int32   sql.NullInt32    `graphql:"int32"`
+		Int16   sql.NullInt16    `graphql:"int16"`
+		Byte    sql.NullByte     `graphql:"byte"`
+		Float64 sql.NullFloat64  `graphql:"float64"`
+		Bool    sql.NullBool     `graphql:"bool"`
+		Time    sql.NullTime     `graphql:"time"`
+		Generic sql.Null[string] `graphql:"generic"`
+		Pointer *sql.NullString  `graphql:"pointer"`
+		List    []sql.NullInt64  `graphql:"list"`
+		Object  sql.NullString   `graphql:"object"`
+	}
+
+	t.Run("value", func(t *testing.T) {
+		t.Parallel()
+		var got query
+		err := graphqljson.UnmarshalData([]byte(`{
+			"string": "Gopher",
+			"int64": 9007199254740993,
+			"int32": -32,
+			"int16": 16,
+			"byte": 255,
+			"float64": 1.5,
+			"bool": true,
+			"time": "2021-02-03T04:05:06.789Z",
+			"generic": "generic",
+			"pointer": "pointer",
+			"list": [1, null, 3],
+			"object": {"String": "object", "Valid": true}
+		}`), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := query{
+			String:  sql.NullString{String: "Gopher", Valid: true},
+			Int64:   sql.NullInt64{Int64: 9007199254740993, Valid: true},
+			Int32:   sql.NullInt32{Int32: -32, Valid: true},
+			Int16:   sql.NullInt16{Int16: 16, Valid: true},
+			Byte:    sql.NullByte{Byte: 255, Valid: true},
+			Float64: sql.NullFloat64{Float64: 1.5, Valid: true},
+			Bool:    sql.NullBool{Bool: true, Valid: true},
+			Time:    sql.NullTime{Time: time.Date(2021, 2, 3, 4, 5, 6, 789000000, time.UTC), Valid: true},
+			Generic: sql.Null[string]{V: "generic", Valid: true},
+			Pointer: &sql.NullString{String: "pointer", Valid: true},
+			List:    []sql.NullInt64{{Int64: 1, Valid: true}, {}, {Int64: 3, Valid: true}},
+			Object:  sql.NullString{String: "object", Valid: true},
+		}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("null", func(t *testing.T) {
+		t.Parallel()
+		s := "previous"
+		got := query{
+			String:  sql.NullString{String: s, Valid: true},
+			Pointer: &sql.NullString{String: s, Valid: true},
+		}
+		err := graphqljson.UnmarshalData([]byte(`{
+			"string": null,
+			"int64": null,
+			"int32": null,
+			"int16": null,
+			"byte": null,
+			"float64": null,
+			"bool": null,
+			"time": null,
+			"generic": null,
+			"pointer": null
+		}`), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(got, query{}); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		var got query
+		err := graphqljson.UnmarshalData([]byte(`{"int32": "not a number"}`), &got)
+		if err == nil || !strings.Contains(err.Error(), `sql.NullInt32 at "int32"`) {
+			t.Fatalf("got error: %v, want: sql.NullInt32 at \"int32\"", err)
+		}
+	})
+}
+
+// protoUser is shaped like a struct generated by protoc-gen-go.
+type protoUser struct {
+	state         struct{}
+	sizeCache     int32
+	unknownFields []byte
+
+	Id          string        `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	DisplayName string        `protobuf:"bytes,2,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	Age         int32         `protobuf:"varint,3,opt,name=age,proto3" json:"age,omitempty"`
+	Address     *protoAddress `protobuf:"bytes,4,opt,name=address,proto3" json:"address,omitempty"`
+	Tags        []string      `protobuf:"bytes,5,rep,name=tags,proto3" json:"tags,omitempty"`
+	// Types that are assignable to Contact:
+	//	*protoUser_Email
+	Contact isProtoUser_Contact `protobuf_oneof:"contact"`
+}
+
+type protoAddress struct {
+	state struct{}
+
+	StreetName string `protobuf:"bytes,1,opt,name=street_name,json=streetName,proto3" json:"street_name,omitempty"`
+}
+
+type isProtoUser_Contact interface {
+	isProtoUser_Contact()
+}
+
+type protoUser_Email struct {
+	Email string `protobuf:"bytes,6,opt,name=email,proto3,oneof"`
+}
+
+func (*protoUser_Email) isProtoUser_Contact() {}
+
+func TestUnmarshalGraphQL_protobuf(t *testing.T) {
+	t.Parallel()
+
+	var got protoUser
+	err := graphqljson.UnmarshalData([]byte(`{
+		"id": "1",
+		"displayName": "Gopher",
+		"age": 11,
+		"address": {"streetName": "Main Street"},
+		"tags": ["go", "proto"]
+	}`), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := protoUser{
+		Id:          "1",
+		DisplayName: "Gopher",
+		Age:         11,
+		Address:     &protoAddress{StreetName: "Main Street"},
+		Tags:        []string{"go", "proto"},
+	}
+	if diff := cmp.Diff(got, want, cmp.AllowUnexported(protoUser{}, protoAddress{})); diff != "" {
+		t.Error(diff)
+	}
+
+	// the wrappers of oneof fields are unknown to the decoder
+	err = graphqljson.UnmarshalData([]byte(`{"email": "gopher@example.com"}`), new(protoUser))
+	if err == nil {
+		t.Fatal("got error: nil, want: non-nil")
+	}
+}
+
+func TestUnmarshalGraphQL_truncated(t *testing.T) {
+	t.Parallel()
+	type friend struct {
+		Name string `graphql:"name"`
+	}
+	type query struct {
+		User struct {
+			Name    string                 `graphql:"name"`
+			Friends []friend               `graphql:"friends"`
+			Extra   map[string]interface{} `graphql:"extra"`
+		} `graphql:"user"`
+	}
+
+	const data = `{"user": {"name": "Gopher", "friends": [{"name": "Gophie"}, {"name": "Gopherine"}], "extra": {"age": 10}}}`
+
+	t.Run("decode", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			data string
+			want graphqljson.TruncatedResponseError
+		}{
+			{``, graphqljson.TruncatedResponseError{Read: 0, Path: ""}},
+			{`{"user": {"na`, graphqljson.TruncatedResponseError{Read: 10, Path: "user"}},
+			{`{"user": {"name": "Goph`, graphqljson.TruncatedResponseError{Read: 16, Path: "user.name"}},
+			{data[:59], graphqljson.TruncatedResponseError{Read: 59, Path: "user.friends[0]"}},
+			// a map field is decoded at once
+			{data[:99], graphqljson.TruncatedResponseError{Read: 91, Path: "user.extra"}},
+			{data[:len(data)-1], graphqljson.TruncatedResponseError{Read: int64(len(data) - 1), Path: "user"}},
+		}
+		for _, tt := range tests {
+			var got query
+			err := graphqljson.UnmarshalData([]byte(tt.data), &got)
+			if !errors.Is(err, graphqljson.ErrTruncatedResponse) {
+				t.Fatalf("%s: got error: %v, want a truncated response", tt.data, err)
+			}
+			var truncated *graphqljson.TruncatedResponseError
+			if !errors.As(err, &truncated) {
+				t.Fatalf("%s: got error: %v, want a *TruncatedResponseError", tt.data, err)
+			}
+			if diff := cmp.Diff(tt.want, *truncated); diff != "" {
+				t.Errorf("%s: %s", tt.data, diff)
+			}
+		}
+	})
+
+	t.Run("check", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			data string
+			want error
+		}{
+			{``, &graphqljson.TruncatedResponseError{Read: 0, Path: ""}},
+			{data[:23], &graphqljson.TruncatedResponseError{Read: 16, Path: "user.name"}},
+			{data[:99], &graphqljson.TruncatedResponseError{Read: 99, Path: "user.extra.age"}},
+			{data, nil},
+			// syntax errors are not truncations
+			{`{"user": {"name": }}`, nil},
+			{`{"user": {"name": "Gopher"}} {`, nil},
+		}
+		for _, tt := range tests {
+			if diff := cmp.Diff(tt.want, graphqljson.CheckTruncated([]byte(tt.data))); diff != "" {
+				t.Errorf("CheckTruncated(%s): %s", tt.data, diff)
+			}
+		}
+	})
+
+	t.Run("decoding error", func(t *testing.T) {
+		t.Parallel()
+		var got query
+		err := graphqljson.UnmarshalData([]byte(`{"user": {"name": 1}}`), &got)
+		if err == nil || errors.Is(err, graphqljson.ErrTruncatedResponse) {
+			t.Errorf("got error: %v, want a decoding error", err)
+		}
+	})
+}
+
+func TestUnmarshalBytes(t *testing.T) {
+	t.Parallel()
+	type query struct {
+		User struct {
+			Name  string   `graphql:"name"`
+			Count int64    `graphql:"count,scalar=BigInt"`
+			Tags  []string `graphql:"tags"`
+		} `graphql:"user"`
+	}
+	for _, data := range []string{
+		`{"user": {"name": "Gopher", "count": "9007199254740993", "tags": ["a", "b"]}}`,
+		`{"user": {"name": "Gopher"}} {}`,
+		`{"user": {"name": 1}}`,
+		`{"user": {"na`,
+	} {
+		var want, got query
+		wantErr := graphqljson.UnmarshalData([]byte(data), &want, graphqljson.WithInt64Scalar("BigInt"))
+		gotErr := graphqljson.UnmarshalBytes([]byte(data), &got, graphqljson.WithInt64Scalar("BigInt"))
+		if fmt.Sprint(gotErr) != fmt.Sprint(wantErr) {
+			t.Errorf("%s: got error: %v, want: %v", data, gotErr, wantErr)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("%s: %s", data, diff)
+		}
+	}
+}
+
+func TestUnmarshalGraphQL_topLevel(t *testing.T) {
+	t.Parallel()
+	type query struct {
+		Name string `graphql:"name"`
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			data string
+			want string
+		}{
+			{``, ": : empty JSON input: unexpected end of JSON input after 0 bytes"},
+			{" \n", ": : empty JSON input: unexpected end of JSON input after 0 bytes"},
+		}
+		for _, tt := range tests {
+			var got query
+			err := graphqljson.UnmarshalData([]byte(tt.data), &got)
+			if err == nil {
+				t.Fatalf("%q: got no error", tt.data)
+			}
+			if got := err.Error(); got != tt.want {
+				t.Errorf("%q: got error: %q, want %q", tt.data, got, tt.want)
+			}
+			// the empty input is still a truncated response
+			if !errors.Is(err, graphqljson.ErrTruncatedResponse) {
+				t.Errorf("%q: got error: %v, want a truncated response", tt.data, err)
+			}
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			data string
+			v    interface{}
+			want string
+		}{
+			{`"Gopher"`, new(query), ": : expected object for struct target graphqljson_test.query, got string"},
+			{`42`, new(query), ": : expected object for struct target graphqljson_test.query, got number"},
+			{`[{"name": "Gopher"}]`, new(*query), ": : expected object for struct target graphqljson_test.query, got array"},
+			{`"Gopher"`, new([]query), ": : expected array for slice target []graphqljson_test.query, got string"},
+			{`42`, new([]query), ": : expected array for slice target []graphqljson_test.query, got number"},
+			{`{"name": "Gopher"}`, new([]query), ": : expected array for slice target []graphqljson_test.query, got object"},
+			{`true`, new(map[string]interface{}), ": : expected object for map target map[string]interface {}, got bool"},
+		}
+		for _, tt := range tests {
+			err := graphqljson.UnmarshalData([]byte(tt.data), tt.v)
+			if err == nil {
+				t.Fatalf("%s into %T: got no error", tt.data, tt.v)
+			}
+			if got := err.Error(); got != tt.want {
+				t.Errorf("%s into %T: got error: %q, want %q", tt.data, tt.v, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("scalar targets", func(t *testing.T) {
+		t.Parallel()
+		var number int
+		if err := graphqljson.UnmarshalData([]byte(`42`), &number); err != nil {
+			t.Fatal(err)
+		}
+		if number != 42 {
+			t.Errorf("got %d, want 42", number)
+		}
+
+		var bytes []byte
+		if err := graphqljson.UnmarshalData([]byte(`"R29waGVy"`), &bytes); err != nil {
+			t.Fatal(err)
+		}
+		if string(bytes) != "Gopher" {
+			t.Errorf("got %q, want Gopher", bytes)
+		}
+
+		got := &query{Name: "Gopher"}
+		if err := graphqljson.UnmarshalData([]byte(`null`), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got != nil {
+			t.Errorf("got %+v, want nil", got)
+		}
+	})
+}
+
+func TestUnmarshalGraphQL_dynamicTopLevel(t *testing.T) {
+	t.Parallel()
+	const data = `{"user": {"name": "Gopher", "age": 12, "ratio": 1.5, "tags": ["a", null, true], "friends": [{"name": "Gophie"}]}}`
+
+	// the value decoded by a map field and by encoding/json with json.Number
+	var field struct {
+		Data map[string]interface{} `graphql:"data"`
+	}
+	if err := graphqljson.UnmarshalData([]byte(`{"data": `+data+`}`), &field); err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	d := json.NewDecoder(strings.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&decoded); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(decoded, field.Data); diff != "" {
+		t.Fatal(diff)
+	}
+
+	t.Run("map", func(t *testing.T) {
+		t.Parallel()
+		got := map[string]interface{}{"stale": true}
+		if err := graphqljson.UnmarshalData([]byte(data), &got); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(field.Data, got); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("interface", func(t *testing.T) {
+		t.Parallel()
+		var got interface{}
+		if err := graphqljson.UnmarshalData([]byte(data), &got); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(interface{}(field.Data), got); diff != "" {
+			t.Error(diff)
+		}
+		if err := graphqljson.UnmarshalData([]byte(`[1, "a"]`), &got); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]interface{}{json.Number("1"), "a"}, got); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("null", func(t *testing.T) {
+		t.Parallel()
+		got := map[string]interface{}{"stale": true}
+		if err := graphqljson.UnmarshalData([]byte(`null`), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got != nil {
+			t.Errorf("got %v, want nil", got)
+		}
+	})
+
+	t.Run("max depth", func(t *testing.T) {
+		t.Parallel()
+		var got map[string]interface{}
+		d := graphqljson.NewDecoder(strings.NewReader(data))
+		d.SetMaxDepth(3)
+		err := d.Decode(&got)
+		if err == nil || !strings.Contains(err.Error(), `maximum depth 3 exceeded at "user.friends[0]"`) {
+			t.Errorf("got error: %v, want the depth of user.friends[0]", err)
+		}
+	})
+}
+
+func TestUnmarshalGraphQL_comments(t *testing.T) {
+	t.Parallel()
+	type query struct {
+		User struct {
+			Name    string   `graphql:"name"`
+			Website string   `graphql:"website"`
+			Bio     string   `graphql:"bio"`
+			Tags    []string `graphql:"tags"`
+		} `graphql:"user"`
+	}
+	// the slashes and stars of strings are not comments
+	const data = `// fixture of GetUser
+	{
+		"user": {
+			"name": "Gopher", // the mascot
+			"website": "https://go.dev",
+			/* multi-line
+			   block comment * / */
+			"bio": "/* not a comment */ \" // nor this",
+			"tags": [/**/ "go", /* "rust", */ "gc"]
+		}
+	}
+	/* trailing */`
+	want := query{}
+	want.User.Name = "Gopher"
+	want.User.Website = "https://go.dev"
+	want.User.Bio = `/* not a comment */ " // nor this`
+	want.User.Tags = []string{"go", "gc"}
+
+	t.Run("tolerant", func(t *testing.T) {
+		t.Parallel()
+		var got query
+		if err := graphqljson.UnmarshalData([]byte(data), &got, graphqljson.WithComments()); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Error(diff)
+		}
+
+		got = query{}
+		if err := graphqljson.NewDecoder(graphqljson.NewCommentReader(strings.NewReader(data))).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		t.Parallel()
+		var got query
+		err := graphqljson.UnmarshalData([]byte(data), &got)
+		if err == nil {
+			t.Fatal("got no error")
+		}
+		if got, want := err.Error(), ": : : invalid character '/' looking for beginning of value"; got != want {
+			t.Errorf("got error: %q, want %q", got, want)
+		}
+	})
+
+	t.Run("offsets", func(t *testing.T) {
+		t.Parallel()
+		stripped, err := graphqljson.StripComments([]byte("{/* a\nb */\"a\": 1} // c"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := string(stripped), "{    \n    \"a\": 1}     "; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("unterminated", func(t *testing.T) {
+		t.Parallel()
+		var got query
+		err := graphqljson.UnmarshalData([]byte(`{"user": {"name": "Gopher"}} /* trailing`), &got, graphqljson.WithComments())
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("got error: %v, want io.ErrUnexpectedEOF", err)
+		}
+	})
+}
+
+var benchmarkData = []byte(`{"user": {"name": "Gopher", "friends": [{"name": "Gophie"}, {"name": "Gopherine"}]}}`)
+
+type benchmarkQuery struct {
+	User struct {
+		Name    string `graphql:"name"`
+		Friends []struct {
+			Name string `graphql:"name"`
+		} `graphql:"friends"`
+	} `graphql:"user"`
+}
+
+func BenchmarkUnmarshalData(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var got benchmarkQuery
+		if err := graphqljson.UnmarshalData(benchmarkData, &got); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalBytes(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var got benchmarkQuery
+		if err := graphqljson.UnmarshalBytes(benchmarkData, &got); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkUnmarshalData_dynamic compares the decoding of the whole value into a map, by encoding/json,
+// with the one into the structs of a query.
+func BenchmarkUnmarshalData_dynamic(b *testing.B) {
+	b.Run("map", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var got map[string]interface{}
+			if err := graphqljson.UnmarshalData(benchmarkData, &got); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("struct", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var got benchmarkQuery
+			if err := graphqljson.UnmarshalData(benchmarkData, &got); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+type shape interface {
+	area() float64
+}
+
+type circle struct {
+	Kind   string  `graphql:"kind"`
+	Radius float64 `graphql:"radius"`
+}
+
+func (c circle) area() float64 {
+	return 3 * c.Radius * c.Radius
+}
+
+type square struct {
+	Kind string  `graphql:"kind"`
+	Side float64 `graphql:"side"`
+}
+
+func (s *square) area() float64 {
+	return s.Side * s.Side
+}
+
+type namedCircle struct {
+	Kind  string `graphql:"kind"`
+	Label string `graphql:"label"`
+}
+
+func (c *namedCircle) name() string {
+	return c.Label
+}
+
+func TestUnmarshalGraphQL_discriminator(t *testing.T) {
+	t.Parallel()
+	options := []graphqljson.Option{
+		graphqljson.WithDiscriminator("kind"),
+		graphqljson.WithType("circle", circle{}),
+		graphqljson.WithType("square", &square{}),
+	}
+
+	t.Run("interfaces", func(t *testing.T) {
+		t.Parallel()
+		type query struct {
+			Shape  shape       `graphql:"shape"`
+			Shapes []shape     `graphql:"shapes"`
+			Any    interface{} `graphql:"any"`
+			None   shape       `graphql:"none"`
+		}
+		var got query
+		err := graphqljson.UnmarshalData([]byte(`{
+			"shape": {"kind": "circle", "radius": 2},
+			"shapes": [{"side": 3, "kind": "square"}, {"kind": "circle", "radius": 1}, null],
+			"any": {"kind": "square", "side": 1},
+			"none": null
+		}`), &got, options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := query{
+			Shape:  circle{Kind: "circle", Radius: 2},
+			Shapes: []shape{&square{Kind: "square", Side: 3}, circle{Kind: "circle", Radius: 1}, nil},
+			Any:    &square{Kind: "square", Side: 1},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("fragments", func(t *testing.T) {
+		t.Parallel()
+		type query struct {
+			Shape struct {
+				Kind   string `graphql:"kind"`
+				Circle struct {
+					Radius float64 `graphql:"radius"`
+				} `graphql:"... on Circle"`
+				Square struct {
+					Radius float64 `graphql:"radius"`
+				} `graphql:"... on Square"`
+			} `graphql:"shape"`
+		}
+		var got query
+		err := graphqljson.UnmarshalData([]byte(`{"shape": {"kind": "Circle", "radius": 2}}`), &got, graphqljson.WithDiscriminator("kind"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Shape.Circle.Radius != 2 || got.Shape.Square.Radius != 0 {
+			t.Errorf("got %+v, want the radius of the circle fragment only", got.Shape)
+		}
+	})
+
+	t.Run("several types", func(t *testing.T) {
+		t.Parallel()
+		// each field is decoded into the type registered for the kind which implements it
+		type named interface {
+			name() string
+		}
+		type query struct {
+			Shape shape `graphql:"shape"`
+			Named named `graphql:"named"`
+		}
+		var got query
+		err := graphqljson.UnmarshalData([]byte(`{"shape": {"kind": "circle", "radius": 2}, "named": {"kind": "circle", "label": "round"}}`), &got,
+			graphqljson.WithDiscriminator("kind"),
+			graphqljson.WithType("circle", &namedCircle{}),
+			graphqljson.WithType("circle", circle{}),
+			graphqljson.WithType("circle", circle{}),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := query{
+			Shape: circle{Kind: "circle", Radius: 2},
+			Named: &namedCircle{Kind: "circle", Label: "round"},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		type query struct {
+			Shape  shape   `graphql:"shape"`
+			Shapes []shape `graphql:"shapes"`
+		}
+		tests := []struct {
+			data string
+			want string
+		}{
+			{`{"shape": {"kind": "triangle"}}`, `unknown kind "triangle" of graphqljson_test.shape at "shape"`},
+			{`{"shapes": [{"kind": "circle"}, {"radius": 1}]}`, `missing kind of graphqljson_test.shape at "shapes[1]"`},
+			{`{"shape": {"kind": "circle", "radius": "big"}}`, `circle at "shape"`},
+		}
+		for _, tt := range tests {
+			var got query
+			err := graphqljson.UnmarshalData([]byte(tt.data), &got, options...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("%s: got error: %v, want: %s", tt.data, err, tt.want)
+			}
+		}
+
+		var got struct {
+			Shape shape `graphql:"shape"`
+		}
+		err := graphqljson.UnmarshalData([]byte(`{"shape": {"kind": "square"}}`), &got, graphqljson.WithDiscriminator("kind"), graphqljson.WithType("square", square{}))
+		if want := `graphqljson_test.square of kind "square" does not implement graphqljson_test.shape at "shape"`; err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("got error: %v, want: %s", err, want)
+		}
+	})
+}
+
+func TestUnmarshalGraphQL_interfaceNumbers(t *testing.T) {
+	t.Parallel()
+	type query struct {
+		Any      interface{}              `graphql:"any"`
+		Scalar   interface{}              `graphql:"scalar"`
+		List     []interface{}            `graphql:"list"`
+		Lists    [][]interface{}          `graphql:"lists"`
+		Object   map[string]interface{}   `graphql:"object"`
+		Objects  []map[string]interface{} `graphql:"objects"`
+		Numbers  map[string]json.Number   `graphql:"numbers"`
+		Nothing  interface{}              `graphql:"nothing"`
+		Replaced []interface{}            `graphql:"replaced"`
+	}
+	got := query{Replaced: []interface{}{"stale", "values"}}
+	err := graphqljson.UnmarshalData([]byte(`{
+		"any": {"int": 1, "float": 2.5, "nested": [3, {"big": 12345678901234567890}]},
+		"scalar": 4,
+		"list": [5, "five", true, null, {"six": 6}, [7]],
+		"lists": [[8], [9.5]],
+		"object": {"ten": 10, "list": [11]},
+		"objects": [{"twelve": 12}],
+		"numbers": {"thirteen": 13},
+		"nothing": null,
+		"replaced": [14]
+	}`), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := query{
+		Any: map[string]interface{}{
+			"int":    json.Number("1"),
+			"float":  json.Number("2.5"),
+			"nested": []interface{}{json.Number("3"), map[string]interface{}{"big": json.Number("12345678901234567890")}},
+		},
+		Scalar:   json.Number("4"),
+		List:     []interface{}{json.Number("5"), "five", true, nil, map[string]interface{}{"six": json.Number("6")}, []interface{}{json.Number("7")}},
+		Lists:    [][]interface{}{{json.Number("8")}, {json.Number("9.5")}},
+		Object:   map[string]interface{}{"ten": json.Number("10"), "list": []interface{}{json.Number("11")}},
+		Objects:  []map[string]interface{}{{"twelve": json.Number("12")}},
+		Numbers:  map[string]json.Number{"thirteen": "13"},
+		Replaced: []interface{}{json.Number("14")},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+
+	// the values of interface{} not decoded into registered types keep their numbers too
+	type shape interface{}
+	var dispatched struct {
+		Scalar shape   `graphql:"scalar"`
+		List   []shape `graphql:"list"`
+	}
+	err = graphqljson.UnmarshalData([]byte(`{"scalar": 1, "list": [2, null]}`), &dispatched, graphqljson.WithType("Circle", struct{}{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]interface{}{json.Number("1"), json.Number("2"), nil}, []interface{}{dispatched.Scalar, dispatched.List[0], dispatched.List[1]}); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestUnmarshalGraphQL_lenientLists(t *testing.T) {
+	t.Parallel()
+	type user struct {
+		Name string `graphql:"name"`
+		Age  int    `graphql:"age"`
+	}
+	type query struct {
+		Users   []*user `graphql:"users"`
+		Scores  [][]int `graphql:"scores"`
+		Viewer  user    `graphql:"viewer"`
+		Friends [2]user `graphql:"friends"`
+	}
+	const data = `{
+		"users": [
+			{"name": "Gopher", "age": 10},
+			{"name": "Gophie", "age": "old", "friends": [{"name": "Gopherine"}]},
+			{"name": "Gopherine", "unknown": {"nested": [1, {"deep": true}]}, "age": 12},
+			{"name": "Gophert", "age": 13}
+		],
+		"scores": [[1, "two", 3], [4]],
+		"viewer": {"name": "Gopher", "age": 10},
+		"friends": [{"name": "Gophie"}, {"name": "Gopherine", "age": false}]
+	}`
+
+	var got query
+	err := graphqljson.UnmarshalData([]byte(data), &got, graphqljson.WithLenientLists())
+	var elementErrors graphqljson.ElementErrors
+	if !errors.As(err, &elementErrors) {
+		t.Fatalf("got error: %v, want graphqljson.ElementErrors", err)
+	}
+	paths := make([]string, len(elementErrors))
+	for i, elementError := range elementErrors {
+		paths[i] = elementError.Path
+	}
+	if diff := cmp.Diff([]string{"users[1]", "users[2]", "scores[0][1]", "friends[1]"}, paths); diff != "" {
+		t.Error(diff)
+	}
+	if !strings.Contains(elementErrors[1].Error(), `struct field for "unknown" doesn't exist`) {
+		t.Errorf("got error: %v, want the error of the unknown field", elementErrors[1])
+	}
+	want := query{
+		Users:   []*user{{Name: "Gopher", Age: 10}, nil, nil, {Name: "Gophert", Age: 13}},
+		Scores:  [][]int{{1, 0, 3}, {4}},
+		Viewer:  user{Name: "Gopher", Age: 10},
+		Friends: [2]user{{Name: "Gophie"}, {}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+
+	// the malformed elements fail the decoding by default
+	if err := graphqljson.UnmarshalData([]byte(data), new(query)); err == nil || errors.As(err, &elementErrors) {
+		t.Errorf("got error: %v, want the error of the first malformed element", err)
+	}
+
+	// the errors of the input stop the decoding
+	err = graphqljson.UnmarshalData([]byte(`{"users": [{"name": "Gopher", "age": "old"}, {"name": "Goph`), new(query), graphqljson.WithLenientLists())
+	if !errors.Is(err, graphqljson.ErrTruncatedResponse) {
+		t.Errorf("got error: %v, want a truncated response", err)
+	}
+	err = graphqljson.UnmarshalData([]byte(`{"users": [{"name": "Gopher", "age": 1}, {"name": "Gophie" "age": 2}]}`), new(query), graphqljson.WithLenientLists())
+	if err == nil || errors.As(err, &elementErrors) {
+		t.Errorf("got error: %v, want a syntax error", err)
+	}
+}
+
+func TestUnmarshalGraphQL_lenientListsDispatched(t *testing.T) {
+	t.Parallel()
+	type circle struct {
+		Typename string `graphql:"__typename"`
+		Label    string `graphql:"label"`
+		Radii    []int  `graphql:"radii"`
+	}
+	type query struct {
+		Shape  interface{}   `graphql:"shape"`
+		Shapes []interface{} `graphql:"shapes"`
+	}
+
+	// the objects of the registered types get the options of the decoder, their paths being the ones of the data
+	var got query
+	err := graphqljson.UnmarshalData([]byte(`{
+		"shape": {"__typename": "Circle", "label": " c ", "radii": [1, "x", 3]},
+		"shapes": [{"__typename": "Circle", "label": " d ", "radii": [false]}]
+	}`), &got,
+		graphqljson.WithType("Circle", circle{}),
+		graphqljson.WithLenientLists(),
+		graphqljson.WithTransform("shapes[*].label", func(v reflect.Value) {
+			v.SetString(strings.TrimSpace(v.String()))
+		}),
+	)
+	var elementErrors graphqljson.ElementErrors
+	if !errors.As(err, &elementErrors) {
+		t.Fatalf("got error: %v, want graphqljson.ElementErrors", err)
+	}
+	paths := make([]string, len(elementErrors))
+	for i, elementError := range elementErrors {
+		paths[i] = elementError.Path
+	}
+	if diff := cmp.Diff([]string{"shape.radii[1]", "shapes[0].radii[0]"}, paths); diff != "" {
+		t.Error(diff)
+	}
+	want := query{
+		Shape:  circle{Typename: "Circle", Label: " c ", Radii: []int{1, 0, 3}},
+		Shapes: []interface{}{circle{Typename: "Circle", Label: "d", Radii: []int{0}}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestUnmarshalGraphQL_transforms(t *testing.T) {
+	t.Parallel()
+	type friend struct {
+		Name     string  `graphql:"name"`
+		Nickname *string `graphql:"nickname"`
+	}
+	type query struct {
+		User struct {
+			Name    string   `graphql:"name"`
+			Bio     string   `graphql:"bio"`
+			Tags    []string `graphql:"tags"`
+			Friends []friend `graphql:"friends"`
+		} `graphql:"user"`
+	}
+	trim := func(v reflect.Value) {
+		v.SetString(strings.TrimSpace(v.String()))
+	}
+
+	var got query
+	err := graphqljson.UnmarshalData([]byte(`{"user": {
+		"name": "  Gopher  ",
+		"bio": "  Go  ",
+		"tags": [" a ", " b "],
+		"friends": [{"name": " Gophie ", "nickname": " Phie "}, {"name": " Gopherine ", "nickname": null}]
+	}}`), &got,
+		graphqljson.WithTransform("user.name", trim),
+		graphqljson.WithTransform("user.tags[1]", trim),
+		graphqljson.WithTransform("user.friends[*].nickname", trim),
+		graphqljson.WithTransform("user.friends[*].nickname", func(v reflect.Value) {
+			v.SetString(strings.ToUpper(v.String()))
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nickname := "PHIE"
+	var want query
+	want.User.Name = "Gopher"
+	want.User.Bio = "  Go  "
+	want.User.Tags = []string{" a ", "b"}
+	want.User.Friends = []friend{{Name: " Gophie ", Nickname: &nickname}, {Name: " Gopherine "}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+// upperString is a string decoding itself, not interned.
+type upperString string
+
+func (s *upperString) UnmarshalJSON(b []byte) error {
+	var v string
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*s = upperString(strings.ToUpper(v))
+
+	return nil
+}
+
+type internQuery struct {
+	Rows []struct {
+		Status  string      `graphql:"status"`
+		Comment *string     `graphql:"comment"`
+		Kind    upperString `graphql:"kind"`
+	} `graphql:"rows"`
+}
+
+// internData returns rows whose statuses, comments and kinds take a few values.
+func internData(rows int) []byte {
+	var b strings.Builder
+	b.WriteString(`{"rows": [`)
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, `{"status": "status%d", "comment": "comment%d", "kind": "kind%d"}`, i%3, i%2, i%2)
+	}
+	b.WriteString(`]}`)
+
+	return []byte(b.String())
+}
+
+func TestUnmarshalGraphQL_stringInterning(t *testing.T) {
+	t.Parallel()
+	data := internData(6)
+
+	var want, got internQuery
+	if err := graphqljson.UnmarshalData(data, &want); err != nil {
+		t.Fatal(err)
+	}
+	if err := graphqljson.UnmarshalData(data, &got, graphqljson.WithStringInterning()); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+
+	// the identical strings share their memory
+	for i := 3; i < len(got.Rows); i++ {
+		if unsafe.StringData(got.Rows[i].Status) != unsafe.StringData(got.Rows[i-3].Status) {
+			t.Errorf("rows[%d].status: not interned", i)
+		}
+		if unsafe.StringData(*got.Rows[i].Comment) != unsafe.StringData(*got.Rows[i-2].Comment) {
+			t.Errorf("rows[%d].comment: not interned", i)
+		}
+	}
+	if got.Rows[0].Kind != "KIND0" {
+		t.Errorf("got kind %q, want KIND0 decoded by its UnmarshalJSON", got.Rows[0].Kind)
+	}
+}
+
+func BenchmarkUnmarshalData_stringInterning(b *testing.B) {
+	data := internData(10000)
+	for _, bb := range []struct {
+		name    string
+		options []graphqljson.Option
+	}{
+		{"default", nil},
+		{"interned", []graphqljson.Option{graphqljson.WithStringInterning()}},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var got internQuery
+				if err := graphqljson.UnmarshalData(data, &got, bb.options...); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			// the heap held by a decoded value
+			var before, after runtime.MemStats
+			runtime.GC()
+			runtime.ReadMemStats(&before)
+			var got internQuery
+			if err := graphqljson.UnmarshalData(data, &got, bb.options...); err != nil {
+				b.Fatal(err)
+			}
+			runtime.GC()
+			runtime.ReadMemStats(&after)
+			runtime.KeepAlive(got)
+			b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc), "heap-B")
+		})
+	}
+}
+
+// arena allocates the values of each type from slabs of slabSize values, one allocation per slab.
+type arena struct {
+	slabs map[reflect.Type]reflect.Value
+	next  map[reflect.Type]int
+	types map[reflect.Type]bool
+}
+
+const slabSize = 1024
+
+func newArena() *arena {
+	return &arena{
+		slabs: make(map[reflect.Type]reflect.Value),
+		next:  make(map[reflect.Type]int),
+		types: make(map[reflect.Type]bool),
+	}
+}
+
+func (a *arena) allocate(t reflect.Type) reflect.Value {
+	a.types[t] = true
+	slab, i := a.slabs[t], a.next[t]
+	if !slab.IsValid() || i == slab.Len() {
+		slab, i = reflect.MakeSlice(reflect.SliceOf(t), slabSize, slabSize), 0
+		a.slabs[t] = slab
+	}
+	a.next[t] = i + 1
+
+	return slab.Index(i).Addr()
+}
+
+type pointerQuery struct {
+	Rows []*struct {
+		ID      *string
+		Age     *int
+		Active  *bool
+		Address *struct {
+			City *string
+		}
+	}
+}
+
+func pointerData(rows int) []byte {
+	var b strings.Builder
+	b.WriteString(`{"rows": [`)
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, `{"id": "%d", "age": %d, "active": true, "address": {"city": "city%d"}}`, i, i, i)
+	}
+	b.WriteString(`, {"id": null, "age": null, "active": null, "address": null}]}`)
+
+	return []byte(b.String())
+}
+
+func TestUnmarshalGraphQL_allocator(t *testing.T) {
+	t.Parallel()
+	data := pointerData(3)
+
+	var want, got pointerQuery
+	if err := graphqljson.UnmarshalData(data, &want); err != nil {
+		t.Fatal(err)
+	}
+	a := newArena()
+	if err := graphqljson.UnmarshalData(data, &got, graphqljson.WithAllocator(a.allocate)); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+
+	types := make([]string, 0, len(a.types))
+	for typ := range a.types {
+		types = append(types, typ.String())
+	}
+	sort.Strings(types)
+	wantTypes := []string{"bool", "int", "string", "struct { City *string }", "struct { ID *string; Age *int; Active *bool; Address *struct { City *string } }"}
+	if diff := cmp.Diff(wantTypes, types); diff != "" {
+		t.Errorf("allocated types: %s", diff)
+	}
+	if got.Rows[3].ID != nil || got.Rows[3].Address != nil {
+		t.Errorf("got %+v, want the null pointers left nil", got.Rows[3])
+	}
+
+	// the pointers of the 64-bit integer scalars are allocated by the allocator too
+	var scalar struct {
+		Size *int64 `graphql:"size,scalar=Long"`
+	}
+	a = newArena()
+	if err := graphqljson.UnmarshalData([]byte(`{"size": "9007199254740993"}`), &scalar, graphqljson.WithInt64Scalar("Long"), graphqljson.WithAllocator(a.allocate)); err != nil {
+		t.Fatal(err)
+	}
+	if scalar.Size == nil || *scalar.Size != 9007199254740993 {
+		t.Errorf("got %v, want 9007199254740993", scalar.Size)
+	}
+	if _, ok := a.types[reflect.TypeOf(int64(0))]; !ok {
+		t.Error("got the int64 allocated without the allocator")
+	}
+}
+
+func BenchmarkUnmarshalData_allocator(b *testing.B) {
+	data := pointerData(10000)
+	b.Run("default", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var got pointerQuery
+			if err := graphqljson.UnmarshalData(data, &got); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("arena", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var got pointerQuery
+			if err := graphqljson.UnmarshalData(data, &got, graphqljson.WithAllocator(newArena().allocate)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// money is a custom scalar decoding amounts like "$1.50" into cents, on its pointer receiver.
+type money int64
+
+func (m *money) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	dollars, cents, _ := strings.Cut(strings.TrimPrefix(s, "$"), ".")
+	amount, err := strconv.ParseInt(dollars+(cents + "00")[:2], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid amount %q", s)
+	}
+	*m = money(amount)
+
+	return nil
+}
+
+func TestUnmarshalGraphQL_pointerUnmarshaler(t *testing.T) {
+	t.Parallel()
+
+	type query struct {
+		Price    *money   `graphql:"price,scalar=Money"`
+		Discount *money   `graphql:"discount,scalar=Money"`
+		Total    money    `graphql:"total,scalar=Money"`
+		History  []*money `graphql:"history,scalar=Money"`
+	}
+	data := []byte(`{"price": "$1.50", "discount": null, "total": "$12", "history": ["$0.99", null]}`)
+	price, total, before := money(150), money(1200), money(99)
+	want := query{Price: &price, Total: total, History: []*money{&before, nil}}
+
+	for _, tt := range []struct {
+		name    string
+		options []graphqljson.Option
+	}{
+		{"default", nil},
+		{"int64 scalar", []graphqljson.Option{graphqljson.WithInt64Scalar("Money")}},
+		{"allocator", []graphqljson.Option{graphqljson.WithAllocator(newArena().allocate)}},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got query
+			if err := graphqljson.UnmarshalData(data, &got, tt.options...); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+
+	var got query
+	err := graphqljson.UnmarshalData([]byte(`{"price": "$x"}`), &got)
+	if err == nil || !strings.HasSuffix(err.Error(), `invalid amount "$x"`) {
+		t.Errorf("got error %v, want the error of UnmarshalJSON", err)
+	}
+}
+
+// content receives a string field as a stream, reading it in small chunks.
+type content struct {
+	text   strings.Builder
+	chunks int
+	reader io.Reader
+}
+
+func (c *content) UnmarshalGraphQLStream(r io.Reader) error {
+	c.reader = r
+	buf := make([]byte, 7)
+	for {
+		n, err := r.Read(buf)
+		c.text.Write(buf[:n])
+		if n > 0 {
+			c.chunks++
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// prefix reads the start of a streamed string only.
+type prefix [4]byte
+
+func (p *prefix) UnmarshalGraphQLStream(r io.Reader) error {
+	_, err := io.ReadFull(r, p[:])
+
+	return err
+}
+
+func TestUnmarshalGraphQL_stream(t *testing.T) {
+	t.Parallel()
+
+	t.Run("large string", func(t *testing.T) {
+		t.Parallel()
+		type query struct {
+			File struct {
+				Name    string   `graphql:"name"`
+				Content content  `graphql:"content"`
+				Head    *prefix  `graphql:"head"`
+				Missing *content `graphql:"missing"`
+				Size    int      `graphql:"size"`
+			} `graphql:"file"`
+		}
+		line := `café 😀 \"quoted\" \\ \/ \ud800 tab\t` + strings.Repeat("x", 100) + `\n`
+		want := strings.Repeat("café 😀 \"quoted\" \\ / � tab\t"+strings.Repeat("x", 100)+"\n", 10000)
+		data := `{"file": {"name": "big.txt", "content": "` + strings.Repeat(line, 10000) + `", "head": "stream", "missing": null, "size": 3}}`
+
+		var got query
+		if err := graphqljson.UnmarshalData([]byte(data), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.File.Content.text.String() != want {
+			t.Errorf("got content of %d bytes, want %d bytes", got.File.Content.text.Len(), len(want))
+		}
+		if got.File.Content.chunks < len(want)/7 {
+			t.Errorf("got %d chunks, want the content read in chunks of 7 bytes", got.File.Content.chunks)
+		}
+		if got.File.Name != "big.txt" || got.File.Size != 3 || got.File.Missing != nil {
+			t.Errorf("got %+v, want the fields around the content", got.File)
+		}
+		// the decoding goes on after the part of the string read
+		if got.File.Head == nil || string(got.File.Head[:]) != "stre" {
+			t.Errorf("got head %v, want stre", got.File.Head)
+		}
+
+		// the reader is valid during the call only
+		if _, err := got.File.Content.reader.Read(make([]byte, 1)); err == nil {
+			t.Error("got error: nil, want an error reading the stream after the call")
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		type query struct {
+			Head prefix `graphql:"head"`
+		}
+		tests := []struct {
+			data string
+			want string
+		}{
+			{`{"head": 12345}`, `graphqljson_test.prefix at "head": cannot stream 12345, not a string`},
+			{`{"head": "ab"}`, `*graphqljson_test.prefix at "head": unexpected EOF`},
+		}
+		for _, tt := range tests {
+			var got query
+			err := graphqljson.UnmarshalData([]byte(tt.data), &got)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("%s: got error: %v, want: %s", tt.data, err, tt.want)
+			}
+		}
+	})
+}
+
+// AuditableFragment is a fragment embedded as a pointer.
+type AuditableFragment struct {
+	CreatedAt string
+	*EditorFragment
+}
+
+// EditorFragment is a fragment embedded as a pointer in another one.
+type EditorFragment struct {
+	Editor struct {
+		Login string
+	}
+}
+
+func TestUnmarshalGraphQL_embeddedPointerFragment(t *testing.T) {
+	t.Parallel()
+	type post struct {
+		Title string
+		*AuditableFragment
+	}
+	type query struct {
+		Post  post
+		Posts []*post
+	}
+
+	var got query
+	if err := graphqljson.UnmarshalData([]byte(`{
+		"post": {"title": "Go", "createdAt": "2006-01-02", "editor": {"login": "gopher"}},
+		"posts": [{"title": "Fragments", "createdAt": "2006-01-03", "editor": {"login": "gordon"}}]
+	}`), &got); err != nil {
+		t.Fatal(err)
+	}
+	audit := func(createdAt, login string) *AuditableFragment {
+		fragment := &AuditableFragment{CreatedAt: createdAt, EditorFragment: &EditorFragment{}}
+		fragment.Editor.Login = login
+
+		return fragment
+	}
+	want := query{
+		Post:  post{Title: "Go", AuditableFragment: audit("2006-01-02", "gopher")},
+		Posts: []*post{{Title: "Fragments", AuditableFragment: audit("2006-01-03", "gordon")}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("not equal (-want +got):\n%s", diff)
+	}
+
+	t.Run("dropped", func(t *testing.T) {
+		t.Parallel()
+		type member struct {
+			Typename string `graphql:"__typename"`
+			User     *struct {
+				Login string
+			} `graphql:"... on User"`
+			Bot *struct {
+				Name string
+			} `graphql:"... on Bot"`
+		}
+		var got struct {
+			Actor member
+		}
+		if err := graphqljson.UnmarshalData([]byte(`{"actor": {"__typename": "Bot", "name": "dependabot"}}`), &got); err != nil {
+			t.Fatal(err)
+		}
+		// the pointer of the fragment on the other member is reset to nil
+		if got.Actor.User != nil || got.Actor.Bot == nil || got.Actor.Bot.Name != "dependabot" {
+			t.Errorf("got user %v and bot %v, want the bot only", got.Actor.User, got.Actor.Bot)
+		}
+	})
+}
+
+func TestUnmarshalGraphQL_decodeStats(t *testing.T) {
+	t.Parallel()
+	type query struct {
+		User struct {
+			Name    string `graphql:"name"`
+			Friends []*struct {
+				Name string `graphql:"name"`
+			} `graphql:"friends"`
+		} `graphql:"user"`
+	}
+
+	var stats graphqljson.DecodeStats
+	var got query
+	if err := graphqljson.UnmarshalData(benchmarkData, &got, graphqljson.WithDecodeStats(&stats)); err != nil {
+		t.Fatal(err)
+	}
+	want := graphqljson.DecodeStats{Tokens: 18, Fields: 5, Allocations: 2}
+	if diff := cmp.Diff(want, stats); diff != "" {
+		t.Errorf("not equal (-want +got):\n%s", diff)
+	}
+
+	t.Run("decoder", func(t *testing.T) {
+		t.Parallel()
+		d := graphqljson.NewDecoder(bytes.NewReader(append(append([]byte{}, benchmarkData...), benchmarkData...)))
+		var got query
+		if err := d.Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if stats := d.Stats(); stats != (graphqljson.DecodeStats{}) {
+			t.Fatalf("got %+v, want no stats before collecting", stats)
+		}
+
+		// the counters add up over the decodes
+		d.SetCollectStats(true)
+		if err := d.Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, d.Stats()); diff != "" {
+			t.Errorf("not equal (-want +got):\n%s", diff)
+		}
+		d.SetCollectStats(false)
+		if stats := d.Stats(); stats != (graphqljson.DecodeStats{}) {
+			t.Errorf("got %+v, want the stats reset", stats)
+		}
+	})
+
+	t.Run("values decoded at once", func(t *testing.T) {
+		t.Parallel()
+		var stats graphqljson.DecodeStats
+		var got struct {
+			User map[string]interface{} `graphql:"user"`
+		}
+		if err := graphqljson.UnmarshalData(benchmarkData, &got, graphqljson.WithDecodeStats(&stats)); err != nil {
+			t.Fatal(err)
+		}
+		// the braces, the key and the map
+		if diff := cmp.Diff(graphqljson.DecodeStats{Tokens: 4, Fields: 1}, stats); diff != "" {
+			t.Errorf("not equal (-want +got):\n%s", diff)
+		}
+	})
+}
+
+// benchmarkDecodeStats reports the stats of a decoding of data into a new value of the type of v, by operation.
+func benchmarkDecodeStats(b *testing.B, data []byte, v func() interface{}, options ...graphqljson.Option) {
+	b.Helper()
+	b.ReportAllocs()
+	var stats graphqljson.DecodeStats
+	options = append(options, graphqljson.WithDecodeStats(&stats))
+	for i := 0; i < b.N; i++ {
+		if err := graphqljson.UnmarshalData(data, v(), options...); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(stats.Tokens)/float64(b.N), "tokens/op")
+	b.ReportMetric(float64(stats.Fields)/float64(b.N), "fields/op")
+	b.ReportMetric(float64(stats.Allocations)/float64(b.N), "values/op")
+}
+
+// BenchmarkUnmarshalData_suite decodes the shapes of data the reflection walker handles, with their stats.
+func BenchmarkUnmarshalData_suite(b *testing.B) {
+	b.Run("objects", func(b *testing.B) {
+		type user struct {
+			Name    string `graphql:"name"`
+			Profile *struct {
+				Bio     string `graphql:"bio"`
+				Website *struct {
+					URL string `graphql:"url"`
+				} `graphql:"website"`
+			} `graphql:"profile"`
+		}
+		data := []byte(`{"name": "Gopher", "profile": {"bio": "Go", "website": {"url": "https://go.dev"}}}`)
+		benchmarkDecodeStats(b, data, func() interface{} { return new(user) })
+	})
+
+	b.Run("arrays", func(b *testing.B) {
+		type query struct {
+			Users []struct {
+				Name string   `graphql:"name"`
+				Tags []string `graphql:"tags"`
+			} `graphql:"users"`
+		}
+		var buf bytes.Buffer
+		buf.WriteString(`{"users": [`)
+		for i := 0; i < 100; i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			fmt.Fprintf(&buf, `{"name": "user%d", "tags": ["a", "b", "c"]}`, i)
+		}
+		buf.WriteString(`]}`)
+		benchmarkDecodeStats(b, buf.Bytes(), func() interface{} { return new(query) })
+	})
+
+	b.Run("fragments", func(b *testing.B) {
+		type query struct {
+			Post struct {
+				Title string
+				*AuditableFragment
+				Author struct {
+					Login string
+				} `graphql:"... on Post"`
+			}
+		}
+		data := []byte(`{"post": {"title": "Go", "createdAt": "2006-01-02", "editor": {"login": "gopher"}, "login": "gordon"}}`)
+		benchmarkDecodeStats(b, data, func() interface{} { return new(query) })
+	})
+
+	b.Run("unions", func(b *testing.B) {
+		type query struct {
+			Shapes []shape `graphql:"shapes"`
+		}
+		data := []byte(`{"shapes": [{"kind": "circle", "radius": 2}, {"kind": "square", "side": 3}, {"kind": "circle", "radius": 1}]}`)
+		benchmarkDecodeStats(b, data, func() interface{} { return new(query) },
+			graphqljson.WithDiscriminator("kind"), graphqljson.WithType("circle", circle{}), graphqljson.WithType("square", &square{}))
+	})
+}
+
+// BenchmarkUnmarshalData_list10k decodes a list of 10k objects of the same struct type, whose keys are each matched to a field.
+func BenchmarkUnmarshalData_list10k(b *testing.B) {
+	type user struct {
+		ID        string   `graphql:"id"`
+		Login     string   `graphql:"login"`
+		Name      string   `graphql:"name"`
+		Email     string   `graphql:"email"`
+		Bio       string   `graphql:"bio"`
+		Company   string   `graphql:"company"`
+		Location  string   `graphql:"location"`
+		Website   string   `graphql:"website"`
+		Followers int      `graphql:"followers"`
+		Following int      `graphql:"following"`
+		CreatedAt string   `json:"createdAt"`
+		UpdatedAt string   `json:"updatedAt"`
+		Tags      []string `graphql:"tags"`
+	}
+	type query struct {
+		Users []user `graphql:"users"`
+	}
+	var buf bytes.Buffer
+	buf.WriteString(`{"users": [`)
+	for i := 0; i < 10000; i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, `{"id": "%d", "login": "user%d", "name": "User", "email": "user@example.com", "bio": "", "company": "Go",
+			"location": "Paris", "website": "https://go.dev", "followers": 1, "following": 2, "createdAt": "2006-01-02",
+			"updatedAt": "2006-01-02", "tags": ["go"]}`, i, i)
+	}
+	buf.WriteString(`]}`)
+	data := buf.Bytes()
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		var got query
+		if err := graphqljson.UnmarshalData(data, &got); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestUnmarshalGraphQL_fieldIndex(t *testing.T) {
+	t.Parallel()
+	// the first field matching a key gets it, by its own name or by its tags, like Login rather than Renamed for login
+	type query struct {
+		UserName string
+		Login    string `json:"userName"`
+		Renamed  string `json:"login"`
+		Typename string
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// the index of the type is shared by the concurrent decodes
+			var got query
+			if err := graphqljson.UnmarshalData([]byte(`{"username": "gopher", "login": "gordon", "__typename": "User"}`), &got); err != nil {
+				errs <- err
+
+				return
+			}
+			if want := (query{UserName: "gopher", Login: "gordon", Typename: "User"}); got != want {
+				errs <- fmt.Errorf("got %+v, want %+v", got, want)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	t.Run("exact", func(t *testing.T) {
+		t.Parallel()
+		var got query
+		if err := graphqljson.UnmarshalData([]byte(`{"userName": "gopher", "UserName": "gordon"}`), &got, graphqljson.WithExactMatch()); err != nil {
+			t.Fatal(err)
+		}
+		// the names of the fields match in their case only
+		if want := (query{UserName: "gordon", Login: "gopher"}); got != want {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+}
+
+func TestUnmarshalGraphQL_bom(t *testing.T) {
+	t.Parallel()
+	type query struct {
+		User struct {
+			Name string `graphql:"name"`
+		} `graphql:"user"`
+	}
+	want := query{}
+	want.User.Name = "Gopher"
+
+	const data = `{"user": {"name": "Gopher"}}`
+	// the byte order mark of some gateways is trimmed, and so is the whitespace after it, a normal payload decoding as before
+	for _, payload := range []string{"\xef\xbb\xbf" + data, "\xef\xbb\xbf \r\n\t" + data, " \r\n\t" + data, data} {
+		var got query
+		if err := graphqljson.UnmarshalData([]byte(payload), &got); err != nil {
+			t.Fatalf("%q: %v", payload, err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("%q: %s", payload, diff)
+		}
+
+		got = query{}
+		if err := graphqljson.UnmarshalBytes([]byte(payload), &got); err != nil {
+			t.Fatalf("%q: %v", payload, err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("%q: %s", payload, diff)
+		}
+
+		// the decoder reading the mark one byte at a time trims it too
+		got = query{}
+		if err := graphqljson.NewDecoder(iotest.OneByteReader(strings.NewReader(payload))).Decode(&got); err != nil {
+			t.Fatalf("%q: %v", payload, err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("%q: %s", payload, diff)
+		}
+	}
+
+	// the values shorter than the mark are read as they are
+	var got int
+	if err := graphqljson.NewDecoder(strings.NewReader("7")).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got != 7 {
+		t.Errorf("got %d, want 7", got)
+	}
+
+	// only the leading mark is trimmed, a second one failing as other invalid characters
+	var q query
+	if err := graphqljson.UnmarshalData([]byte("\xef\xbb\xbf\xef\xbb\xbf"+data), &q); err == nil {
+		t.Error("got no error for a second byte order mark")
+	}
+	if got := graphqljson.TrimBOM([]byte(data)); string(got) != data {
+		t.Errorf("got %q, want %q", got, data)
+	}
+}
+
+func TestUnmarshalGraphQL_sharedDynamicKey(t *testing.T) {
+	t.Parallel()
+	// a key of a fragment and of the struct embedding it is decoded into both places
+	type query struct {
+		User struct {
+			Meta  map[string]interface{} `graphql:"meta"`
+			Shape shape                  `graphql:"shape"`
+			Head  *prefix                `graphql:"head"`
+			Name  string                 `graphql:"name"`
+			Frag  struct {
+				Meta  map[string]interface{} `graphql:"meta"`
+				Shape shape                  `graphql:"shape"`
+				Head  *prefix                `graphql:"head"`
+			} `graphql:"... on User"`
+		} `graphql:"user"`
+	}
+	var got query
+	err := graphqljson.UnmarshalData([]byte(`{"user": {"meta": {"a": 1}, "shape": {"kind": "circle", "radius": 2}, "head": "stream", "name": "x"}}`), &got,
+		graphqljson.WithDiscriminator("kind"),
+		graphqljson.WithType("circle", circle{}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := map[string]interface{}{"a": json.Number("1")}
+	if diff := cmp.Diff(meta, got.User.Meta); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff(meta, got.User.Frag.Meta); diff != "" {
+		t.Error(diff)
+	}
+	for _, s := range []shape{got.User.Shape, got.User.Frag.Shape} {
+		if diff := cmp.Diff(shape(circle{Kind: "circle", Radius: 2}), s); diff != "" {
+			t.Error(diff)
+		}
+	}
+	for _, head := range []*prefix{got.User.Head, got.User.Frag.Head} {
+		if head == nil || string(head[:]) != "stre" {
+			t.Errorf("got head %v, want stre", head)
+		}
+	}
+	if got.User.Name != "x" {
+		t.Errorf("got name %q, want x", got.User.Name)
+	}
+
+	// the places do not share the decoded values
+	got.User.Meta["b"] = 2
+	if _, ok := got.User.Frag.Meta["b"]; ok {
+		t.Error("got the map of the struct in the fragment")
+	}
+}
@@ -0,0 +1,58 @@
+package graphqljson
+
+import (
+	"encoding"
+	"reflect"
+)
+
+var (
+	stringType          = reflect.TypeOf("")
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// EnableStringInterning makes Decode share the memory of the identical strings it decodes into string fields,
+// like the statuses of thousands of rows, reducing the allocations and the size of the decoded value.
+// The pool of strings lives as long as the decoder.
+func (d *Decoder) EnableStringInterning() {
+	if d.strings == nil {
+		d.strings = make(map[string]string)
+	}
+}
+
+// WithStringInterning makes UnmarshalData share the memory of identical strings, see Decoder.EnableStringInterning.
+func WithStringInterning() Option {
+	return func(d *Decoder) {
+		d.EnableStringInterning()
+	}
+}
+
+// internString sets the string field v, or the string pointed by v, to the interned s
+// and reports whether v is such a field. The types decoding themselves are not.
+func (d *Decoder) internString(s string, v reflect.Value) bool {
+	typ := v.Type()
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.String {
+		return false
+	}
+	if typ != stringType && decodesItself(typ) {
+		return false
+	}
+
+	interned, ok := d.strings[s]
+	if !ok {
+		interned = s
+		d.strings[s] = s
+	}
+
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(d.newValue(typ)) // v = new(T).
+		}
+		v = v.Elem()
+	}
+	v.SetString(interned)
+
+	return true
+}
@@ -0,0 +1,147 @@
+package graphqljson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ElementError is the error of an element of a JSON array skipped by a decoder with lenient lists.
+type ElementError struct {
+	// Path is the path of the element, like "users[2]".
+	Path string
+	Err  error
+}
+
+func (e *ElementError) Error() string {
+	return fmt.Sprintf("element %q: %v", e.Path, e.Err)
+}
+
+func (e *ElementError) Unwrap() error {
+	return e.Err
+}
+
+// ElementErrors are the errors of the elements skipped by a decoder with lenient lists, in the order of the input.
+// Decode returns them once the rest of the value is decoded.
+type ElementErrors []*ElementError
+
+func (e ElementErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return fmt.Sprintf("%d malformed list elements: %s", len(e), strings.Join(messages, "; "))
+}
+
+// SetLenientLists makes Decode go on with the next element of a JSON array when an element fails to decode,
+// like an element of a mismatched type, leaving the element at its zero value. The errors of the elements are
+// returned as ElementErrors after the rest of the value is decoded. The errors of the JSON input itself,
+// like a truncated or invalid input, still stop the decoding. It is off by default.
+func (d *Decoder) SetLenientLists(lenient bool) {
+	d.lenientLists = lenient
+}
+
+// WithLenientLists makes UnmarshalData skip the malformed elements of lists, see Decoder.SetLenientLists.
+func WithLenientLists() Option {
+	return func(d *Decoder) {
+		d.SetLenientLists(true)
+	}
+}
+
+// element is an element of a JSON array being decoded by a decoder with lenient lists.
+type element struct {
+	path string
+	// depth is the parse state depth of the array.
+	depth int
+	// vs are the stacks of values before the element, values the places of the element.
+	vs     [][]target
+	values []reflect.Value
+}
+
+// inputError is an error of the JSON input itself, after which the decoding cannot go on.
+type inputError struct {
+	err error
+}
+
+func (e *inputError) Error() string {
+	return e.err.Error()
+}
+
+func (e *inputError) Unwrap() error {
+	return e.err
+}
+
+// beginElement records the element of the current array decoded into the top of the stacks vs,
+// the stacks before the element.
+func (d *Decoder) beginElement(vs [][]target) {
+	e := element{
+		path:  joinPath(d.pathPrefix, d.currentPath()),
+		depth: len(d.parseState),
+		vs:    vs,
+	}
+	for _, dv := range d.vs {
+		if top := dv[len(dv)-1].value; top.IsValid() {
+			e.values = append(e.values, top)
+		}
+	}
+	d.elements = append(d.elements, e)
+}
+
+// endElements forgets the elements of the arrays deeper than depth, and the current element of the array at depth.
+func (d *Decoder) endElements(depth int) {
+	for len(d.elements) > 0 && d.elements[len(d.elements)-1].depth >= depth {
+		d.elements = d.elements[:len(d.elements)-1]
+	}
+}
+
+// recoverElement records err as the error of the innermost element being decoded when lists are lenient,
+// skips the rest of the element and resets it. It returns the error stopping the decoding, nil if it goes on.
+func (d *Decoder) recoverElement(err error) error {
+	var input *inputError
+	if !d.lenientLists || len(d.elements) == 0 || errors.As(err, &input) {
+		return err
+	}
+
+	e := d.elements[len(d.elements)-1]
+	d.elementErrors = append(d.elementErrors, &ElementError{Path: e.path, Err: err})
+	for len(d.parseState) > e.depth {
+		tok, err := d.jsonDecoder.Token()
+		if err != nil {
+			return d.readError(err)
+		}
+		d.countToken()
+
+		switch tok {
+		case objectBeginToken, arrayBeginToken:
+			if err := d.pushState(tok.(json.Delim)); err != nil {
+				return err
+			}
+		case objectEndToken, arrayEndToken:
+			d.popState()
+		}
+	}
+
+	for _, v := range e.values {
+		v.Set(reflect.Zero(v.Type()))
+	}
+	d.vs = e.vs
+
+	return nil
+}
+
+// skipElement returns err after consuming the rest of the JSON value starting with tok when lists are lenient,
+// for the decoding to go on after the element holding the value.
+func (d *Decoder) skipElement(tok json.Token, err error) error {
+	if !d.lenientLists {
+		return err
+	}
+
+	if err := d.skipValue(tok); err != nil {
+		return err
+	}
+
+	return err
+}
@@ -0,0 +1,74 @@
+package graphqljson
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+)
+
+// unmarshalWholeNumber stores into the integer v, or the integer pointed by v, the JSON number n written
+// with an exponent or a fraction, like 1e3 or 1000.0 sent by some serializers for whole numbers,
+// and reports whether v is such an integer. The numbers with a fractional part fail.
+// The other numbers and the types decoding themselves are left to encoding/json.
+func (d *Decoder) unmarshalWholeNumber(n json.Number, v reflect.Value) (bool, error) {
+	if !strings.ContainsAny(string(n), ".eE") {
+		return false, nil
+	}
+
+	typ := v.Type()
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		return false, nil
+	}
+	if decodesItself(typ) {
+		return false, nil
+	}
+
+	// 256 bits hold the integers exactly, the exponent is not expanded like by big.Rat for 1e1000000000
+	f, _, err := big.ParseFloat(string(n), 10, 256, big.ToNearestEven)
+	if err != nil {
+		return true, fmt.Errorf("invalid number %q", n)
+	}
+	if f.IsInf() || f.MantExp(nil) > 64 {
+		return true, fmt.Errorf("%s overflows %s", n, typ)
+	}
+	mantissa := string(n)
+	if i := strings.IndexAny(mantissa, "eE"); i != -1 {
+		mantissa = mantissa[:i]
+	}
+	// a fraction rounded to an integer, or underflowing to 0
+	if !f.IsInt() || f.Acc() != big.Exact || f.Sign() == 0 && strings.Trim(mantissa, "-0.") != "" {
+		return true, fmt.Errorf("number %s is not an integer", n)
+	}
+
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(d.newValue(typ)) // v = new(T).
+		}
+		v = v.Elem()
+	}
+
+	switch typ.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, acc := f.Uint64()
+		if acc != big.Exact || v.OverflowUint(u) {
+			return true, fmt.Errorf("%s overflows %s", n, typ)
+		}
+		v.SetUint(u)
+	default:
+		i, acc := f.Int64()
+		if acc != big.Exact || v.OverflowInt(i) {
+			return true, fmt.Errorf("%s overflows %s", n, typ)
+		}
+		v.SetInt(i)
+	}
+
+	return true, nil
+}
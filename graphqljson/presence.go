@@ -0,0 +1,36 @@
+package graphqljson
+
+// SetRecordPresence makes Decode record the fields present in the data, reported by PresentFields, to tell a field absent
+// from the data from a field explicitly null or zero, like the fields of a patch whose absence means no change.
+// The fields of the values decoded at once, into map, interface{}, interface or streamed fields, are not recorded, theirs are.
+func (d *Decoder) SetRecordPresence(record bool) {
+	if !record {
+		d.present = nil
+
+		return
+	}
+	if d.present == nil {
+		d.present = make(map[string]bool)
+	}
+}
+
+// PresentFields returns the fields present in the data decoded while presence is recorded, by path like "user.friends[2].name",
+// true for a value, zero values included, and false for a null, the absent fields having no entry.
+// It is nil when presence is not recorded.
+func (d *Decoder) PresentFields() map[string]bool {
+	return d.present
+}
+
+// WithPresentFields makes UnmarshalData record the fields present in the data into present, see Decoder.SetRecordPresence.
+func WithPresentFields(present map[string]bool) Option {
+	return func(d *Decoder) {
+		d.present = present
+	}
+}
+
+// recordPresence records the field of the current path as present, with a value or null.
+func (d *Decoder) recordPresence(null bool) {
+	if d.present != nil {
+		d.present[d.currentPath()] = !null
+	}
+}
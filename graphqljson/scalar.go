@@ -0,0 +1,150 @@
+package graphqljson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// NumberConverter stores the JSON number n into v.
+// v is never a pointer, pointers are allocated by the decoder beforehand.
+type NumberConverter func(n json.Number, v reflect.Value) error
+
+// RegisterScalar makes the decoder convert the JSON numbers of the fields
+// tagged with the scalar option name, e.g. `graphql:"timeout,scalar=DurationSeconds"`,
+// using convert instead of the default encoding/json behavior.
+func (d *Decoder) RegisterScalar(name string, convert NumberConverter) {
+	if d.scalars == nil {
+		d.scalars = make(map[string]NumberConverter)
+	}
+	d.scalars[name] = convert
+}
+
+// WithScalar returns an Option registering convert for the scalar name.
+func WithScalar(name string, convert NumberConverter) Option {
+	return func(d *Decoder) {
+		d.RegisterScalar(name, convert)
+	}
+}
+
+// TimeConverter converts an integer counted in unit into a time.Duration,
+// or into a time.Time counted since the unix epoch, depending on the type of the field.
+// For instance TimeConverter(time.Second) suits a DurationSeconds scalar
+// and TimeConverter(time.Millisecond) an EpochMillis one.
+// It fails for the durations which overflow a time.Duration, the times being counted without overflowing.
+func TimeConverter(unit time.Duration) NumberConverter {
+	return func(n json.Number, v reflect.Value) error {
+		i, err := n.Int64()
+		if err != nil {
+			return fmt.Errorf("invalid integer: %w", err)
+		}
+
+		switch v.Type() {
+		case durationType:
+			if unit > 0 && (i > math.MaxInt64/int64(unit) || i < math.MinInt64/int64(unit)) {
+				return fmt.Errorf("%s of %s overflows a duration", n, unit)
+			}
+			v.SetInt(int64(time.Duration(i) * unit))
+		case timeType:
+			// the nanoseconds since the epoch overflow an int64 past 2262, the seconds do not
+			ns := new(big.Int).Mul(big.NewInt(i), big.NewInt(int64(unit)))
+			sec, nsec := new(big.Int).DivMod(ns, big.NewInt(int64(time.Second)), new(big.Int))
+			if !sec.IsInt64() {
+				return fmt.Errorf("%s of %s overflows a time", n, unit)
+			}
+			v.Set(reflect.ValueOf(time.Unix(sec.Int64(), nsec.Int64()).UTC()))
+		default:
+			return fmt.Errorf("cannot convert %s into %s, want %s or %s", n, v.Type(), durationType, timeType)
+		}
+
+		return nil
+	}
+}
+
+// RegisterInt64Scalar makes the decoder decode the fields tagged with the scalar option name,
+// e.g. `graphql:"id,scalar=BigInt"`, from JSON numbers or from JSON strings holding an integer,
+// as servers send 64-bit integers in strings to not lose precision in JavaScript.
+// The integer is parsed without going through a float into the integer fields, failing if it overflows them.
+// String fields get the digits as is, and the types implementing json.Unmarshaler, like a Money parsing "$1.50", decode themselves.
+func (d *Decoder) RegisterInt64Scalar(name string) {
+	if d.int64Scalars == nil {
+		d.int64Scalars = make(map[string]bool)
+	}
+	d.int64Scalars[name] = true
+}
+
+// WithInt64Scalar returns an Option registering the 64-bit integer scalar name, see Decoder.RegisterInt64Scalar.
+func WithInt64Scalar(name string) Option {
+	return func(d *Decoder) {
+		d.RegisterInt64Scalar(name)
+	}
+}
+
+// unmarshalInt64 stores the integer of the JSON number or string value into v, dereferencing pointers.
+func (d *Decoder) unmarshalInt64(value json.Token, v reflect.Value) error {
+	var s string
+	switch value := value.(type) {
+	case json.Number:
+		s = value.String()
+	case string:
+		s = value
+	default:
+		return fmt.Errorf("cannot decode %v into %s, want a number or a string", value, v.Type())
+	}
+
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(d.newValue(v.Type().Elem())) // v = new(T).
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return integerError(s, v.Type(), err)
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if strings.HasPrefix(s, "-") {
+			if _, err := strconv.ParseInt(s, 10, 64); err == nil || errors.Is(err, strconv.ErrRange) {
+				return fmt.Errorf("%s overflows %s", s, v.Type())
+			}
+		}
+		u, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return integerError(s, v.Type(), err)
+		}
+		v.SetUint(u)
+	case reflect.String:
+		if _, err := strconv.ParseInt(strings.TrimPrefix(s, "-"), 10, 64); err != nil && !errors.Is(err, strconv.ErrRange) {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		v.SetString(s)
+	default:
+		return fmt.Errorf("cannot decode %s into %s, want an integer or a string", s, v.Type())
+	}
+
+	return nil
+}
+
+// integerError returns the error of parsing the integer s for typ.
+func integerError(s string, typ reflect.Type, err error) error {
+	if errors.Is(err, strconv.ErrRange) {
+		return fmt.Errorf("%s overflows %s", s, typ)
+	}
+
+	return fmt.Errorf("invalid integer %q", s)
+}
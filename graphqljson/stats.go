@@ -0,0 +1,48 @@
+package graphqljson
+
+// DecodeStats are the counters of the work of the decoder, collected for profiling and regression tracking.
+type DecodeStats struct {
+	// Tokens is the number of JSON tokens read, a value decoded at once, like the value of a map field, counting as one.
+	Tokens int
+	// Fields is the number of keys of the objects matched to struct fields, once for each place the value is decoded into,
+	// like a fragment and the struct embedding it.
+	Fields int
+	// Allocations is the number of values allocated for the pointers of the data, by the allocator when set.
+	Allocations int
+}
+
+// SetCollectStats makes Decode collect the counters of its work, reported by Stats, adding up over the decodes
+// until the collection is turned off, which resets them. It is off by default, for no overhead.
+func (d *Decoder) SetCollectStats(collect bool) {
+	if !collect {
+		d.stats = nil
+
+		return
+	}
+	if d.stats == nil {
+		d.stats = &DecodeStats{}
+	}
+}
+
+// Stats returns the counters collected, zero when they are not collected.
+func (d *Decoder) Stats() DecodeStats {
+	if d.stats == nil {
+		return DecodeStats{}
+	}
+
+	return *d.stats
+}
+
+// WithDecodeStats makes UnmarshalData add the counters of its work to stats, see Decoder.SetCollectStats.
+func WithDecodeStats(stats *DecodeStats) Option {
+	return func(d *Decoder) {
+		d.stats = stats
+	}
+}
+
+// countToken counts a token read when the stats are collected.
+func (d *Decoder) countToken() {
+	if d.stats != nil {
+		d.stats.Tokens++
+	}
+}
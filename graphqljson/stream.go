@@ -0,0 +1,197 @@
+package graphqljson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// StreamUnmarshaler is implemented by the types of the fields receiving a JSON string as a stream, like the large content
+// of a file, rather than as a go string. The decoder passes r, reading the string unescaped, from the bytes of the token
+// in its input: the string is never decoded into a go string, and its bytes are reused from one streamed field to the next.
+// r is valid during the call only, the decoding going on after the token whatever the part of r read.
+// A null sets a pointer field to nil and leaves the others unchanged, the other JSON values fail.
+// Only struct fields are streamed, the elements of lists are not.
+type StreamUnmarshaler interface {
+	UnmarshalGraphQLStream(r io.Reader) error
+}
+
+var streamUnmarshalerType = reflect.TypeOf((*StreamUnmarshaler)(nil)).Elem()
+
+// isStreamed reports whether the values of typ, or the ones pointed by typ, receive their JSON string as a stream.
+func isStreamed(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	return reflect.PtrTo(typ).Implements(streamUnmarshalerType)
+}
+
+// decodeStreamed decodes the next JSON value, a string or null read once, into the fields whose values receive their string as a stream.
+func (d *Decoder) decodeStreamed(fields []reflect.Value) error {
+	// the token is decoded into the bytes of the previous one
+	d.streamed = d.streamed[:0]
+	if err := d.jsonDecoder.Decode(&d.streamed); err != nil {
+		return d.readError(err)
+	}
+	d.countToken()
+	d.recordPresence(isNull(d.streamed))
+
+	for _, v := range fields {
+		if err := d.streamField(v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// streamField streams the string of d.streamed into the field v.
+func (d *Decoder) streamField(v reflect.Value) error {
+	if isNull(d.streamed) {
+		if v.Kind() == reflect.Ptr {
+			v.Set(reflect.Zero(v.Type()))
+		}
+
+		return nil
+	}
+	if len(d.streamed) == 0 || d.streamed[0] != '"' {
+		return fmt.Errorf("%v at %q: cannot stream %s, not a string", v.Type(), d.currentPath(), truncate(d.streamed))
+	}
+
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(d.newValue(v.Type().Elem())) // v = new(T).
+		}
+	} else {
+		v = v.Addr()
+	}
+	r := &stringReader{raw: d.streamed[1 : len(d.streamed)-1]}
+	err := v.Interface().(StreamUnmarshaler).UnmarshalGraphQLStream(r)
+	r.closed = true
+	if err != nil {
+		return fmt.Errorf("%v at %q: %w", v.Type(), d.currentPath(), err)
+	}
+
+	return nil
+}
+
+// truncate returns the start of a JSON value for an error.
+func truncate(raw json.RawMessage) string {
+	if len(raw) > 16 {
+		return string(raw[:16]) + "..."
+	}
+
+	return string(raw)
+}
+
+// errStreamClosed is the error of a stream read after the call it was passed to.
+var errStreamClosed = errors.New("graphqljson: stream read after UnmarshalGraphQLStream returned")
+
+// stringReader reads the content of a JSON string, raw being the bytes between its quotes, unescaping it as it is read.
+type stringReader struct {
+	raw []byte
+	// pending is the rest of the UTF-8 encoding of the last escaped rune, which did not fit the buffer read.
+	pending []byte
+	// closed is set once the call the reader was passed to returned.
+	closed bool
+}
+
+func (r *stringReader) Read(p []byte) (int, error) {
+	if r.closed {
+		return 0, errStreamClosed
+	}
+
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	for n < len(p) && len(r.raw) > 0 {
+		if r.raw[0] != '\\' {
+			// the bytes up to the next escape are read as is
+			i := 0
+			for i < len(r.raw) && r.raw[i] != '\\' && n+i < len(p) {
+				i++
+			}
+			n += copy(p[n:], r.raw[:i])
+			r.raw = r.raw[i:]
+
+			continue
+		}
+
+		c, size, err := unescape(r.raw)
+		if err != nil {
+			return n, err
+		}
+		r.raw = r.raw[size:]
+		var encoded [utf8.UTFMax]byte
+		m := utf8.EncodeRune(encoded[:], c)
+		copied := copy(p[n:], encoded[:m])
+		n += copied
+		r.pending = append(r.pending[:0], encoded[copied:m]...)
+	}
+
+	if n == 0 && len(r.raw) == 0 && len(r.pending) == 0 {
+		return 0, io.EOF
+	}
+
+	return n, nil
+}
+
+// unescape returns the rune of the escape sequence at the start of raw and the size of the sequence,
+// the lone surrogates of \u sequences being replaced by utf8.RuneError as encoding/json does.
+func unescape(raw []byte) (rune, int, error) {
+	if len(raw) < 2 {
+		return 0, 0, errors.New("truncated escape sequence")
+	}
+
+	switch raw[1] {
+	case '"', '\\', '/':
+		return rune(raw[1]), 2, nil
+	case 'b':
+		return '\b', 2, nil
+	case 'f':
+		return '\f', 2, nil
+	case 'n':
+		return '\n', 2, nil
+	case 'r':
+		return '\r', 2, nil
+	case 't':
+		return '\t', 2, nil
+	case 'u':
+		c, ok := hexRune(raw[2:])
+		if !ok {
+			return 0, 0, fmt.Errorf("invalid escape sequence %q", raw[:min(len(raw), 6)])
+		}
+		if !utf16.IsSurrogate(c) {
+			return c, 6, nil
+		}
+		if len(raw) >= 12 && raw[6] == '\\' && raw[7] == 'u' {
+			if low, ok := hexRune(raw[8:]); ok {
+				if pair := utf16.DecodeRune(c, low); pair != utf8.RuneError {
+					return pair, 12, nil
+				}
+			}
+		}
+
+		return utf8.RuneError, 6, nil
+	}
+
+	return 0, 0, fmt.Errorf("invalid escape sequence %q", raw[:2])
+}
+
+// hexRune returns the rune of the 4 hexadecimal digits at the start of raw.
+func hexRune(raw []byte) (rune, bool) {
+	if len(raw) < 4 {
+		return 0, false
+	}
+	c, err := strconv.ParseUint(string(raw[:4]), 16, 16)
+	if err != nil {
+		return 0, false
+	}
+
+	return rune(c), true
+}
@@ -0,0 +1,204 @@
+package graphqljson
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+)
+
+// readFirst reads the first token of the value decoded into typ, failing on empty input
+// and on a token which cannot start a value of typ, like a string for a struct,
+// rather than with the errors of the decoding of the tokens after it.
+func (d *Decoder) readFirst(typ reflect.Type) error {
+	tok, err := d.jsonDecoder.Token()
+	if err == io.EOF {
+		return fmt.Errorf("empty JSON input: %w", d.truncated())
+	}
+	if err != nil {
+		return d.readError(err)
+	}
+	d.countToken()
+
+	if expected, typ := expectedKind(typ); expected != "" && tok != nil && tokenKind(tok) != expected {
+		return fmt.Errorf("expected %s for %s target %v, got %s", expected, typ.Kind(), typ, tokenKind(tok))
+	}
+
+	d.first, d.pendingFirst = tok, true
+
+	return nil
+}
+
+// token returns the first token read by readFirst, then the next token of the input.
+func (d *Decoder) token() (json.Token, error) {
+	if d.pendingFirst {
+		d.pendingFirst = false
+
+		return d.first, nil
+	}
+
+	tok, err := d.jsonDecoder.Token()
+	if err == nil {
+		d.countToken()
+	}
+
+	return tok, err
+}
+
+// expectedKind returns the kind of the JSON values decoded into typ, object or array, and the type pointed by typ.
+// The kind is empty for the other types and for the types decoding themselves, null being accepted by all.
+func expectedKind(typ reflect.Type) (string, reflect.Type) {
+	for {
+		if decodesItself(typ) || reflect.PtrTo(typ).Implements(scannerType) {
+			return "", typ
+		}
+		if typ.Kind() != reflect.Ptr {
+			break
+		}
+		typ = typ.Elem()
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct, reflect.Map:
+		return "object", typ
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			// encoding/json decodes []byte from base64 strings
+			return "", typ
+		}
+
+		return "array", typ
+	case reflect.Array:
+		return "array", typ
+	}
+
+	return "", typ
+}
+
+// tokenKind returns the kind of the JSON value starting with tok.
+func tokenKind(tok json.Token) string {
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok == objectBeginToken {
+			return "object"
+		}
+
+		return "array"
+	case string:
+		return "string"
+	case json.Number, float64:
+		return "number"
+	case bool:
+		return "bool"
+	}
+
+	return "null"
+}
+
+// decodeDynamic decodes the value into v by encoding/json when v is a *map[string]interface{} or an *interface{},
+// bypassing the matching of the fields the value has none of, and reports whether it did. The numbers are json.Number
+// as for the map and interface{} fields, and the maximum depth is checked once the value is decoded.
+func (d *Decoder) decodeDynamic(v interface{}) (bool, error) {
+	switch v := v.(type) {
+	case *map[string]interface{}:
+		// decoded as an interface{} to fail on the other values as readFirst
+		var value interface{}
+		if err := d.decodeValue(&value); err != nil {
+			return true, err
+		}
+		switch value := value.(type) {
+		case map[string]interface{}:
+			*v = value
+		case nil:
+			*v = nil
+		default:
+			return true, fmt.Errorf("expected object for map target %T, got %s", *v, valueKind(value))
+		}
+	case *interface{}:
+		*v = nil
+		if err := d.decodeValue(v); err != nil {
+			return true, err
+		}
+	default:
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// decodeValue decodes the next JSON value into v by encoding/json.
+func (d *Decoder) decodeValue(v *interface{}) error {
+	if err := d.jsonDecoder.Decode(v); err != nil {
+		if err == io.EOF {
+			return fmt.Errorf("empty JSON input: %w", d.truncated())
+		}
+
+		return d.readError(err)
+	}
+	d.countToken()
+	if d.maxDepth <= 0 {
+		return nil
+	}
+	if path, ok := exceedingPath(*v, 1, d.maxDepth, ""); ok {
+		return &inputError{err: fmt.Errorf("maximum depth %d exceeded at %q", d.maxDepth, path)}
+	}
+
+	return nil
+}
+
+// exceedingPath returns the path of the first object or array nested in value deeper than maxDepth, depth being the one
+// of value, and whether there is one. The keys of objects are walked sorted.
+func exceedingPath(value interface{}, depth, maxDepth int, path string) (string, bool) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			keyPath := key
+			if path != "" {
+				keyPath = path + "." + key
+			}
+			if found, ok := nestedPath(value[key], depth, maxDepth, keyPath); ok {
+				return found, true
+			}
+		}
+	case []interface{}:
+		for i, element := range value {
+			if found, ok := nestedPath(element, depth, maxDepth, fmt.Sprintf("%s[%d]", path, i)); ok {
+				return found, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// nestedPath returns the path of the first object or array deeper than maxDepth of value, nested at path in a value of depth.
+func nestedPath(value interface{}, depth, maxDepth int, path string) (string, bool) {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		if depth >= maxDepth {
+			return path, true
+		}
+
+		return exceedingPath(value, depth+1, maxDepth, path)
+	}
+
+	return "", false
+}
+
+// valueKind returns the kind of the JSON value decoded into value by encoding/json.
+func valueKind(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+
+	return tokenKind(value)
+}
@@ -0,0 +1,68 @@
+package graphqljson
+
+import (
+	"reflect"
+	"strings"
+)
+
+// AddTransform makes Decode call fn with each scalar value decoded at path, after decoding it,
+// to put the values in a canonical form like trimmed or normalized strings. The path is the one of the errors,
+// like "user.friends[2].name", where [*] matches any index, like "user.friends[*].name".
+// fn is given the value of the field, the pointed value for a pointer, and is not called for null.
+// The values of maps and interface{} fields, decoded at once, are not transformed.
+func (d *Decoder) AddTransform(path string, fn func(v reflect.Value)) {
+	d.transforms = append(d.transforms, transform{path: path, fn: fn})
+}
+
+// WithTransform makes UnmarshalData call fn with each scalar value decoded at path, see Decoder.AddTransform.
+func WithTransform(path string, fn func(v reflect.Value)) Option {
+	return func(d *Decoder) {
+		d.AddTransform(path, fn)
+	}
+}
+
+// transform is a function transforming the scalar values decoded at a path.
+type transform struct {
+	path string
+	fn   func(v reflect.Value)
+}
+
+// transformValue calls the transforms of the current path with the scalar value v.
+func (d *Decoder) transformValue(v reflect.Value) {
+	if len(d.transforms) == 0 {
+		return
+	}
+
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	path := joinPath(d.pathPrefix, d.currentPath())
+	for _, t := range d.transforms {
+		if matchPath(t.path, path) {
+			t.fn(v)
+		}
+	}
+}
+
+// matchPath reports whether path matches pattern, whose [*] match any index.
+func matchPath(pattern, path string) bool {
+	for {
+		i := strings.Index(pattern, "[*]")
+		if i == -1 {
+			return pattern == path
+		}
+		if !strings.HasPrefix(path, pattern[:i]+"[") {
+			return false
+		}
+
+		end := strings.IndexByte(path[i:], ']')
+		if end == -1 {
+			return false
+		}
+		pattern, path = pattern[i+len("[*]"):], path[i+end+1:]
+	}
+}
@@ -0,0 +1,106 @@
+package graphqljson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrTruncatedResponse is matched by errors.Is for the errors of JSON input ending in the middle of a value,
+// like a response body cut by a dropped connection, to tell them apart from the data not matching the query.
+var ErrTruncatedResponse = errors.New("truncated response")
+
+// TruncatedResponseError is the error of JSON input ending in the middle of a value.
+type TruncatedResponseError struct {
+	// Read is the number of bytes of the complete JSON tokens read before the end of the input.
+	Read int64
+	// Path is the path of the value read when the input ended, like "user.friends[2].name", empty at the top level.
+	Path string
+}
+
+func (e *TruncatedResponseError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("unexpected end of JSON input after %d bytes", e.Read)
+	}
+
+	return fmt.Sprintf("unexpected end of JSON input at %q after %d bytes", e.Path, e.Read)
+}
+
+// Is reports whether target is ErrTruncatedResponse.
+func (e *TruncatedResponseError) Is(target error) bool {
+	return target == ErrTruncatedResponse
+}
+
+// CheckTruncated returns a *TruncatedResponseError if data ends in the middle of its top-level JSON value, nil otherwise.
+// Other syntax errors are not reported, decoding data reports them.
+func CheckTruncated(data []byte) error {
+	d := NewDecoder(bytes.NewReader(data))
+
+	// whether the next token of the current object is the value of its current key
+	value := false
+	for started := false; !started || len(d.parseState) > 0; started = true {
+		tok, err := d.jsonDecoder.Token()
+		if err != nil {
+			if isEndOfInput(err) {
+				return d.truncated()
+			}
+
+			return nil
+		}
+
+		switch {
+		case d.insideObject(tok) && !value:
+			if key, ok := tok.(string); ok {
+				d.path[len(d.path)-1].key = key
+			}
+			value = true
+
+			continue
+		case d.insideArray(tok):
+			d.path[len(d.path)-1].index++
+		}
+		value = false
+
+		switch tok {
+		case objectBeginToken, arrayBeginToken:
+			if err := d.pushState(tok.(json.Delim)); err != nil {
+				return nil
+			}
+		case objectEndToken, arrayEndToken:
+			d.popState()
+		}
+	}
+
+	return nil
+}
+
+// readError returns the error of reading the JSON input, a *TruncatedResponseError if it ended in the middle of a value.
+func (d *Decoder) readError(err error) error {
+	if isEndOfInput(err) {
+		return &inputError{err: d.truncated()}
+	}
+
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) {
+		// the value was read, the input goes on
+		return fmt.Errorf(": %w", err)
+	}
+
+	return &inputError{err: fmt.Errorf(": %w", err)}
+}
+
+// truncated returns the error of the input ending at the current path.
+func (d *Decoder) truncated() error {
+	return &TruncatedResponseError{
+		Read: d.jsonDecoder.InputOffset(),
+		Path: d.currentPath(),
+	}
+}
+
+// isEndOfInput reports whether err is the JSON tokenizer reaching the end of its input,
+// between tokens or in the middle of one.
+func isEndOfInput(err error) bool {
+	return err == io.EOF || err == io.ErrUnexpectedEOF
+}
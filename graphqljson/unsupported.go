@@ -0,0 +1,36 @@
+package graphqljson
+
+import "reflect"
+
+// SetSkipUnsupported makes Decode skip the values of the keys of struct fields of kinds JSON does not decode into,
+// func, chan, complex and unsafe.Pointer, or slices, arrays or pointers of them, like the callbacks of a struct reused from other code,
+// as if the struct had no such field. By default a value for these fields fails with an error naming the field and its kind.
+// The fields of types decoding themselves are decoded by their UnmarshalJSON whatever their kind.
+func (d *Decoder) SetSkipUnsupported(skip bool) {
+	d.skipUnsupported = skip
+}
+
+// WithSkipUnsupported makes UnmarshalData skip the values of the fields of unsupported kinds, see Decoder.SetSkipUnsupported.
+func WithSkipUnsupported() Option {
+	return func(d *Decoder) {
+		d.SetSkipUnsupported(true)
+	}
+}
+
+// unsupportedKind returns the kind of the values of typ JSON does not decode into, following pointers, slices and arrays,
+// and whether there is one.
+func unsupportedKind(typ reflect.Type) (reflect.Kind, bool) {
+	for {
+		if decodesItself(typ) {
+			return reflect.Invalid, false
+		}
+		switch typ.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array:
+			typ = typ.Elem()
+		case reflect.Func, reflect.Chan, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+			return typ.Kind(), true
+		default:
+			return reflect.Invalid, false
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package introspection
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// builtInScalars are defined by every schema, an introspection result lists them as any other type.
+// Definitions parsed from an introspection result are all marked built-in, so they are told apart by name.
+var builtInScalars = map[string]bool{
+	"Int":     true,
+	"Float":   true,
+	"String":  true,
+	"Boolean": true,
+	"ID":      true,
+}
+
+// SchemaHash returns the hex encoded sha256 of a canonical description of schema.
+//
+// The description has one line per root operation type, then per type sorted by name
+// with its kind, sorted interfaces and possible types, and its fields, arguments,
+// input fields or enum values sorted by name. Descriptions, directives, default values,
+// built-in scalars and introspection types are left out, so the same schema loaded from
+// files or by introspection has the same hash.
+func SchemaHash(schema *ast.Schema) string {
+	var lines []string
+	for _, operation := range []struct {
+		name       string
+		definition *ast.Definition
+	}{
+		{"query", schema.Query},
+		{"mutation", schema.Mutation},
+		{"subscription", schema.Subscription},
+	} {
+		if operation.definition != nil {
+			lines = append(lines, fmt.Sprintf("schema %s: %s", operation.name, operation.definition.Name))
+		}
+	}
+
+	names := make([]string, 0, len(schema.Types))
+	for name := range schema.Types {
+		if builtInScalars[name] || strings.HasPrefix(name, "__") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		definition := schema.Types[name]
+		line := fmt.Sprintf("%s %s", definition.Kind, name)
+		if len(definition.Interfaces) > 0 {
+			line += " implements " + sortedJoin(definition.Interfaces, " & ")
+		}
+		if len(definition.Types) > 0 {
+			line += " = " + sortedJoin(definition.Types, " | ")
+		}
+		lines = append(lines, line)
+
+		fields := make([]string, 0, len(definition.Fields))
+		for _, field := range definition.Fields {
+			if strings.HasPrefix(field.Name, "__") {
+				continue
+			}
+			arguments := make([]string, 0, len(field.Arguments))
+			for _, argument := range field.Arguments {
+				arguments = append(arguments, fmt.Sprintf("%s: %s", argument.Name, argument.Type.String()))
+			}
+			sort.Strings(arguments)
+			fields = append(fields, fmt.Sprintf("  %s(%s): %s", field.Name, strings.Join(arguments, ", "), field.Type.String()))
+		}
+		for _, value := range definition.EnumValues {
+			fields = append(fields, "  "+value.Name)
+		}
+		sort.Strings(fields)
+		lines = append(lines, fields...)
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func sortedJoin(values []string, sep string) string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+
+	return strings.Join(sorted, sep)
+}
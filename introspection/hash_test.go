@@ -0,0 +1,60 @@
+package introspection
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/pleclech/gqlgenc/graphqljson"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/validator"
+)
+
+func TestSchemaHash(t *testing.T) {
+	t.Parallel()
+
+	sdl, err := ioutil.ReadFile("testdata/hash.graphql")
+	require.NoError(t, err)
+	loadSDL := func(t *testing.T, input string) *ast.Schema {
+		t.Helper()
+		schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Name: "hash.graphql", Input: input})
+		require.Nil(t, gqlErr)
+
+		return schema
+	}
+	want := SchemaHash(loadSDL(t, string(sdl)))
+
+	t.Run("same schema by introspection", func(t *testing.T) {
+		t.Parallel()
+		// the introspection result is decoded as the response of the introspection query, by its graphql tags
+		data, err := ioutil.ReadFile("testdata/introspection_result_hash.json")
+		require.NoError(t, err)
+		var query Query
+		require.NoError(t, graphqljson.UnmarshalData(data, &query))
+
+		schema, gqlErr := validator.ValidateSchemaDocument(ParseIntrospectionQuery("test", query))
+		require.Nil(t, gqlErr)
+		require.Equal(t, want, SchemaHash(schema))
+	})
+
+	t.Run("descriptions and directives", func(t *testing.T) {
+		t.Parallel()
+		input := strings.Replace(string(sdl), "type User implements Node {", `"A user" type User implements Node @deprecated {`, 1)
+		input = strings.Replace(input, "name: String\n    role", "name: String @deprecated(reason: \"gone\")\n    role", 1)
+		require.Equal(t, want, SchemaHash(loadSDL(t, input)))
+	})
+
+	t.Run("changed field", func(t *testing.T) {
+		t.Parallel()
+		input := strings.Replace(string(sdl), "name: String\n    role", "name: String!\n    role", 1)
+		require.NotEqual(t, want, SchemaHash(loadSDL(t, input)))
+	})
+
+	t.Run("new enum value", func(t *testing.T) {
+		t.Parallel()
+		input := strings.Replace(string(sdl), "ADMIN\n", "ADMIN\n    GUEST\n", 1)
+		require.NotEqual(t, want, SchemaHash(loadSDL(t, input)))
+	})
+}
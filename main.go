@@ -2,14 +2,16 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"os"
 
 	"github.com/99designs/gqlgen/api"
-	"github.com/Yamashou/gqlgenc/clientgen"
-	"github.com/Yamashou/gqlgenc/clientgenv2"
-	"github.com/Yamashou/gqlgenc/config"
-	"github.com/Yamashou/gqlgenc/generator"
+	"github.com/pleclech/gqlgenc/clientgen"
+	"github.com/pleclech/gqlgenc/clientgenv2"
+	"github.com/pleclech/gqlgenc/config"
+	"github.com/pleclech/gqlgenc/generator"
+	"github.com/pleclech/gqlgenc/verify"
 )
 
 func main() {
@@ -20,6 +22,10 @@ func main() {
 		os.Exit(2)
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		os.Exit(runVerify(cfg, os.Args[2:]))
+	}
+
 	clientGen := api.AddPlugin(clientgen.New(cfg.Query, cfg.Client, cfg.Generate))
 	if cfg.Generate != nil {
 		if cfg.Generate.ClientV2 {
@@ -32,3 +38,31 @@ func main() {
 		os.Exit(4)
 	}
 }
+
+// runVerify reports the generated operations which are never called by the packages given in args.
+// The unused operation names are written one per line on stdout and a summary on stderr.
+func runVerify(cfg *config.Config, args []string) int {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	fail := flags.Bool("fail", false, "exit with a non zero code when an operation is never called")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	report, err := verify.UnusedOperations(".", cfg.Client.ImportPath(), flags.Args()...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%+v", err.Error())
+
+		return 5
+	}
+
+	for _, operation := range report.Unused {
+		fmt.Fprintln(os.Stdout, operation)
+	}
+	fmt.Fprintln(os.Stderr, report.Summary())
+
+	if *fail && report.HasUnused() {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,38 @@
+// Package plugin defines the code generators run by the client generator after it generated the client,
+// like the plugins of gqlgen, to generate other files from the schema and the operations.
+package plugin
+
+import (
+	"github.com/99designs/gqlgen/codegen/config"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Plugin is a code generator given the schema and the operations of the generated client.
+// The plugins registered with clientgenv2.New run in their order of registration.
+type Plugin interface {
+	Name() string
+	GenerateCode(data *GenerateData) error
+}
+
+// GenerateData is the data of the generated client.
+type GenerateData struct {
+	// Schema is the schema the client is generated from.
+	Schema *ast.Schema
+	// QueryDocument has all the operations and fragments of the query files.
+	QueryDocument *ast.QueryDocument
+	// Operations are the operations of the client, in the order of the query files.
+	Operations []*Operation
+	// Client is the package of the generated client.
+	Client config.PackageConfig
+}
+
+// Operation is an operation of the generated client.
+type Operation struct {
+	Name string
+	// Definition is the operation in QueryDocument.
+	Definition *ast.OperationDefinition
+	// Document has the operation and the fragments it uses.
+	Document *ast.QueryDocument
+	// Query is the document sent by the generated client.
+	Query string
+}
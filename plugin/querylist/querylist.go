@@ -0,0 +1,46 @@
+// Package querylist is a sample plugin writing the document of each operation in a JSON file,
+// like an allowlist of the operations a server runs for the client.
+package querylist
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/pleclech/gqlgenc/plugin"
+)
+
+var _ plugin.Plugin = &Plugin{}
+
+// Plugin writes a JSON object mapping the name of each operation to its document.
+type Plugin struct {
+	filename string
+}
+
+// New returns the plugin writing the query list to filename.
+func New(filename string) *Plugin {
+	return &Plugin{filename: filename}
+}
+
+func (p *Plugin) Name() string {
+	return "querylist"
+}
+
+func (p *Plugin) GenerateCode(data *plugin.GenerateData) error {
+	queries := make(map[string]string, len(data.Operations))
+	for _, operation := range data.Operations {
+		queries[operation.Name] = operation.Query
+	}
+
+	// encoding/json sorts the keys, the file is the same from one generation to the next
+	content, err := json.MarshalIndent(queries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode query list failed: %w", err)
+	}
+
+	if err := ioutil.WriteFile(p.filename, append(content, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write %s failed: %w", p.filename, err)
+	}
+
+	return nil
+}
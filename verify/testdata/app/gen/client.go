@@ -0,0 +1,33 @@
+package gen
+
+import "context"
+
+type Client struct{}
+
+func NewClient() *Client {
+	return &Client{}
+}
+
+type GetUser struct{}
+
+const GetUserDocument = `query GetUser { viewer { id } }`
+
+func (c *Client) GetUser(ctx context.Context) (*GetUser, error) {
+	return &GetUser{}, nil
+}
+
+type ListRepositories struct{}
+
+const ListRepositoriesDocument = `query ListRepositories { viewer { repositories { id } } }`
+
+func (c *Client) ListRepositories(ctx context.Context) (*ListRepositories, error) {
+	return &ListRepositories{}, nil
+}
+
+type DeleteRepository struct{}
+
+const DeleteRepositoryDocument = `mutation DeleteRepository { deleteRepository { id } }`
+
+func (c *Client) DeleteRepository(ctx context.Context) (*DeleteRepository, error) {
+	return &DeleteRepository{}, nil
+}
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"context"
+
+	"github.com/pleclech/gqlgenc/verify/testdata/app/gen"
+)
+
+func main() {
+	_, _ = gen.NewClient().GetUser(context.Background())
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pleclech/gqlgenc/verify/testdata/app/gen"
+)
+
+func TestDelete(t *testing.T) {
+	if _, err := gen.NewClient().DeleteRepository(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
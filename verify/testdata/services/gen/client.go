@@ -0,0 +1,67 @@
+package gen
+
+import "context"
+
+type Client struct {
+	Orders *OrdersService
+	Users  *UsersService
+}
+
+func NewClient() *Client {
+	c := &Client{}
+	c.Orders = &OrdersService{client: c}
+	c.Users = &UsersService{client: c}
+
+	return c
+}
+
+type OrdersService struct {
+	client *Client
+}
+
+type UsersService struct {
+	client *Client
+}
+
+type Viewer struct{}
+
+const ViewerDocument = `query Viewer { viewer { id } }`
+
+func (c *Client) Viewer(ctx context.Context) (*Viewer, error) {
+	return &Viewer{}, nil
+}
+
+type GetOrder struct{}
+
+const GetOrderDocument = `query GetOrder { order { id } }`
+
+func (s *OrdersService) GetOrder(ctx context.Context) (*GetOrder, error) {
+	return &GetOrder{}, nil
+}
+
+type CancelOrder struct{}
+
+const CancelOrderDocument = `mutation CancelOrder { cancelOrder { id } }`
+
+func (s *OrdersService) CancelOrder(ctx context.Context) (*CancelOrder, error) {
+	return &CancelOrder{}, nil
+}
+
+type GetUser struct {
+	User *GetUser_User
+}
+
+// GetUser_User has a method named as an operation, which is not one.
+type GetUser_User struct {
+	viewer *Viewer
+}
+
+func (t *GetUser_User) Viewer() *Viewer {
+	return t.viewer
+}
+
+const GetUserDocument = `query GetUser { user { id } }`
+
+func (s *UsersService) GetUser(ctx context.Context) (*GetUser, error) {
+	return &GetUser{User: &GetUser_User{}}, nil
+}
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"context"
+
+	"github.com/pleclech/gqlgenc/verify/testdata/services/gen"
+)
+
+func main() {
+	c := gen.NewClient()
+	if res, err := c.Users.GetUser(context.Background()); err == nil {
+		_ = res.User.Viewer()
+	}
+	_, _ = c.Orders.GetOrder(context.Background())
+}
@@ -0,0 +1,56 @@
+package gen
+
+import (
+	"context"
+	"encoding/json"
+)
+
+type Client struct{}
+
+type ListUsers struct{}
+
+const ListUsersDocument = `query ListUsers ($first: Int, $after: String) { users(first: $first, after: $after) { nodes { id } } }`
+
+func (c *Client) ListUsers(ctx context.Context, first *int, after *string) (*ListUsers, error) {
+	return &ListUsers{}, nil
+}
+
+type ListUsersIterator struct{}
+
+func (c *Client) ListUsersIterator(ctx context.Context, pageSize int) *ListUsersIterator {
+	return &ListUsersIterator{}
+}
+
+type GetUser struct{}
+
+const GetUserDocument = `query GetUser { user { id } }`
+
+func (c *Client) GetUser(ctx context.Context) (*GetUser, error) {
+	return &GetUser{}, nil
+}
+
+func (c *Client) GetUserRaw(ctx context.Context) (json.RawMessage, error) {
+	return nil, nil
+}
+
+type Rename struct{}
+
+const RenameDocument = `mutation Rename { rename { id } }`
+
+func (c *Client) Rename(ctx context.Context) (*Rename, error) {
+	return &Rename{}, nil
+}
+
+type RenameResult struct{}
+
+func (c *Client) RenameResult(ctx context.Context) (*RenameResult, error) {
+	return &RenameResult{}, nil
+}
+
+type Viewer struct{}
+
+const ViewerDocument = `query Viewer { viewer { id } }`
+
+func (c *Client) Viewer(ctx context.Context) (*Viewer, error) {
+	return &Viewer{}, nil
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"context"
+
+	"github.com/pleclech/gqlgenc/verify/testdata/wrappers/gen"
+)
+
+func main() {
+	c := &gen.Client{}
+	_ = c.ListUsersIterator(context.Background(), 10)
+	_, _ = c.GetUserRaw(context.Background())
+	_, _ = c.RenameResult(context.Background())
+}
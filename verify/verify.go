@@ -0,0 +1,206 @@
+// Package verify reports the generated client operations that are never
+// called from the user's code so that dead queries can be pruned.
+package verify
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// clientTypeName is the name of the struct generated by clientgen and clientgenv2
+const clientTypeName = "Client"
+
+// documentSuffix is appended to an operation's method name to name its query constant
+const documentSuffix = "Document"
+
+// wrapperSuffixes are appended to an operation's method name to name the methods calling it,
+// its pagination iterator, its raw method and its result type method
+var wrapperSuffixes = []string{"Iterator", "Raw", "Result"}
+
+// Report is the result of an unused operation scan
+type Report struct {
+	// Operations lists every operation method generated on the client
+	Operations []string `json:"operations"`
+	// Unused lists the operation methods which are never referenced by the scanned packages
+	Unused []string `json:"unused"`
+}
+
+// HasUnused returns true when at least one generated operation is never called
+func (r *Report) HasUnused() bool {
+	return len(r.Unused) > 0
+}
+
+// UnusedOperations loads the packages matched by patterns with their tests
+// and reports the operations of the generated client package clientPkgPath
+// which are never referenced.
+//
+// An operation is a method of the generated Client type, or of the service types
+// its fields point to with services, backed by a <Name>Document constant in the same package.
+// Calling one of its wrappers, like <Name>Iterator, <Name>Raw or <Name>Result, uses it.
+func UnusedOperations(dir, clientPkgPath string, patterns ...string) (*Report, error) {
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	cfg := &packages.Config{
+		Mode:  packages.NeedName | packages.NeedTypes | packages.NeedTypesSizes | packages.NeedTypesInfo | packages.NeedSyntax | packages.NeedImports | packages.NeedDeps,
+		Dir:   dir,
+		Tests: true,
+	}
+	pkgs, err := packages.Load(cfg, append(patterns, clientPkgPath)...)
+	if err != nil {
+		return nil, fmt.Errorf("load packages failed: %w", err)
+	}
+
+	if n := packages.PrintErrors(pkgs); n > 0 {
+		return nil, fmt.Errorf("%d errors while loading packages", n)
+	}
+
+	operations, methods, typeNames, err := generatedOperations(pkgs, clientPkgPath)
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[string]bool)
+	for _, pkg := range pkgs {
+		if pkg.PkgPath == clientPkgPath {
+			// the generated package never calls its own operations
+			continue
+		}
+
+		for _, obj := range pkg.TypesInfo.Uses {
+			if name, ok := operationMethodName(obj, clientPkgPath, typeNames); ok && methods[name] != "" {
+				used[methods[name]] = true
+			}
+		}
+	}
+
+	report := &Report{
+		Operations: operations,
+		Unused:     []string{},
+	}
+	for _, operation := range operations {
+		if !used[operation] {
+			report.Unused = append(report.Unused, operation)
+		}
+	}
+
+	return report, nil
+}
+
+// generatedOperations returns the sorted operation method names of the generated client, the operation
+// of each of its operation methods and their wrappers, and the names of the types having them, the Client and its services.
+func generatedOperations(pkgs []*packages.Package, clientPkgPath string) ([]string, map[string]string, map[string]bool, error) {
+	var clientPkg *types.Package
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if clientPkg == nil && pkg.PkgPath == clientPkgPath && pkg.Types != nil {
+			clientPkg = pkg.Types
+		}
+	})
+
+	if clientPkg == nil {
+		return nil, nil, nil, fmt.Errorf("generated client package %s not found", clientPkgPath)
+	}
+
+	obj := clientPkg.Scope().Lookup(clientTypeName)
+	if obj == nil {
+		return nil, nil, nil, fmt.Errorf("%s not found in %s, was the client generated?", clientTypeName, clientPkgPath)
+	}
+
+	named, ok := obj.Type().(*types.Named)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("%s.%s is not a named type", clientPkgPath, clientTypeName)
+	}
+
+	operationTypes := append([]*types.Named{named}, serviceTypes(named)...)
+	typeNames := make(map[string]bool, len(operationTypes))
+	operations := []string{}
+	methods := make(map[string]string)
+	isOperation := func(name string) bool {
+		_, ok := clientPkg.Scope().Lookup(name + documentSuffix).(*types.Const)
+
+		return ok
+	}
+	for _, operationType := range operationTypes {
+		typeNames[operationType.Obj().Name()] = true
+		for i := 0; i < operationType.NumMethods(); i++ {
+			name := operationType.Method(i).Name()
+			if isOperation(name) {
+				operations = append(operations, name)
+				methods[name] = name
+
+				continue
+			}
+			for _, suffix := range wrapperSuffixes {
+				if operation := strings.TrimSuffix(name, suffix); operation != name && isOperation(operation) {
+					methods[name] = operation
+				}
+			}
+		}
+	}
+	sort.Strings(operations)
+
+	return operations, methods, typeNames, nil
+}
+
+// serviceTypes returns the service types of the generated client, the types of its package its fields point to,
+// like UsersService for Users *UsersService.
+func serviceTypes(client *types.Named) []*types.Named {
+	s, ok := client.Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+
+	var services []*types.Named
+	for i := 0; i < s.NumFields(); i++ {
+		ptr, ok := s.Field(i).Type().(*types.Pointer)
+		if !ok {
+			continue
+		}
+		if service, ok := ptr.Elem().(*types.Named); ok && service.Obj().Pkg() == client.Obj().Pkg() && service != client {
+			services = append(services, service)
+		}
+	}
+
+	return services
+}
+
+// operationMethodName returns the method name when obj is a method of the generated client or of one of its services,
+// the types of typeNames. Objects are compared by path and name because the test variants of a package
+// are type checked separately.
+func operationMethodName(obj types.Object, clientPkgPath string, typeNames map[string]bool) (string, bool) {
+	fn, ok := obj.(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != clientPkgPath {
+		return "", false
+	}
+
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil {
+		return "", false
+	}
+
+	recv := sig.Recv().Type()
+	if ptr, ok := recv.(*types.Pointer); ok {
+		recv = ptr.Elem()
+	}
+
+	named, ok := recv.(*types.Named)
+	if !ok || !typeNames[named.Obj().Name()] {
+		return "", false
+	}
+
+	return fn.Name(), true
+}
+
+// Summary returns a human readable description of the report
+func (r *Report) Summary() string {
+	if !r.HasUnused() {
+		return fmt.Sprintf("all %d generated operations are used", len(r.Operations))
+	}
+
+	return fmt.Sprintf("%d of %d generated operations are never called: %s", len(r.Unused), len(r.Operations), strings.Join(r.Unused, ", "))
+}
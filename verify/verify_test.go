@@ -0,0 +1,41 @@
+package verify
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnusedOperations(t *testing.T) {
+	t.Parallel()
+
+	report, err := UnusedOperations("testdata/app", "github.com/pleclech/gqlgenc/verify/testdata/app/gen", "./...")
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"DeleteRepository", "GetUser", "ListRepositories"}, report.Operations)
+	require.Equal(t, []string{"ListRepositories"}, report.Unused)
+	require.True(t, report.HasUnused())
+	require.Equal(t, "1 of 3 generated operations are never called: ListRepositories", report.Summary())
+}
+
+func TestUnusedOperations_services(t *testing.T) {
+	t.Parallel()
+
+	// the operations of the services are counted along the ones of Client
+	report, err := UnusedOperations("testdata/services", "github.com/pleclech/gqlgenc/verify/testdata/services/gen", "./...")
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"CancelOrder", "GetOrder", "GetUser", "Viewer"}, report.Operations)
+	require.Equal(t, []string{"CancelOrder", "Viewer"}, report.Unused)
+}
+
+func TestUnusedOperations_wrappers(t *testing.T) {
+	t.Parallel()
+
+	// calling the iterator, the raw or the result type method of an operation uses it
+	report, err := UnusedOperations("testdata/wrappers", "github.com/pleclech/gqlgenc/verify/testdata/wrappers/gen", "./...")
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"GetUser", "ListUsers", "Rename", "Viewer"}, report.Operations)
+	require.Equal(t, []string{"Viewer"}, report.Unused)
+}